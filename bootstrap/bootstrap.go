@@ -2,14 +2,13 @@ package bootstrap
 
 import (
 	"context"
-	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kirychukyurii/wdeploy/internal/api"
 	"github.com/kirychukyurii/wdeploy/internal/config"
 	"github.com/kirychukyurii/wdeploy/internal/lib"
-	"github.com/kirychukyurii/wdeploy/internal/lib/file"
-	"github.com/kirychukyurii/wdeploy/internal/lib/git"
+	"github.com/kirychukyurii/wdeploy/internal/lib/deployment"
 	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/playbook"
 	"github.com/kirychukyurii/wdeploy/internal/tui"
 	"github.com/kirychukyurii/wdeploy/internal/tui/common"
 	"github.com/kirychukyurii/wdeploy/internal/tui/keymap"
@@ -17,7 +16,6 @@ import (
 	zone "github.com/lrstanley/bubblezone"
 	"go.uber.org/fx"
 	"golang.org/x/term"
-	"regexp"
 )
 
 var Module = fx.Options(
@@ -26,24 +24,17 @@ var Module = fx.Options(
 	fx.Invoke(bootstrap),
 )
 
-func bootstrap(lifecycle fx.Lifecycle, logger logger.Logger, config config.Config) {
-	var err error
-
-	tempDirPattern := regexp.MustCompile(`.*/(.*)`).FindStringSubmatch(config.PlaybookRepositoryUrl)
-	config.PlaybookTempDir, err = file.CreateTempDir(fmt.Sprintf("%s-", tempDirPattern[1]))
-	if err != nil {
+func bootstrap(lifecycle fx.Lifecycle, shutdowner fx.Shutdowner, logger logger.Logger, config config.Config) {
+	if err := playbook.Prepare(logger, &config); err != nil {
 		logger.Zap.Fatal(err)
 	}
-	logger.Zap.Infof("Created temporary directory: %s", config.PlaybookTempDir)
 
-	if err = git.CloneGitRepo(config.PlaybookRepositoryUrl, config.PlaybookTempDir); err != nil {
+	if err := checkCredentials(logger, config); err != nil {
 		logger.Zap.Fatal(err)
 	}
-	logger.Zap.Infof("Cloned Ansible code for deploying Webitel services: %s", config.PlaybookRepositoryUrl)
 
-	if config.WebitelRepositoryUser == "" || config.WebitelRepositoryPassword == "" {
-		logger.Zap.Fatal("Forbidden: repository user or password not specified")
-	}
+	// Deploys started from the TUI, the application waits for them before the playbook is cleaned up.
+	runs := deployment.NewRuns()
 
 	lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
@@ -74,25 +65,28 @@ func bootstrap(lifecycle fx.Lifecycle, logger logger.Logger, config config.Confi
 					Zone:   zone.New(),
 				}
 
-				initialModel := tui.New(c, config, logger)
+				initialModel := tui.New(c, config, logger, runs)
 
 				p := tea.NewProgram(initialModel, opts...)
 				if _, err := p.Run(); err != nil {
 					logger.Zap.Fatalf("Failed to start: %s", err.Error())
 				}
+
+				if err := shutdowner.Shutdown(); err != nil {
+					logger.Zap.Error(err)
+				}
 			}()
 
 			return nil
 		},
-		OnStop: func(context.Context) error {
+		OnStop: func(stopCtx context.Context) error {
 			logger.Zap.Info("Stopping Application")
 
-			if err := file.RemoveAll(config.PlaybookTempDir); err != nil {
-				logger.Zap.Error(err)
-			}
-			logger.Zap.Infof("Deleted temporary directory: %s", config.PlaybookTempDir)
+			// A running deploy is interrupted and its playbook directory is removed once it is over.
+			err := runs.Stop(stopCtx)
+			playbook.Cleanup(logger, config)
 
-			return nil
+			return err
 		},
 	})
 }
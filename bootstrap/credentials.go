@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/credentials"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"os"
+)
+
+// checkCredentials verifies the Webitel repository user and password against the package repository.
+// Rejected credentials are an error, a repository that is unreachable or doesn't verify them is only reported:
+// it may be reachable from the deployed hosts while it is not from here.
+func checkCredentials(logger logger.Logger, config config.Config) error {
+	if config.WebitelRepositoryUser == "" || config.WebitelRepositoryPassword == "" {
+		return errors.New("forbidden: repository user or password not specified")
+	}
+
+	if vault.IsEncrypted(config.WebitelRepositoryPassword) {
+		return vault.ErrPasswordRequired
+	}
+
+	result := credentials.NewProber().Probe(context.Background(),
+		credentials.ReleaseURL(config.WebitelRepositoryUrl, config.WebitelVersion),
+		config.WebitelRepositoryUser, config.WebitelRepositoryPassword)
+	switch result.Status {
+	case credentials.StatusOK:
+		logger.Zap.Info(result)
+	case credentials.StatusAuthFailed:
+		return errors.New(result.String())
+	default:
+		logger.Zap.Warn(result)
+		fmt.Fprintf(os.Stderr, "warning: %s\n", result)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible"
+	"github.com/kirychukyurii/wdeploy/internal/lib/deployment"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/playbook"
+	"go.uber.org/fx"
+	"os"
+	"strings"
+	"time"
+)
+
+// DeployModule runs the playbook without TUI and stops the application when it finishes.
+var DeployModule = fx.Options(
+	config.Module,
+	lib.Module,
+	fx.Invoke(deploy),
+)
+
+func deploy(lifecycle fx.Lifecycle, shutdowner fx.Shutdowner, logger logger.Logger, config config.Config) error {
+	if err := checkCredentials(logger, config); err != nil {
+		return err
+	}
+
+	if err := playbook.Prepare(logger, &config); err != nil {
+		return err
+	}
+
+	warnUnknownTags(logger, config.Tags)
+
+	// The run is aborted when the application stops before the playbook finishes, e.g. on SIGINT.
+	ctx, abort := context.WithCancel(context.Background())
+	// The error of the run, the channel is buffered so the run goroutine doesn't wait for OnStop.
+	result := make(chan error, 1)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			logger.Zap.Info("Starting headless deploy")
+
+			fmt.Printf("Playbook: %s, commit %s\n", config.PlaybookTempDir, config.PlaybookCommit)
+
+			go func() {
+				exitCode := 0
+				run, err := deployment.Run(config, logger, deployment.Options{Context: ctx, Output: os.Stdout})
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					exitCode = 1
+				}
+				if config.CheckMode {
+					fmt.Printf("Dry run, changes per host:\n%s", deployment.DryRunSummary(run.Stats))
+				}
+				if run.ID != "" {
+					fmt.Printf("Run %s %s in %s\n", run.ID, run.Status, run.Duration.Round(time.Second))
+				}
+				result <- err
+
+				if ctx.Err() != nil {
+					return
+				}
+
+				if err := shutdowner.Shutdown(fx.ExitCode(exitCode)); err != nil {
+					logger.Zap.Error(err)
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			logger.Zap.Info("Stopping headless deploy")
+			abort()
+
+			var runErr error
+			select {
+			case runErr = <-result:
+			case <-stopCtx.Done():
+				playbook.Cleanup(logger, config)
+				return fmt.Errorf("playbook didn't stop in time: %w", stopCtx.Err())
+			}
+			playbook.Cleanup(logger, config)
+
+			// Makes the application exit with a non-zero code when the deploy was interrupted.
+			if errors.Is(runErr, ansible.ErrAborted) {
+				return runErr
+			}
+
+			return nil
+		},
+	})
+
+	return nil
+}
+
+// warnUnknownTags warns about --tags values that are not Webitel services, ansible-playbook
+// silently skips every task for them.
+func warnUnknownTags(logger logger.Logger, tags string) {
+	if tags == "" {
+		return
+	}
+
+	known := make(map[string]bool, len(vars.WebitelServices))
+	for _, s := range vars.WebitelServices {
+		known[s] = true
+	}
+
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); !known[tag] {
+			logger.Zap.Warnf("Unknown service tag %q", tag)
+			fmt.Fprintf(os.Stderr, "warning: %q is not a Webitel service\n", tag)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/playbook"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	refreshCommand.Flags().StringVar(&config.DefaultConfig.PlaybookRef, "playbook-ref",
+		config.DefaultConfig.PlaybookRef, "specify branch, tag or commit of the Ansible playbook repository")
+
+	Command.AddCommand(showCommand)
+	Command.AddCommand(refreshCommand)
+	Command.AddCommand(clearCommand)
+}
+
+var Command = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cached clone of the Ansible playbook repository",
+	Args:  cobra.NoArgs,
+}
+
+var showCommand = &cobra.Command{
+	Use:          "show",
+	Short:        "Show the playbook cache",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := playbook.Cache(config.DefaultConfig)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Directory:  %s\n", info.Dir)
+		if info.Commit == "" {
+			fmt.Fprintln(out, "Repository: not cached yet")
+		} else {
+			fmt.Fprintf(out, "Repository: %s\n", info.URL)
+			fmt.Fprintf(out, "Commit:     %s\n", info.Commit)
+			fmt.Fprintf(out, "Size:       %s\n", formatSize(info.Size))
+		}
+
+		for _, dir := range info.StaleDirs {
+			fmt.Fprintf(out, "Stale:      %s\n", dir)
+		}
+
+		return nil
+	},
+}
+
+var refreshCommand = &cobra.Command{
+	Use:          "refresh",
+	Short:        "Fetch the playbook repository into the cache",
+	Example:      `wdeploy cache refresh --playbook-ref v1.2.0`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commit, err := playbook.RefreshCache(logger.NewLogger(config.DefaultConfig), config.DefaultConfig)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: commit %s\n", playbook.CacheDir(config.DefaultConfig), commit)
+
+		return nil
+	},
+}
+
+var clearCommand = &cobra.Command{
+	Use:          "clear",
+	Short:        "Remove the playbook cache and stale temporary directories",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := playbook.CollectGarbage(logger.NewLogger(config.DefaultConfig), config.DefaultConfig); err != nil {
+			return err
+		}
+
+		dir := playbook.CacheDir(config.DefaultConfig)
+		if !file.IsDir(dir) {
+			fmt.Fprintln(cmd.OutOrStdout(), "Cache is empty")
+			return nil
+		}
+
+		if err := playbook.ClearCache(config.DefaultConfig); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", dir)
+
+		return nil
+	},
+}
+
+// formatSize returns size in a human-readable form.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
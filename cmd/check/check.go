@@ -0,0 +1,68 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/hostcheck"
+	"github.com/spf13/cobra"
+	"text/tabwriter"
+	"time"
+)
+
+var timeout time.Duration
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	hostsCommand.Flags().StringVar(&config.DefaultConfig.Limit, "limit", config.DefaultConfig.Limit,
+		"check only these hosts of the inventory, comma separated")
+	hostsCommand.Flags().DurationVar(&timeout, "timeout", hostcheck.DefaultTimeout,
+		"time given to a host to accept the connection and the credentials")
+
+	Command.AddCommand(hostsCommand)
+}
+
+var Command = &cobra.Command{
+	Use:   "check",
+	Short: "Check the environment before a deploy",
+	Args:  cobra.NoArgs,
+}
+
+var hostsCommand = &cobra.Command{
+	Use:   "hosts",
+	Short: "Check that every host of the inventory accepts SSH connections",
+	Long: `Connect to every host of the inventory over SSH with the user, port, private key and password
+Ansible would use, the connection variables of the variables file take precedence over the host ones.
+Keys of ssh-agent are tried too. The command exits with a non-zero code when at least one host is not reachable`,
+	Example: `wdeploy check hosts --inventory ./hosts.yml
+wdeploy check hosts --limit node1,node2 --timeout 5s`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.New()
+		targets, err := hostcheck.Targets(cfg, cfg.Limit)
+		if err != nil {
+			return err
+		}
+
+		checker := hostcheck.New()
+		checker.Timeout = timeout
+		results := checker.Check(context.Background(), targets)
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOST\tADDRESS\tUSER\tSTATUS\tDETAIL")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Name, r.Addr(), r.User, r.Status, r.Detail)
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+
+		if n := results.Failed(); n > 0 {
+			return fmt.Errorf("check: %d of %d host(s) not reachable", n, len(results))
+		}
+
+		return nil
+	},
+}
@@ -3,14 +3,39 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/cache"
+	"github.com/kirychukyurii/wdeploy/cmd/check"
+	"github.com/kirychukyurii/wdeploy/cmd/config"
+	"github.com/kirychukyurii/wdeploy/cmd/deploy"
+	"github.com/kirychukyurii/wdeploy/cmd/diff"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/cmd/history"
+	"github.com/kirychukyurii/wdeploy/cmd/inventory"
+	"github.com/kirychukyurii/wdeploy/cmd/login"
 	"github.com/kirychukyurii/wdeploy/cmd/man"
+	"github.com/kirychukyurii/wdeploy/cmd/profile"
 	"github.com/kirychukyurii/wdeploy/cmd/run"
+	"github.com/kirychukyurii/wdeploy/cmd/summary"
+	"github.com/kirychukyurii/wdeploy/cmd/templates"
+	"github.com/kirychukyurii/wdeploy/cmd/validate"
 	"github.com/spf13/cobra"
 	"os"
 )
 
 func init() {
 	Command.AddCommand(run.Command)
+	Command.AddCommand(deploy.Command)
+	Command.AddCommand(validate.Command)
+	Command.AddCommand(check.Command)
+	Command.AddCommand(cache.Command)
+	Command.AddCommand(login.Command)
+	Command.AddCommand(history.Command)
+	Command.AddCommand(diff.Command)
+	Command.AddCommand(config.Command)
+	Command.AddCommand(inventory.Command)
+	Command.AddCommand(summary.Command)
+	Command.AddCommand(profile.Command)
+	Command.AddCommand(templates.Command)
 	Command.AddCommand(man.Command)
 }
 
@@ -36,8 +61,14 @@ Just specify needed variables and hosts configuration in TUI and take a coffee,
 		}
 		return nil
 	},
-	PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
-	Run:               func(cmd *cobra.Command, args []string) {},
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if err := flags.CheckProfile(); err != nil {
+			return err
+		}
+
+		return flags.CheckDeployType(cmd.Flags())
+	},
+	Run: func(cmd *cobra.Command, args []string) {},
 }
 
 func Execute() {
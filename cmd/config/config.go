@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/configdiff"
+	"github.com/kirychukyurii/wdeploy/internal/lib/yamldoc"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var restore bool
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	revisionsCommand.Flags().BoolVar(&restore, "restore", false,
+		"restore the revision, the current content is kept as a revision")
+
+	Command.AddCommand(getCommand, setCommand, unsetCommand, revisionsCommand)
+}
+
+var Command = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the variables and inventory files",
+	Args:  cobra.NoArgs,
+}
+
+var getCommand = &cobra.Command{
+	Use:   "get <vars|inventory> <path>",
+	Short: "Print a value of a config file",
+	Long: `Get prints the value at the path of the variables or inventory file: a scalar as is, a mapping or a
+sequence in YAML. Keys of the path are separated by dots, a key with dots is quoted and an item of a sequence
+is its index. Secrets are masked.`,
+	Example: `wdeploy config get vars nginx_site_name
+wdeploy config get inventory 'all.hosts."node1.example.com".webitel_services'
+wdeploy config get inventory all.hosts.node1.webitel_services.0`,
+	Args:         cobra.ExactArgs(2),
+	ValidArgs:    config.ConfigFileNames,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFileType, err := parseConfigFileType(args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg := config.Load(config.DefaultConfig, cmd.ErrOrStderr())
+		node, err := cfg.Value(configFileType, args[1])
+		if err != nil {
+			return err
+		}
+
+		if node.Kind == yaml.ScalarNode {
+			keys, _ := yamldoc.SplitPath(args[1])
+			fmt.Fprintln(cmd.OutOrStdout(), config.MaskValue(keys[len(keys)-1], node))
+
+			return nil
+		}
+
+		content, err := yaml.Marshal(node)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), cfg.MaskSecrets(string(content), false))
+
+		return nil
+	},
+}
+
+var setCommand = &cobra.Command{
+	Use:   "set <vars|inventory> <path> <value>",
+	Short: "Set a value of a config file, keeping its comments",
+	Long: `Set sets the value at the path of the variables or inventory file, see get for the path. The value is
+read as YAML: quote it to set a number or a boolean as a string, use [] or {} for an empty sequence or mapping.
+Missing mappings on the way are created and the index after the last item of a sequence appends one. Comments
+and the order of the keys are kept, the file is indented with two spaces and loses its blank lines. The previous
+content is kept as a revision, the file is not written when the value is already set. Secrets are vault-encrypted
+when the vault password is known.`,
+	Example: `wdeploy config set vars nginx_site_name webitel.example.com
+wdeploy config set vars grafana_enable false
+wdeploy config set inventory all.hosts.node1.ansible_port 2222
+wdeploy config set inventory all.hosts.node1.webitel_services '[nginx, webitel_core]'`,
+	Args:         cobra.ExactArgs(3),
+	ValidArgs:    config.ConfigFileNames,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFileType, err := parseConfigFileType(args[0])
+		if err != nil {
+			return err
+		}
+
+		var value yaml.Node
+		if err = yaml.Unmarshal([]byte(args[2]), &value); err != nil {
+			return fmt.Errorf("value: %w", err)
+		}
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+		if len(value.Content) > 0 {
+			node = value.Content[0]
+		}
+
+		cfg := config.Load(config.DefaultConfig, cmd.ErrOrStderr())
+		if err = cfg.SetValue(configFileType, args[1], node); err != nil {
+			return err
+		}
+		if _, err = cfg.EncryptSecrets(configFileType); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Set %s in %s\n", args[1], cfg.ConfigFiles[configFileType])
+
+		return nil
+	},
+}
+
+var unsetCommand = &cobra.Command{
+	Use:   "unset <vars|inventory> <path>",
+	Short: "Remove a value from a config file, keeping its comments",
+	Long: `Unset removes the key or the item of a sequence at the path of the variables or inventory file, see get
+for the path. The previous content is kept as a revision.`,
+	Example: `wdeploy config unset vars grafana_basic_dashboards_language
+wdeploy config unset inventory all.hosts.node1.ansible_port`,
+	Args:         cobra.ExactArgs(2),
+	ValidArgs:    config.ConfigFileNames,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFileType, err := parseConfigFileType(args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg := config.Load(config.DefaultConfig, cmd.ErrOrStderr())
+		removed, err := cfg.DeleteValue(configFileType, args[1])
+		if err != nil {
+			return err
+		}
+
+		if !removed {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is not set in %s\n", args[1], cfg.ConfigFiles[configFileType])
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %s from %s\n", args[1], cfg.ConfigFiles[configFileType])
+
+		return nil
+	},
+}
+
+var revisionsCommand = &cobra.Command{
+	Use:   "revisions <vars|inventory> [revision ID]",
+	Short: "List previous contents of a config file, show or restore one of them",
+	Long: `Every time wdeploy writes the variables or inventory file, or the file is opened in $EDITOR, its previous
+content is kept as a revision. Revisions lists them, the latest first. Given a revision ID, a unique prefix of it
+or "last", it prints what restoring the revision changes in the current file or, with --restore, restores it.
+Secrets are masked.`,
+	Example: `wdeploy config revisions inventory
+wdeploy config revisions inventory last
+wdeploy config revisions vars 20231018-052353 --restore`,
+	Args:         cobra.RangeArgs(1, 2),
+	ValidArgs:    config.ConfigFileNames,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFileType, err := parseConfigFileType(args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg := config.Load(config.DefaultConfig, cmd.ErrOrStderr())
+		if len(args) == 1 {
+			return listRevisions(cmd, cfg, configFileType)
+		}
+
+		if restore {
+			return restoreRevision(cmd, cfg, configFileType, args[1])
+		}
+
+		return showRevision(cmd, cfg, configFileType, args[1])
+	},
+}
+
+func parseConfigFileType(name string) (int, error) {
+	for i, n := range config.ConfigFileNames {
+		if name == n {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("config file %q is unknown, expected one of: %s", name,
+		strings.Join(config.ConfigFileNames, ", "))
+}
+
+func listRevisions(cmd *cobra.Command, cfg config.Config, configFileType int) error {
+	revisions, err := cfg.Revisions(configFileType).List()
+	if err != nil {
+		return err
+	}
+
+	if len(revisions) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No revisions yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSAVED\tSIZE")
+	for _, r := range revisions {
+		fmt.Fprintf(w, "%s\t%s\t%d B\n", r.ID, r.Time.Format(time.DateTime), r.Size)
+	}
+
+	return w.Flush()
+}
+
+func showRevision(cmd *cobra.Command, cfg config.Config, configFileType int, id string) error {
+	r, err := cfg.Revisions(configFileType).Get(id)
+	if err != nil {
+		return err
+	}
+
+	content, err := r.Content()
+	if err != nil {
+		return err
+	}
+
+	current, err := os.ReadFile(cfg.ConfigFiles[configFileType])
+	if err != nil {
+		return err
+	}
+
+	lines := configdiff.Lines(cfg.MaskSecrets(string(current), false), cfg.MaskSecrets(content, false))
+	if len(lines) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "Revision %s is the same as the current file\n", r.ID)
+		return nil
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Restoring revision %s (%s) changes %s:\n\n%s\n", r.ID, r.Time.Format(time.DateTime),
+		cfg.ConfigFiles[configFileType], strings.Join(lines, "\n"))
+
+	return nil
+}
+
+func restoreRevision(cmd *cobra.Command, cfg config.Config, configFileType int, id string) error {
+	store := cfg.Revisions(configFileType)
+	r, err := store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err = store.Restore(r.ID, cfg.ConfigFiles[configFileType]); err != nil {
+		return err
+	}
+
+	// The revision may be older than the vault password.
+	if _, err = cfg.EncryptSecrets(configFileType); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Restored revision %s to %s\n", r.ID, cfg.ConfigFiles[configFileType])
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package deploy
+
+import (
+	"github.com/kirychukyurii/wdeploy/bootstrap"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"strings"
+)
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	Command.Flags().BoolVar(&config.DefaultConfig.CheckMode, "check", config.DefaultConfig.CheckMode,
+		"run the playbook with --check --diff and report what would change without changing hosts")
+	Command.Flags().StringVar(&config.DefaultConfig.Limit, "limit", config.DefaultConfig.Limit,
+		"deploy only to these hosts of the inventory, comma separated")
+	Command.Flags().StringVar(&config.DefaultConfig.Tags, "tags", config.DefaultConfig.Tags,
+		"deploy only these services, comma separated: "+strings.Join(vars.WebitelServices, ", "))
+}
+
+var Command = &cobra.Command{
+	Use:   "deploy",
+	Short: "Deploy Webitel without TUI",
+	Long: `Deploy Webitel services using the same variables and inventory files as the TUI.
+Ansible output is streamed to stdout and the command exits with a non-zero code if the playbook fails`,
+	Example: `wdeploy deploy --user "testUser" --password "testPassword" --vars ./vars.yml --inventory ./hosts.yml
+wdeploy deploy --check --vars ./vars.yml --inventory ./hosts.yml
+wdeploy deploy --limit node1 --tags webitel_engine`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDeploy()
+	},
+}
+
+func runDeploy() error {
+	app := fx.New(bootstrap.DeployModule, fx.NopLogger)
+	if err := app.Err(); err != nil {
+		return err
+	}
+
+	// Run exits with the code passed to fx.Shutdowner when the playbook fails.
+	app.Run()
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/configdiff"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+}
+
+var Command = &cobra.Command{
+	Use:   "diff [run ID]",
+	Short: "Show config changes since the last successful deploy",
+	Long: `Diff compares the variables and inventory files with their snapshots taken by the last successful deploy,
+or by the given run: added and removed hosts, services placed on other hosts and changed values. Secrets are masked.
+A file that is not valid YAML is compared line by line.`,
+	Example: `wdeploy diff
+wdeploy diff 20231018-052353`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.Load(config.DefaultConfig, cmd.ErrOrStderr())
+		store := history.New(cfg)
+
+		run, err := store.LastSucceeded()
+		if len(args) > 0 {
+			run, err = store.Get(args[0])
+		}
+		if errors.Is(err, history.ErrNotFound) && len(args) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No successful deploys yet, nothing to compare with")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		d, err := configdiff.Compare(cfg, run)
+		if err != nil {
+			return err
+		}
+
+		since := fmt.Sprintf("%s (%s %s)", run.ID, run.Mode(), run.Start.Format(time.DateTime))
+		if d.Empty() {
+			fmt.Fprintf(cmd.OutOrStdout(), "No changes since %s\n", since)
+			return nil
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Changes since %s\n\n%s", since, d)
+
+		return nil
+	},
+}
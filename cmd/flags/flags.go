@@ -0,0 +1,65 @@
+package flags
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/profile"
+	"github.com/kirychukyurii/wdeploy/internal/templates"
+	"github.com/spf13/pflag"
+	"strings"
+)
+
+// Config registers flags shared by every command that loads config.Config.
+func Config(pf *pflag.FlagSet) {
+	pf.StringVarP(&config.DefaultConfig.Profile, "profile", "P",
+		"", "specify deployment profile, defaults to the Webitel Repository user")
+	pf.StringVarP(&config.DefaultConfig.LogLevel, "log-level", "l",
+		"debug", "log output level: debug, info, warn, error, dpanic, panic, fatal")
+	pf.StringVarP(&config.DefaultConfig.LogFormat, "log-format", "F",
+		"plain", "log output format: json, console")
+	pf.StringVarP(&config.DefaultConfig.LogDirectory, "log-path", "L",
+		"./", "log output to this directory")
+	pf.StringVarP(&config.DefaultConfig.ConfigFiles[config.VarsConfig], "vars", "V",
+		"", "specify Ansible variables file")
+	pf.StringVarP(&config.DefaultConfig.ConfigFiles[config.InventoryConfig], "inventory", "i",
+		"", "specify Ansible inventory host path")
+	pf.StringVarP(&config.DefaultConfig.WebitelRepositoryUser, "user", "u",
+		"", "specify Webitel Repository user")
+	pf.StringVarP(&config.DefaultConfig.WebitelRepositoryPassword, "password", "p",
+		"", "specify Webitel Repository password")
+	pf.StringVar(&config.DefaultConfig.WebitelRepositoryUrl, "repository-url",
+		config.DefaultConfig.WebitelRepositoryUrl, "specify Webitel Repository URL used to verify user and password")
+	pf.StringVar(&config.DefaultConfig.VaultPasswordFile, "vault-password-file",
+		config.DefaultConfig.VaultPasswordFile, "specify Ansible Vault password file used to encrypt secrets")
+	pf.BoolVar(&config.DefaultConfig.AskVaultPass, "ask-vault-pass",
+		config.DefaultConfig.AskVaultPass, "ask for Ansible Vault password used to encrypt secrets")
+	pf.StringVarP(&config.DefaultConfig.InventoryType, "deploy-type", "t",
+		"localhost", "specify Ansible inventory template: "+strings.Join(templates.Names(templates.Inventory), ", "))
+	pf.StringVar(&config.DefaultConfig.PlaybookRef, "playbook-ref",
+		config.DefaultConfig.PlaybookRef, "specify branch, tag or commit of the Ansible playbook repository")
+	pf.StringVar(&config.DefaultConfig.PlaybookPath, "playbook-path",
+		config.DefaultConfig.PlaybookPath, "specify local Ansible playbook directory or .tar.gz archive instead of the repository")
+}
+
+// CheckProfile checks that the profile given with --profile, or the one named after the Webitel Repository
+// user, is a valid profile name, so its directory stays inside config.ProfilesDir.
+func CheckProfile() error {
+	if err := profile.ValidateName(config.DefaultConfig.ProfileName()); err != nil {
+		return fmt.Errorf("--profile: %w", err)
+	}
+
+	return nil
+}
+
+// CheckDeployType checks that the inventory template given with --deploy-type exists.
+func CheckDeployType(fs *pflag.FlagSet) error {
+	if f := fs.Lookup("deploy-type"); f == nil || !f.Changed {
+		return nil
+	}
+
+	if _, err := templates.Get(templates.Inventory, config.DefaultConfig.InventoryType); err != nil {
+		return fmt.Errorf("--deploy-type: %w", err)
+	}
+
+	return nil
+}
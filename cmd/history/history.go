@@ -0,0 +1,82 @@
+package history
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"github.com/spf13/cobra"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var showLog bool
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	Command.Flags().BoolVar(&showLog, "log", false, "print the ansible-playbook output of the run instead of its summary")
+}
+
+var Command = &cobra.Command{
+	Use:   "history [run ID]",
+	Short: "List past deploys or show one of them",
+	Long: `History lists past deploys, the latest first. Given a run ID, a unique prefix of it or "last",
+it prints the summary of the run or, with --log, its ansible-playbook output.`,
+	Example:      `wdeploy history last --log`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := history.New(config.New())
+		if len(args) == 0 {
+			return listRuns(cmd, store)
+		}
+
+		return showRun(cmd, store, args[0])
+	},
+}
+
+func listRuns(cmd *cobra.Command, store history.Store) error {
+	runs, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No deploys yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTARTED\tMODE\tSTATUS\tDURATION\tCOMMIT\tHOSTS")
+	for _, run := range runs {
+		commit := run.PlaybookCommit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", run.ID, run.Start.Format(time.DateTime), run.Mode(), run.Status,
+			run.Duration.Round(time.Second), commit, strings.Join(run.Hosts, ","))
+	}
+
+	return w.Flush()
+}
+
+func showRun(cmd *cobra.Command, store history.Store, id string) error {
+	run, err := store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	content, err := run.Summary()
+	if showLog {
+		content, err = run.Log()
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), content)
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package inventory
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/hostimport"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	from    string
+	replace bool
+	dryRun  bool
+)
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	importCommand.Flags().StringVar(&from, "from", "",
+		fmt.Sprintf("format of the file: %s, guessed from the file by default", strings.Join(hostimport.Formats, ", ")))
+	importCommand.Flags().BoolVar(&replace, "replace", false, "remove the hosts of the inventory that are not imported")
+	importCommand.Flags().BoolVar(&dryRun, "dry-run", false, "print the imported hosts without changing the inventory")
+
+	Command.AddCommand(importCommand)
+}
+
+var Command = &cobra.Command{
+	Use:   "inventory",
+	Short: "Manage the hosts of the inventory file",
+	Args:  cobra.NoArgs,
+}
+
+var importCommand = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import hosts from an Ansible INI inventory, a CSV host list or ssh_config",
+	Long: `Import reads hosts from an Ansible INI inventory, a CSV host list with a header row or the Host blocks of
+ssh_config and adds them to the inventory file. A host with the name of an imported one gets its address, user, port,
+private key and password, passwords are encrypted when a vault password is set.
+
+INI groups named after Webitel services place the services on their hosts, the other INI groups are added to the
+inventory with their hosts and variables. A CSV list may have a services column. Assign the other services on the
+Hosts page afterwards. The file defaults to ~/.ssh/config with --from ssh-config,
+"-" reads the standard input.`,
+	Example: `wdeploy inventory import hosts.ini
+wdeploy inventory import --from csv --dry-run servers.csv
+wdeploy inventory import --from ssh-config`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := importPath(args)
+		if err != nil {
+			return err
+		}
+
+		content, err := readSource(cmd, path)
+		if err != nil {
+			return err
+		}
+
+		format := from
+		if format == "" {
+			if format, err = hostimport.Detect(path, content); err != nil {
+				return err
+			}
+		}
+
+		result, err := hostimport.Parse(format, strings.NewReader(string(content)))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", w)
+		}
+		if len(result.Hosts) == 0 {
+			return fmt.Errorf("%s: no hosts found in the %s file", path, format)
+		}
+
+		cfg := config.Load(config.DefaultConfig, cmd.ErrOrStderr())
+		hosts, err := cfg.InventoryHosts()
+		if err != nil {
+			return err
+		}
+
+		removed := make([]string, 0)
+		if replace {
+			kept := make([]config.InventoryHost, 0, len(hosts))
+			for _, h := range hosts {
+				if imported(result.Hosts, h.Name) {
+					kept = append(kept, h)
+				} else {
+					removed = append(removed, h.Name)
+				}
+			}
+			hosts = kept
+		}
+
+		merged, added, updated := hostimport.Merge(hosts, result.Hosts)
+		printHosts(cmd.OutOrStdout(), merged, added, updated)
+		printGroups(cmd.OutOrStdout(), result.Groups)
+
+		summary := fmt.Sprintf("%d added, %d updated", len(added), len(updated))
+		if len(result.Groups) > 0 {
+			summary += fmt.Sprintf(", %d group(s)", len(result.Groups))
+		}
+		if replace {
+			summary += fmt.Sprintf(", %d removed", len(removed))
+		}
+
+		if dryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "\nDry run, the inventory is not changed: %s\n", summary)
+			return nil
+		}
+
+		if err = cfg.ImportInventory(merged, result.Groups); err != nil {
+			return err
+		}
+		if _, err = cfg.EncryptSecrets(config.InventoryConfig); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "\nImported %d host(s) from %s into %s: %s\n", len(result.Hosts), path,
+			cfg.ConfigFiles[config.InventoryConfig], summary)
+		fmt.Fprintln(cmd.OutOrStdout(), "Assign the services on the Hosts page or in the inventory file")
+
+		return nil
+	},
+}
+
+// importPath returns the file to import, ssh_config of the user by default.
+func importPath(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	if from != hostimport.FormatSSHConfig {
+		return "", fmt.Errorf("a file to import is required, unless --from is %s", hostimport.FormatSSHConfig)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".ssh", "config"), nil
+}
+
+func readSource(cmd *cobra.Command, path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(cmd.InOrStdin())
+	}
+
+	return os.ReadFile(path)
+}
+
+func imported(hosts []config.InventoryHost, name string) bool {
+	for _, h := range hosts {
+		if h.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printGroups prints the imported groups as a table.
+func printGroups(out io.Writer, groups []config.InventoryGroup) {
+	if len(groups) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out)
+	fmt.Fprintln(w, "GROUP\tPARENTS\tHOSTS\tVARIABLES\t")
+	for _, g := range groups {
+		variables := make([]string, 0, len(g.Vars))
+		for k := range g.Vars {
+			variables = append(variables, k)
+		}
+		sort.Strings(variables)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", g.Name, firstOf(strings.Join(g.Parents, ", "), "all"),
+			firstOf(strings.Join(g.Hosts, ", "), "-"), firstOf(strings.Join(variables, ", "), "-"))
+	}
+	w.Flush()
+}
+
+// printHosts prints the added and the updated hosts as a table.
+func printHosts(out io.Writer, hosts []config.InventoryHost, added, updated []string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tADDRESS\tUSER\tPORT\tSERVICES\t")
+	for _, h := range hosts {
+		status := ""
+		for _, name := range added {
+			if name == h.Name {
+				status = "added"
+			}
+		}
+		for _, name := range updated {
+			if name == h.Name {
+				status = "updated"
+			}
+		}
+		if status == "" {
+			continue
+		}
+
+		port := "-"
+		if h.AnsiblePort != 0 {
+			port = strconv.Itoa(h.AnsiblePort)
+		}
+		services := strings.Join(h.WebitelServices, ", ")
+		if services == "" {
+			services = "-"
+		}
+
+		fmt.Fprintf(w, "%s (%s)\t%s\t%s\t%s\t%s\t\n", h.Name, status, h.AnsibleHost, firstOf(h.AnsibleUser, "-"), port,
+			services)
+	}
+	w.Flush()
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
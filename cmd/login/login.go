@@ -0,0 +1,117 @@
+package login
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/credentials"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+)
+
+var check bool
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	Command.Flags().BoolVar(&check, "check", false, "only verify the configured user and password, do not prompt")
+}
+
+var Command = &cobra.Command{
+	Use:   "login",
+	Short: "Verify and save Webitel Repository user and password",
+	Long: `Login prompts for Webitel Repository user and password, verifies them against the package repository
+and saves them to the variables file. With --check it verifies the configured credentials only.`,
+	Example:      `wdeploy login --check --vars ./vars.yml`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogin(cmd)
+	},
+}
+
+func runLogin(cmd *cobra.Command) error {
+	cfg := config.New()
+	user, password := cfg.WebitelRepositoryUser, cfg.WebitelRepositoryPassword
+
+	if !check {
+		var err error
+		if user, password, err = prompt(cmd, user); err != nil {
+			return err
+		}
+	}
+
+	if user == "" || password == "" {
+		return errors.New("forbidden: repository user or password not specified")
+	}
+
+	if vault.IsEncrypted(password) {
+		return vault.ErrPasswordRequired
+	}
+
+	result := credentials.NewProber().Probe(context.Background(),
+		credentials.ReleaseURL(cfg.WebitelRepositoryUrl, cfg.WebitelVersion), user, password)
+	if result.Status != credentials.StatusOK {
+		return errors.New(result.String())
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), result)
+
+	if check {
+		return nil
+	}
+
+	if err := cfg.SetVariable("webitel_repository_user", user); err != nil {
+		return err
+	}
+	if err := cfg.SetVariable("webitel_repository_password", password); err != nil {
+		return err
+	}
+	if _, err := cfg.EncryptSecrets(config.VarsConfig); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Saved to %s\n", cfg.ConfigFiles[config.VarsConfig])
+
+	return nil
+}
+
+// prompt reads the user and the password from stdin, the password is not echoed on a terminal.
+func prompt(cmd *cobra.Command, defaultUser string) (string, string, error) {
+	in := bufio.NewReader(cmd.InOrStdin())
+	out := cmd.OutOrStdout()
+
+	fmt.Fprintf(out, "Webitel Repository user [%s]: ", defaultUser)
+	user, err := readLine(in)
+	if err != nil {
+		return "", "", err
+	}
+	if user == "" {
+		user = defaultUser
+	}
+
+	fmt.Fprint(out, "Webitel Repository password: ")
+	if fd := int(os.Stdin.Fd()); cmd.InOrStdin() == os.Stdin && term.IsTerminal(fd) {
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(out)
+
+		return user, string(password), err
+	}
+
+	password, err := readLine(in)
+
+	return user, password, err
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
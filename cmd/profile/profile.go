@@ -0,0 +1,161 @@
+package profile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/profile"
+	"github.com/spf13/cobra"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var yes bool
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	deleteCommand.Flags().BoolVarP(&yes, "yes", "y", false, "delete without asking for confirmation")
+
+	Command.AddCommand(listCommand)
+	Command.AddCommand(createCommand)
+	Command.AddCommand(cloneCommand)
+	Command.AddCommand(renameCommand)
+	Command.AddCommand(deleteCommand)
+}
+
+var Command = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage deployment profiles",
+	Long: `A profile is a named set of Ansible variables and inventory files with its own logs and history,
+e.g. one per environment or customer install. Select it with --profile in any command, without --profile
+the profile is named after the Webitel Repository user.`,
+	Args: cobra.NoArgs,
+}
+
+var listCommand = &cobra.Command{
+	Use:          "list",
+	Short:        "List profiles, the active one is marked with *",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles, err := profile.List()
+		if err != nil {
+			return err
+		}
+
+		if len(profiles) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No profiles yet")
+			return nil
+		}
+
+		active := config.DefaultConfig.ProfileName()
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tNAME\tLAST DEPLOY\tDIRECTORY")
+		for _, p := range profiles {
+			mark := ""
+			if p.Name == active {
+				mark = "*"
+			}
+
+			last := "-"
+			if run, err := p.LastRun(); err == nil {
+				last = fmt.Sprintf("%s %s", run.Start.Format(time.DateTime), run.Status)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, p.Name, last, p.Dir)
+		}
+
+		return w.Flush()
+	},
+}
+
+var createCommand = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile with variables and inventory generated from the templates",
+	Example: `wdeploy profile create staging -u user -p password --deploy-type custom
+wdeploy run --profile staging`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := profile.Create(config.DefaultConfig, args[0], cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created profile %s in %s\n", p.Name, p.Dir)
+
+		return nil
+	},
+}
+
+var cloneCommand = &cobra.Command{
+	Use:          "clone <source> <name>",
+	Short:        "Create a profile with a copy of the variables and inventory of another one",
+	Example:      `wdeploy profile clone staging production`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := profile.Clone(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Cloned profile %s to %s in %s\n", args[0], p.Name, p.Dir)
+
+		return nil
+	},
+}
+
+var renameCommand = &cobra.Command{
+	Use:          "rename <name> <new name>",
+	Short:        "Rename a profile, its logs and history are kept",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := profile.Rename(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Renamed profile %s to %s\n", args[0], p.Name)
+
+		return nil
+	},
+}
+
+var deleteCommand = &cobra.Command{
+	Use:          "delete <name>",
+	Short:        "Delete a profile with its variables, inventory, logs and history",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := profile.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		if !yes {
+			fmt.Fprintf(cmd.OutOrStdout(), "Delete profile %s and everything in %s? [y/N]: ", p.Name, p.Dir)
+			answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && answer == "" {
+				return errors.New("profile: not deleted, use --yes to delete without confirmation")
+			}
+
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Fprintln(cmd.OutOrStdout(), "Canceled")
+				return nil
+			}
+		}
+
+		if err = profile.Delete(p.Name); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted profile %s\n", p.Name)
+
+		return nil
+	},
+}
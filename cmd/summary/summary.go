@@ -0,0 +1,59 @@
+package summary
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/summary"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var (
+	format string
+	output string
+)
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+	Command.Flags().StringVar(&format, "format", summary.FormatMarkdown,
+		"summary format: "+strings.Join(summary.Formats, ", "))
+	Command.Flags().StringVarP(&output, "output", "o", "", "write the summary to this file instead of stdout")
+}
+
+var Command = &cobra.Command{
+	Use:   "summary",
+	Short: "Export the deployment summary",
+	Long: `Summary describes the deployment for handover: Webitel version, hosts with their addresses and services,
+the site, Let's Encrypt, Grafana and other main settings, and the outcome of the last run if there is one.
+Secrets are not exported.`,
+	Example: `wdeploy summary --format html -o handover.html
+wdeploy summary --format json --profile customer1`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Paths of the config files go to stderr, stdout may be the summary itself.
+		s, err := summary.New(config.Load(config.DefaultConfig, cmd.ErrOrStderr()))
+		if err != nil {
+			return err
+		}
+
+		content, err := s.Render(format)
+		if err != nil {
+			return err
+		}
+
+		if output == "" {
+			_, err = cmd.OutOrStdout().Write(content)
+			return err
+		}
+
+		if err = summary.WriteFile(output, content); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Summary written to %s\n", output)
+
+		return nil
+	},
+}
@@ -0,0 +1,49 @@
+package templates
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/templates"
+	"github.com/spf13/cobra"
+	"text/tabwriter"
+)
+
+func init() {
+	Command.AddCommand(listCommand)
+}
+
+var Command = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage the templates the config files and the plan are generated from",
+	Long: fmt.Sprintf(`The variables and inventory files of a new profile are generated from templates, the inventory
+template is chosen with --deploy-type. Templates in %s replace the built-in
+ones with the same kind and name or add new ones: put an inventory template into the inventory directory, e.g.
+inventory/cluster.yml, and create a profile with --deploy-type cluster. A template may start with a comment
+describing it: {{- /* Two hosts behind a load balancer */ -}}.`, templates.Dir()),
+	Args: cobra.NoArgs,
+}
+
+var listCommand = &cobra.Command{
+	Use:          "list",
+	Short:        "List the built-in and the user templates",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, err := templates.List()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAME\tDESCRIPTION\tSOURCE")
+		for _, t := range list {
+			description := t.Description
+			if description == "" {
+				description = "-"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Kind, t.Name, description, t.Source())
+		}
+
+		return w.Flush()
+	},
+}
@@ -0,0 +1,43 @@
+package validate
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/cmd/flags"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/validator"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	flags.Config(Command.PersistentFlags())
+}
+
+var Command = &cobra.Command{
+	Use:          "validate",
+	Short:        "Validate Ansible variables and inventory files",
+	Example:      `wdeploy validate --vars ./vars.yml --inventory ./hosts.yml`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runValidate(cmd)
+	},
+}
+
+func runValidate(cmd *cobra.Command) error {
+	problems, err := validator.Validate(config.New())
+	if err != nil {
+		return err
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(cmd.OutOrStdout(), p)
+	}
+
+	if problems.HasErrors() {
+		return fmt.Errorf("validation failed: %d error(s), %d warning(s)", problems.Errors(), problems.Warnings())
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "OK: %d warning(s)\n", problems.Warnings())
+
+	return nil
+}
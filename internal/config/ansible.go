@@ -10,14 +10,16 @@ type Variables struct {
 	AnsibleSSHPrivateKeyFile string `mapstructure:"ansible_ssh_private_key_file" yaml:"ansible_ssh_private_key_file"` // Private key file used by ssh. Useful if using multiple keys and you don’t want to use SSH agent
 	AnsibleSSHPass           string `mapstructure:"ansible_ssh_pass" yaml:"ansible_ssh_pass"`                         // The password to use to authenticate to the host
 
+	PlaybookRepositoryRef string `mapstructure:"playbook_ref" yaml:"playbook_ref,omitempty"` // Branch, tag or commit of the playbook repository, --playbook-ref overrides it
+
 	WebitelVersion            string `mapstructure:"webitel_version" yaml:"webitel_version"`
 	WebitelRepositoryUser     string `mapstructure:"webitel_repository_user" yaml:"webitel_repository_user"`
 	WebitelRepositoryPassword string `mapstructure:"webitel_repository_password" yaml:"webitel_repository_password"`
 
-	RTPEngineMode           string `mapstructure:"rtp_engine_mode" yaml:"rtp_engine_mode"`
+	RTPEngineMode           string `mapstructure:"rtpengine_mode" yaml:"rtpengine_mode"`
 	FreeswitchSignalwireKey string `mapstructure:"freeswitch_signalwire_key" yaml:"freeswitch_signalwire_key"`
 	OpensipsVersion         string `mapstructure:"opensips_version" yaml:"opensips_version"`
-	OpensipsFail2ban        bool   `mapstructure:"opensips_fail_2_ban" yaml:"opensips_fail_2_ban"`
+	OpensipsFail2ban        bool   `mapstructure:"opensips_fail2ban" yaml:"opensips_fail2ban"`
 
 	NginxLetsencrypt               bool   `mapstructure:"nginx_letsencrypt" yaml:"nginx_letsencrypt"`
 	NginxSiteName                  string `mapstructure:"nginx_site_name" yaml:"nginx_site_name"`
@@ -30,14 +32,26 @@ type Variables struct {
 }
 
 type Inventory struct {
-	Inventory Hosts `mapstructure:"all" yaml:"all"`
+	Inventory Group `mapstructure:"all" yaml:"all"`
 }
 
-type Hosts struct {
-	Hosts map[string]Host `mapstructure:"hosts" yaml:"hosts"`
+// Group is a group of the inventory, the hosts of its children are its hosts too.
+type Group struct {
+	Hosts    map[string]Host  `mapstructure:"hosts" yaml:"hosts"`
+	Vars     map[string]any   `mapstructure:"vars" yaml:"vars,omitempty"`
+	Children map[string]Group `mapstructure:"children" yaml:"children,omitempty"`
+	Extra    map[string]any   `mapstructure:",remain" yaml:",inline"` // Keys of the group unknown to wdeploy
 }
 
 type Host struct {
-	AnsibleHost     string   `mapstructure:"ansible_host" yaml:"ansible_host"`
-	WebitelServices []string `mapstructure:"webitel_services" yaml:"webitel_services"`
+	AnsibleHost              string   `mapstructure:"ansible_host" yaml:"ansible_host"`
+	AnsibleConnection        string   `mapstructure:"ansible_connection" yaml:"ansible_connection"`                     // local runs the tasks on the control node without SSH
+	AnsibleUser              string   `mapstructure:"ansible_user" yaml:"ansible_user"`                                 // Used when the variables file doesn't set ansible_user
+	AnsiblePort              int      `mapstructure:"ansible_port" yaml:"ansible_port"`                                 // Used when the variables file doesn't set ansible_port
+	AnsibleSSHPrivateKeyFile string   `mapstructure:"ansible_ssh_private_key_file" yaml:"ansible_ssh_private_key_file"` // Used when the variables file doesn't set ansible_ssh_private_key_file
+	AnsibleSSHPass           string   `mapstructure:"ansible_ssh_pass" yaml:"ansible_ssh_pass"`                         // Used when the variables file doesn't set ansible_ssh_pass
+	WebitelServices          []string `mapstructure:"webitel_services" yaml:"webitel_services"`
+
+	Vars   map[string]any `mapstructure:",remain" yaml:",inline"` // Host variables unknown to wdeploy
+	Groups []string       `mapstructure:"-" yaml:"-"`             // Groups of the host, set by Inventory.Hosts
 }
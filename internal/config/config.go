@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"github.com/adrg/xdg"
 	"github.com/kirychukyurii/wdeploy/internal/constants"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
 	"github.com/kirychukyurii/wdeploy/internal/lib/file"
-	"github.com/kirychukyurii/wdeploy/internal/templates/inventory/custom"
-	"github.com/kirychukyurii/wdeploy/internal/templates/inventory/localhost"
-	"github.com/kirychukyurii/wdeploy/internal/templates/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/yamldoc"
+	"github.com/kirychukyurii/wdeploy/internal/templates"
 	"go.uber.org/fx"
-	"gopkg.in/yaml.v3"
+	"io"
+	"os"
 	"path/filepath"
 	"regexp"
 	"text/template"
@@ -19,16 +20,39 @@ var Module = fx.Options(
 	fx.Provide(New),
 )
 
+// DefaultProfile is the profile used when neither a profile nor a Webitel repository user is given.
+const DefaultProfile = "default"
+
 const (
 	VarsConfig int = iota
 	InventoryConfig
 	lastsConfig
 )
 
+// ConfigFileNames are the names of the config files by their type, they are the directories of the files
+// in the profile.
+var ConfigFileNames = []string{
+	VarsConfig:      "vars",
+	InventoryConfig: "inventory",
+}
+
 type Config struct {
+	Profile               string // Profile with its own vars, inventory, logs and history, see ProfileName
 	PlaybookRepositoryUrl string
+	PlaybookRef           string // Branch, tag or commit of the playbook repository to check out, see Variables.PlaybookRepositoryRef
+	PlaybookCommit        string // Commit the playbook was resolved to
+	PlaybookPath          string // Local playbook directory or .tar.gz archive used instead of the repository
 	PlaybookTempDir       string
+	WebitelRepositoryUrl  string // Package repository used to verify Webitel repository credentials
+	VaultPasswordFile     string // File with the Ansible Vault password
+	AskVaultPass          bool   // Prompt for the Ansible Vault password on start
+	CheckMode             bool   // Run the playbook with --check --diff, hosts are not changed
+	Limit                 string // Hosts to run the playbook on, comma separated, all when empty
+	Tags                  string // Services to deploy, comma separated, all when empty
+	VaultPassword         string // Ansible Vault password used to encrypt secrets in the config files
 	ConfigFiles           []string
+	HistoryDirectory      string // Directory with records of past deploys
+	RevisionsDirectory    string // Directory with the previous contents of the config files
 	InventoryType         string
 	LoggerConfig
 	Variables
@@ -37,6 +61,7 @@ type Config struct {
 
 var DefaultConfig = Config{
 	PlaybookRepositoryUrl: "https://github.com/kirychukyurii/wansible",
+	WebitelRepositoryUrl:  "https://deb.webitel.com",
 	ConfigFiles:           make([]string, 2),
 	InventoryType:         "custom",
 	LoggerConfig: LoggerConfig{
@@ -51,37 +76,71 @@ var DefaultConfig = Config{
 }
 
 func New() Config {
-	config := DefaultConfig
-	home := config.getUserLocalHome()
+	return Load(DefaultConfig, os.Stdout)
+}
+
+// Load reads the config files of the config profile, the files missing in the profile are created
+// from the templates. Paths of the files and the problems found are written to out.
+func Load(config Config, out io.Writer) Config {
+	// DefaultConfig.ConfigFiles is bound to flags, don't write the resolved paths through to it.
+	config.ConfigFiles = append([]string(nil), config.ConfigFiles...)
+	config.Profile = config.ProfileName()
+	home := ProfileDir(config.Profile)
 
-	configFilesType := make(map[int]string, lastsConfig)
-	configFilesType[VarsConfig] = "vars"
-	configFilesType[InventoryConfig] = "inventory"
+	if err := config.loadVaultPassword(); err != nil {
+		fmt.Fprintln(out, "config.loadVaultPassword(): "+err.Error())
+	}
+
+	config.RevisionsDirectory = filepath.Join(home, "revisions")
 
 	for i, v := range config.ConfigFiles {
 		if v == "" {
-			if err := file.EnsureDir(filepath.Join(home, configFilesType[i])); err != nil {
-				fmt.Println("file.EnsureDir(): " + err.Error())
+			if err := file.EnsureDir(filepath.Join(home, ConfigFileNames[i])); err != nil {
+				fmt.Fprintln(out, "file.EnsureDir(): "+err.Error())
 			}
 
-			config.ConfigFiles[i] = filepath.Join(home, configFilesType[i], "all.yml")
-			fmt.Printf("%s: %s\n", configFilesType[i], config.ConfigFiles[i])
+			config.ConfigFiles[i] = filepath.Join(home, ConfigFileNames[i], "all.yml")
+			fmt.Fprintf(out, "%s: %s\n", ConfigFileNames[i], config.ConfigFiles[i])
 		}
 
 		if !file.IsFile(config.ConfigFiles[i]) {
 			if err := config.createConfigFromTpl(i); err != nil {
-				fmt.Println("config.createConfigFromTpl(i): " + err.Error())
+				fmt.Fprintln(out, "config.createConfigFromTpl(i): "+err.Error())
+			}
+		}
+
+		if i == VarsConfig {
+			renamed, err := config.MigrateVars()
+			if err != nil {
+				fmt.Fprintln(out, "config.MigrateVars(): "+err.Error())
 			}
+			for _, old := range renamed {
+				fmt.Fprintf(out, "%s: renamed %s to %s\n", config.ConfigFiles[i], old, vars.RenamedKeys[old])
+			}
+		}
+
+		if _, err := config.EncryptSecrets(i); err != nil {
+			fmt.Fprintln(out, "config.EncryptSecrets(i): "+err.Error())
 		}
 
 		if err := config.ReadToStruct(i); err != nil {
-			fmt.Println("config.ReadToStruct(i): ", err.Error())
+			fmt.Fprintln(out, "config.ReadToStruct(i): ", err.Error())
 		}
 	}
 
+	// --playbook-ref overrides the ref pinned in the variables file.
+	if config.PlaybookRef == "" {
+		config.PlaybookRef = config.PlaybookRepositoryRef
+	}
+
 	config.LogDirectory = filepath.Join(home, "logs")
 	if err := file.EnsureDir(config.LogDirectory); err != nil {
-		fmt.Println("file.EnsureDir(): " + err.Error())
+		fmt.Fprintln(out, "file.EnsureDir(): "+err.Error())
+	}
+
+	config.HistoryDirectory = filepath.Join(home, "history")
+	if err := file.EnsureDir(config.HistoryDirectory); err != nil {
+		fmt.Fprintln(out, "file.EnsureDir(): "+err.Error())
 	}
 
 	ansibleLogLocation := config.GetAnsibleLogLocation()
@@ -89,7 +148,7 @@ func New() Config {
 	if !file.IsFile(ansibleLogLocation) {
 		f, err := file.Create(ansibleLogLocation)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(out, err)
 		}
 
 		defer file.Close(f)
@@ -98,30 +157,59 @@ func New() Config {
 	return config
 }
 
-func (c *Config) getUserLocalHome() string {
-	trimUser := regexp.MustCompile("[^a-zA-Z0-9]+").ReplaceAllString(c.WebitelRepositoryUser, "")
-	return filepath.Join(xdg.DataHome, constants.AppName, trimUser)
+// ProfileName returns the profile of the config. Without an explicit profile it is the
+// Webitel repository user with non-alphanumeric characters removed, as wdeploy stored a single
+// vars and inventory pair per user before profiles, or DefaultProfile when there is no user.
+func (c *Config) ProfileName() string {
+	if c.Profile != "" {
+		return c.Profile
+	}
+
+	if trimUser := regexp.MustCompile("[^a-zA-Z0-9]+").ReplaceAllString(c.WebitelRepositoryUser, ""); trimUser != "" {
+		return trimUser
+	}
+
+	return DefaultProfile
+}
+
+// ProfilesDir returns the directory with a subdirectory per profile.
+func ProfilesDir() string {
+	return filepath.Join(xdg.DataHome, constants.AppName)
 }
 
-func (c *Config) createConfigFromTpl(configFileType int) error {
-	var tmpl string
+// ProfileDir returns the data directory of the profile.
+func ProfileDir(profile string) string {
+	return filepath.Join(ProfilesDir(), profile)
+}
 
-	switch configFileType {
-	case VarsConfig:
-		tmpl = vars.Tmpl
-	case InventoryConfig:
-		if c.InventoryType == "local" {
-			tmpl = localhost.Tmpl
-		} else {
-			tmpl = custom.Tmpl
-		}
+// SwitchProfile returns the config of another profile. The playbook, its ref and the vault password of c are kept,
+// the config files given with flags are not: every profile uses its own.
+func (c Config) SwitchProfile(profile string, out io.Writer) Config {
+	next := DefaultConfig
+	next.Profile = profile
+	next.ConfigFiles = make([]string, lastsConfig)
+	next.PlaybookTempDir, next.PlaybookRef, next.PlaybookCommit = c.PlaybookTempDir, c.PlaybookRef, c.PlaybookCommit
+	next.VaultPassword, next.VaultPasswordFile, next.AskVaultPass = c.VaultPassword, "", false
+
+	return Load(next, out)
+}
+
+func (c *Config) createConfigFromTpl(configFileType int) error {
+	kind, name := templates.Vars, "default"
+	if configFileType == InventoryConfig {
+		kind, name = templates.Inventory, c.InventoryType
 	}
 
-	t, err := template.New("").Parse(tmpl)
+	tmpl, err := templates.Get(kind, name)
 	if err != nil {
 		return err
 	}
 
+	t, err := template.New(tmpl.Name).Parse(tmpl.Content)
+	if err != nil {
+		return fmt.Errorf("%s template %s: %w", kind, tmpl.Name, err)
+	}
+
 	f, err := file.Create(c.ConfigFiles[configFileType])
 	defer file.Close(f)
 	if err != nil {
@@ -141,21 +229,30 @@ func (c *Config) GetAnsibleLogLocation() string {
 }
 
 func (c *Config) ReadToStruct(configFileType int) error {
-	f, err := file.Open(c.ConfigFiles[configFileType])
-	defer file.Close(f)
+	doc, err := yamldoc.Read(c.ConfigFiles[configFileType])
 	if err != nil {
 		return err
 	}
 
+	// Values that fail to decrypt stay encrypted, the rest of the file is still decoded.
+	decryptErr := decryptSecrets(doc.Node(), c.VaultPassword)
+
 	switch configFileType {
 	case VarsConfig:
-		if err = yaml.NewDecoder(f).Decode(&c.Variables); err != nil {
+		if err = doc.Decode(&c.Variables); err != nil {
 			return err
 		}
 	case InventoryConfig:
-		if err = yaml.NewDecoder(f).Decode(&c.Inventory); err != nil {
+		// Decoding into the maps of the previous inventory would keep the hosts removed from the file.
+		var inventory Inventory
+		if err = doc.Decode(&inventory); err != nil {
 			return err
 		}
+		c.Inventory = inventory
+	}
+
+	if decryptErr != nil {
+		return fmt.Errorf("%s: %w", c.ConfigFiles[configFileType], decryptErr)
 	}
 
 	return nil
@@ -0,0 +1,56 @@
+package config
+
+import "strconv"
+
+// Sources of a connection setting.
+const (
+	SourceVars    = "vars"    // The variables file
+	SourceHost    = "host"    // The host in the inventory
+	SourceDefault = "default" // Ansible and ssh defaults
+)
+
+// Setting is a connection setting with where it comes from.
+type Setting struct {
+	Value  string
+	Source string
+}
+
+// Connection is how Ansible connects to a host.
+type Connection struct {
+	User     Setting // The user ssh defaults to, the one running wdeploy, when the source is SourceDefault
+	Port     Setting
+	KeyFile  Setting // ssh-agent and the default keys of the user when the source is SourceDefault
+	Password Setting
+	Local    bool // Tasks run on the control node, the other settings are not used
+}
+
+// HostConnection returns the connection settings Ansible uses for the host. The variables file is passed
+// to ansible-playbook as extra vars, so its connection variables take precedence over the host ones.
+func (c Config) HostConnection(h Host) Connection {
+	port, hostPort := "", ""
+	if c.AnsiblePort != 0 {
+		port = strconv.Itoa(c.AnsiblePort)
+	}
+	if h.AnsiblePort != 0 {
+		hostPort = strconv.Itoa(h.AnsiblePort)
+	}
+
+	return Connection{
+		User:     setting(c.AnsibleUser, h.AnsibleUser, ""),
+		Port:     setting(port, hostPort, "22"),
+		KeyFile:  setting(c.AnsibleSSHPrivateKeyFile, h.AnsibleSSHPrivateKeyFile, ""),
+		Password: setting(c.AnsibleSSHPass, h.AnsibleSSHPass, ""),
+		Local:    h.AnsibleConnection == "local",
+	}
+}
+
+func setting(vars, host, def string) Setting {
+	switch {
+	case vars != "":
+		return Setting{Value: vars, Source: SourceVars}
+	case host != "":
+		return Setting{Value: host, Source: SourceHost}
+	}
+
+	return Setting{Value: def, Source: SourceDefault}
+}
@@ -0,0 +1,562 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/lib/yamldoc"
+	"gopkg.in/yaml.v3"
+	"strconv"
+)
+
+// SetVariable sets a top-level string value in the variables file, keeping its comments.
+// A key missing from the file is appended.
+func (c *Config) SetVariable(key, value string) error {
+	return c.SetVariables([]VariableValue{{Key: key, Value: value}})
+}
+
+// VariableValue is a value of a top-level key of the variables file, a nil Value removes the key.
+type VariableValue struct {
+	Key   string
+	Value any
+}
+
+// SetVariables sets top-level values in the variables file, keeping comments and the order of the keys.
+// Keys missing from the file are appended. The previous content is kept as a revision.
+func (c *Config) SetVariables(values []VariableValue) error {
+	path := c.ConfigFiles[VarsConfig]
+	doc, err := yamldoc.Read(path)
+	if err != nil {
+		return err
+	}
+
+	root, err := doc.RootMapping()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, v := range values {
+		if v.Value == nil {
+			yamldoc.DeleteKey(root, v.Key)
+			continue
+		}
+
+		if err = yamldoc.SetKey(root, v.Key, v.Value); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, v.Key, err)
+		}
+	}
+
+	if err = c.writeDocument(VarsConfig, doc); err != nil {
+		return err
+	}
+
+	return c.ReadToStruct(VarsConfig)
+}
+
+// Value returns the node at the path of the config file, see yamldoc.SplitPath.
+func (c *Config) Value(configFileType int, path string) (*yaml.Node, error) {
+	doc, err := yamldoc.Read(c.ConfigFiles[configFileType])
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Get(path)
+}
+
+// SetValue sets the value at the path of the config file, keeping comments and the order of the keys.
+// The file must still decode into the config. The previous content is kept as a revision.
+func (c *Config) SetValue(configFileType int, path string, value any) error {
+	_, err := c.editDocument(configFileType, func(doc *yamldoc.Document) (bool, error) {
+		return true, doc.Set(path, value)
+	})
+
+	return err
+}
+
+// DeleteValue removes the value at the path of the config file and reports whether it was set.
+// The previous content is kept as a revision.
+func (c *Config) DeleteValue(configFileType int, path string) (bool, error) {
+	return c.editDocument(configFileType, func(doc *yamldoc.Document) (bool, error) {
+		return doc.Delete(path)
+	})
+}
+
+// editDocument applies edit to the config file and writes it when edit reports a change.
+func (c *Config) editDocument(configFileType int, edit func(doc *yamldoc.Document) (bool, error)) (bool, error) {
+	path := c.ConfigFiles[configFileType]
+	doc, err := yamldoc.Read(path)
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := edit(doc)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	switch configFileType {
+	case VarsConfig:
+		err = doc.Decode(&Variables{})
+	case InventoryConfig:
+		err = doc.Decode(&Inventory{})
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err = c.writeDocument(configFileType, doc); err != nil {
+		return false, err
+	}
+
+	return true, c.ReadToStruct(configFileType)
+}
+
+// writeDocument keeps the content of the config file as a revision and writes the document to it. Nothing is done
+// when the document is not changed, e.g. a value is set to the value it has.
+func (c *Config) writeDocument(configFileType int, doc *yamldoc.Document) error {
+	if !doc.Changed() {
+		return nil
+	}
+
+	if err := c.SaveRevision(configFileType); err != nil {
+		return err
+	}
+
+	return doc.Write(c.ConfigFiles[configFileType])
+}
+
+// InventoryHost is a host of the inventory file with its name. Host holds the values set on the host itself,
+// where it is first listed, and the groups of the host.
+type InventoryHost struct {
+	Name     string
+	OrigName string // Name of the host in the file, empty for a host that is not saved yet
+	Host
+}
+
+// hostKeys are the host keys written by SetInventoryHosts, a missing key is inserted after the ones before it.
+var hostKeys = []string{
+	"ansible_host",
+	"ansible_connection",
+	"ansible_user",
+	"ansible_port",
+	"ansible_ssh_private_key_file",
+	"ansible_ssh_pass",
+	"webitel_services",
+}
+
+// hostEntry is a host listed in the hosts of a group of the inventory file.
+type hostEntry struct {
+	hosts *yaml.Node // The hosts mapping of the group
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+// hostEntries returns the hosts listed in all and its children, in the order of the file.
+func hostEntries(all *yaml.Node) []hostEntry {
+	entries := make([]hostEntry, 0)
+	var walk func(group *yaml.Node, depth int)
+	walk = func(group *yaml.Node, depth int) {
+		// Groups that are their own ancestors are only possible with aliases, they are not followed.
+		if depth > 64 {
+			return
+		}
+
+		if hosts := yamldoc.MappingValue(group, "hosts"); hosts != nil && hosts.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(hosts.Content); i += 2 {
+				entries = append(entries, hostEntry{hosts: hosts, key: hosts.Content[i], value: hosts.Content[i+1]})
+			}
+		}
+
+		if children := yamldoc.MappingValue(group, "children"); children != nil && children.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(children.Content); i += 2 {
+				walk(children.Content[i+1], depth+1)
+			}
+		}
+	}
+	walk(all, 0)
+
+	return entries
+}
+
+// definition returns the entry with the values of the host: the first one that sets any.
+func definition(entries []hostEntry) hostEntry {
+	for _, e := range entries {
+		if e.value.Kind == yaml.MappingNode && len(e.value.Content) > 0 {
+			return e
+		}
+	}
+
+	return entries[0]
+}
+
+// InventoryHosts returns the hosts of all groups of the inventory file in the order of the file.
+func (c *Config) InventoryHosts() ([]InventoryHost, error) {
+	path := c.ConfigFiles[InventoryConfig]
+	doc, err := yamldoc.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var inventory Inventory
+	if err = doc.Decode(&inventory); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	resolved := inventory.Hosts()
+
+	names := make([]string, 0)
+	entries := make(map[string][]hostEntry)
+	for _, e := range hostEntries(yamldoc.MappingValue(doc.Root(), "all")) {
+		if entries[e.key.Value] == nil {
+			names = append(names, e.key.Value)
+		}
+		entries[e.key.Value] = append(entries[e.key.Value], e)
+	}
+
+	hosts := make([]InventoryHost, 0, len(names))
+	for _, name := range names {
+		h := InventoryHost{Name: name, OrigName: name}
+		if err = definition(entries[name]).value.Decode(&h.Host); err != nil {
+			return nil, fmt.Errorf("%s: host %s: %w", path, name, err)
+		}
+		h.Groups = resolved[name].Groups
+
+		hosts = append(hosts, h)
+	}
+
+	return hosts, nil
+}
+
+// SetInventoryHosts replaces the hosts of the inventory file, keeping comments, groups and the keys it doesn't
+// edit. Hosts are matched to the file by OrigName, a host is written where it is first listed with values and
+// renamed in every group. Added hosts go to all.hosts, the hosts of the file missing from hosts are removed
+// from every group. The previous content is kept as a revision.
+func (c *Config) SetInventoryHosts(hosts []InventoryHost) error {
+	return c.editInventory(func(all *yaml.Node) error {
+		return setInventoryHosts(all, hosts)
+	})
+}
+
+// ImportInventory replaces the hosts as SetInventoryHosts does and adds the groups to the inventory file, in one
+// revision. A group of the file gets the hosts and variables of the imported one, a new group is added to the
+// children of its parents or of all. Hosts missing from hosts are left out of the groups.
+func (c *Config) ImportInventory(hosts []InventoryHost, groups []InventoryGroup) error {
+	return c.editInventory(func(all *yaml.Node) error {
+		if err := setInventoryHosts(all, hosts); err != nil {
+			return err
+		}
+
+		return addInventoryGroups(all, groups, hosts)
+	})
+}
+
+// editInventory runs edit on the all group of the inventory file and writes the file, the previous content is
+// kept as a revision.
+func (c *Config) editInventory(edit func(all *yaml.Node) error) error {
+	path := c.ConfigFiles[InventoryConfig]
+	doc, err := yamldoc.Read(path)
+	if err != nil {
+		return err
+	}
+
+	root, err := doc.RootMapping()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	all := yamldoc.EnsureMapping(root, "all")
+	if all.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: all: expected a mapping", path)
+	}
+
+	if err = edit(all); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err = c.writeDocument(InventoryConfig, doc); err != nil {
+		return err
+	}
+
+	return c.ReadToStruct(InventoryConfig)
+}
+
+// setInventoryHosts replaces the hosts of the all group and its children.
+func setInventoryHosts(all *yaml.Node, hosts []InventoryHost) error {
+	node := yamldoc.EnsureMapping(all, "hosts")
+	if node.Kind != yaml.MappingNode {
+		return errors.New("all.hosts: expected a mapping")
+	}
+
+	existing := make(map[string][]hostEntry)
+	for _, e := range hostEntries(all) {
+		existing[e.key.Value] = append(existing[e.key.Value], e)
+	}
+
+	kept := make(map[*yaml.Node]bool)
+	content := make([]*yaml.Node, 0, 2*len(hosts))
+	for _, h := range hosts {
+		entries, ok := existing[h.OrigName]
+		if !ok || h.OrigName == "" {
+			kv := [2]*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: h.Name},
+				{Kind: yaml.MappingNode, Tag: "!!map"},
+			}
+			setHost(kv[1], h.Host)
+			content = append(content, kv[0], kv[1])
+
+			continue
+		}
+
+		def := definition(entries).value
+		if def.Kind != yaml.MappingNode {
+			def.Kind, def.Tag, def.Value, def.Content = yaml.MappingNode, "!!map", "", nil
+		}
+		setHost(def, h.Host)
+
+		for _, e := range entries {
+			e.key.Value = h.Name
+			kept[e.key] = true
+			if e.hosts == node {
+				content = append(content, e.key, e.value)
+			}
+		}
+	}
+	node.Content = content
+
+	// The removed hosts are removed from the other groups too.
+	for _, e := range hostEntries(all) {
+		if e.hosts != node && !kept[e.key] {
+			for i := 0; i+1 < len(e.hosts.Content); i += 2 {
+				if e.hosts.Content[i] == e.key {
+					e.hosts.Content = append(e.hosts.Content[:i], e.hosts.Content[i+2:]...)
+					break
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// addInventoryGroups adds the groups to all, parents before their children.
+func addInventoryGroups(all *yaml.Node, groups []InventoryGroup, hosts []InventoryHost) error {
+	nodes := groupNodes(all)
+	byName := make(map[string]InventoryGroup, len(groups))
+	for _, g := range groups {
+		byName[g.Name] = g
+	}
+
+	var add func(name string, path []string) (*yaml.Node, error)
+	add = func(name string, path []string) (*yaml.Node, error) {
+		if node := nodes[name]; node != nil {
+			// A group listed without values gets them.
+			if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+				node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+			}
+			if node.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("group %s: expected a mapping", name)
+			}
+
+			return node, nil
+		}
+
+		g := byName[name]
+		if contains(path, name) {
+			return nil, fmt.Errorf("group %s is its own ancestor", name)
+		}
+
+		parents := []*yaml.Node{all}
+		if len(g.Parents) > 0 {
+			parents = parents[:0]
+			for _, p := range g.Parents {
+				node, err := add(p, append(path, name))
+				if err != nil {
+					return nil, err
+				}
+				parents = append(parents, node)
+			}
+		}
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for i, parent := range parents {
+			children := yamldoc.EnsureMapping(parent, "children")
+			if children.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("group %s: children: expected a mapping", name)
+			}
+
+			// The group is defined under its first parent and listed under the others.
+			value := node
+			if i > 0 {
+				value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+			}
+			children.Content = append(children.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+		}
+		nodes[name] = node
+
+		return node, nil
+	}
+
+	for _, g := range groups {
+		node, err := add(g.Name, nil)
+		if err != nil {
+			return err
+		}
+
+		members := yamldoc.EnsureMapping(node, "hosts")
+		if members.Kind != yaml.MappingNode {
+			return fmt.Errorf("group %s: hosts: expected a mapping", g.Name)
+		}
+		for _, h := range g.Hosts {
+			if indexOfHost(hosts, h) >= 0 && yamldoc.MappingValue(members, h) == nil {
+				members.Content = append(members.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: h},
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"})
+			}
+		}
+		if len(members.Content) == 0 {
+			yamldoc.DeleteKey(node, "hosts")
+		}
+
+		if len(g.Vars) == 0 {
+			continue
+		}
+		variables := yamldoc.EnsureMapping(node, "vars")
+		if variables.Kind != yaml.MappingNode {
+			return fmt.Errorf("group %s: vars: expected a mapping", g.Name)
+		}
+		for _, k := range sortedKeys(g.Vars) {
+			if err = yamldoc.SetKey(variables, k, g.Vars[k]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// A group that was in the file before is defined where it was, its new parents list it.
+	for _, g := range groups {
+		for _, p := range g.Parents {
+			parent, err := add(p, nil)
+			if err != nil {
+				return err
+			}
+
+			children := yamldoc.EnsureMapping(parent, "children")
+			if children.Kind != yaml.MappingNode {
+				return fmt.Errorf("group %s: children: expected a mapping", p)
+			}
+			if yamldoc.MappingValue(children, g.Name) == nil {
+				children.Content = append(children.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: g.Name},
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"})
+			}
+		}
+	}
+
+	return nil
+}
+
+// groupNodes returns the mapping of every group of all by name, the first one where a group is defined.
+func groupNodes(all *yaml.Node) map[string]*yaml.Node {
+	nodes := map[string]*yaml.Node{"all": all}
+	var walk func(group *yaml.Node, depth int)
+	walk = func(group *yaml.Node, depth int) {
+		children := yamldoc.MappingValue(group, "children")
+		if depth > 64 || children == nil || children.Kind != yaml.MappingNode {
+			return
+		}
+
+		for i := 0; i+1 < len(children.Content); i += 2 {
+			name, value := children.Content[i].Value, children.Content[i+1]
+			if n := nodes[name]; n == nil || isEmptyGroup(n) && !isEmptyGroup(value) {
+				nodes[name] = value
+			}
+			walk(value, depth+1)
+		}
+	}
+	walk(all, 0)
+
+	return nodes
+}
+
+// isEmptyGroup reports whether the group is only listed, without hosts, variables or children.
+func isEmptyGroup(node *yaml.Node) bool {
+	return node.Kind != yaml.MappingNode || len(node.Content) == 0
+}
+
+func indexOfHost(hosts []InventoryHost, name string) int {
+	for i, h := range hosts {
+		if h.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func setHost(node *yaml.Node, h Host) {
+	setHostScalar(node, "ansible_host", "!!str", h.AnsibleHost)
+	setHostScalar(node, "ansible_connection", "!!str", h.AnsibleConnection)
+	setHostScalar(node, "ansible_user", "!!str", h.AnsibleUser)
+	setHostScalar(node, "ansible_ssh_private_key_file", "!!str", h.AnsibleSSHPrivateKeyFile)
+	// An unchanged password keeps its !vault tag.
+	if v := yamldoc.MappingValue(node, "ansible_ssh_pass"); v == nil || v.Value != h.AnsibleSSHPass {
+		setHostScalar(node, "ansible_ssh_pass", "!!str", h.AnsibleSSHPass)
+	}
+	if h.AnsiblePort != 0 {
+		setHostScalar(node, "ansible_port", "!!int", strconv.Itoa(h.AnsiblePort))
+	} else {
+		yamldoc.DeleteKey(node, "ansible_port")
+	}
+
+	services := yamldoc.MappingValue(node, "webitel_services")
+	// The services of a host in groups may be set in the variables of a group.
+	if services == nil && len(h.WebitelServices) == 0 && len(h.Groups) > 0 {
+		return
+	}
+	if services == nil {
+		services = &yaml.Node{}
+		insertHostKey(node, "webitel_services", services)
+	}
+	services.Kind, services.Tag, services.Value, services.Style = yaml.SequenceNode, "!!seq", "", 0
+	if len(h.WebitelServices) == 0 {
+		services.Style = yaml.FlowStyle
+	}
+
+	services.Content = make([]*yaml.Node, 0, len(h.WebitelServices))
+	for _, s := range h.WebitelServices {
+		services.Content = append(services.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s})
+	}
+}
+
+// setHostScalar sets a scalar host key, an empty value removes the key.
+func setHostScalar(node *yaml.Node, key, tag, value string) {
+	if value == "" && key != "ansible_host" {
+		yamldoc.DeleteKey(node, key)
+		return
+	}
+
+	v := yamldoc.MappingValue(node, key)
+	if v == nil {
+		insertHostKey(node, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+		return
+	}
+
+	if v.Kind != yaml.ScalarNode {
+		v.Style = 0
+	}
+	v.Kind, v.Tag, v.Value, v.Content = yaml.ScalarNode, tag, value, nil
+}
+
+// insertHostKey inserts the key after the last of the hostKeys before it, at the start when there is none.
+func insertHostKey(node *yaml.Node, key string, value *yaml.Node) {
+	at := 0
+	for _, k := range hostKeys {
+		if k == key {
+			break
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == k && i+2 > at {
+				at = i + 2
+			}
+		}
+	}
+
+	kv := []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value}
+	node.Content = append(node.Content[:at], append(kv, node.Content[at:]...)...)
+}
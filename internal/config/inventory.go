@@ -0,0 +1,224 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"sort"
+)
+
+// InventoryGroup is a group of the inventory merged from every place it is defined, as Ansible reads it.
+type InventoryGroup struct {
+	Name     string
+	Depth    int      // 0 for all, 1 for its children and so on, the deepest path counts
+	Parents  []string // Empty for all
+	Children []string
+	Hosts    []string // Hosts listed in the group itself, not in its children
+	Vars     map[string]any
+	Extra    map[string]any // Keys of the group unknown to wdeploy
+}
+
+// Groups returns the groups of the inventory: all first, then the rest by depth and name.
+func (i Inventory) Groups() []InventoryGroup {
+	groups := make(map[string]*InventoryGroup)
+	var walk func(name, parent string, g Group, path []string)
+	walk = func(name, parent string, g Group, path []string) {
+		// Ansible refuses a group that is its own ancestor, the loop is not followed but its hosts are kept.
+		loop := contains(path, name)
+
+		group := groups[name]
+		if group == nil {
+			group = &InventoryGroup{Name: name, Vars: make(map[string]any), Extra: make(map[string]any)}
+			groups[name] = group
+		}
+		if parent != "" && !loop && !contains(group.Parents, parent) {
+			group.Parents = append(group.Parents, parent)
+		}
+		for _, h := range sortedKeys(g.Hosts) {
+			if !contains(group.Hosts, h) {
+				group.Hosts = append(group.Hosts, h)
+			}
+		}
+		for k, v := range g.Vars {
+			group.Vars[k] = v
+		}
+		for k, v := range g.Extra {
+			group.Extra[k] = v
+		}
+
+		if loop {
+			return
+		}
+
+		for _, child := range sortedKeys(g.Children) {
+			if !contains(group.Children, child) {
+				group.Children = append(group.Children, child)
+			}
+			walk(child, name, g.Children[child], append(path, name))
+		}
+	}
+	walk("all", "", i.Inventory, nil)
+
+	var depth func(name string) int
+	depth = func(name string) int {
+		d := 0
+		for _, p := range groups[name].Parents {
+			if pd := depth(p) + 1; pd > d {
+				d = pd
+			}
+		}
+
+		return d
+	}
+
+	result := make([]InventoryGroup, 0, len(groups))
+	for name, g := range groups {
+		g.Depth = depth(name)
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].Depth != result[b].Depth {
+			return result[a].Depth < result[b].Depth
+		}
+
+		return result[a].Name < result[b].Name
+	})
+
+	return result
+}
+
+// Hosts returns the hosts of every group with the values Ansible uses for them: the variables of all,
+// then of the other groups of the host by depth and name, then the variables set on the host itself.
+// A host listed in several groups gets the variables set in each of them.
+func (i Inventory) Hosts() map[string]Host {
+	groups := i.Groups()
+	index := make(map[string]int, len(groups))
+	for n, g := range groups {
+		index[g.Name] = n
+	}
+
+	// The groups of every host with the groups of their children.
+	memberOf := make(map[string]map[string]bool)
+	var addGroup func(host, group string)
+	addGroup = func(host, group string) {
+		if memberOf[host][group] {
+			return
+		}
+		memberOf[host][group] = true
+		for _, p := range groups[index[group]].Parents {
+			addGroup(host, p)
+		}
+	}
+	for _, g := range groups {
+		for _, h := range g.Hosts {
+			if memberOf[h] == nil {
+				memberOf[h] = make(map[string]bool)
+			}
+			addGroup(h, g.Name)
+		}
+	}
+
+	definitions := make(map[string][]Host)
+	i.Inventory.hostDefinitions(definitions)
+
+	hosts := make(map[string]Host, len(memberOf))
+	for name, member := range memberOf {
+		h := Host{Vars: make(map[string]any)}
+		for _, g := range groups {
+			if !member[g.Name] {
+				continue
+			}
+
+			h = h.override(hostFromVars(g.Vars))
+			if g.Name != "all" {
+				h.Groups = append(h.Groups, g.Name)
+			}
+		}
+
+		for _, d := range definitions[name] {
+			h = h.override(d)
+		}
+
+		hosts[name] = h
+	}
+
+	return hosts
+}
+
+// hostDefinitions collects the hosts listed in the group and its children, in the order Groups walks them.
+func (g Group) hostDefinitions(definitions map[string][]Host) {
+	for _, name := range sortedKeys(g.Hosts) {
+		definitions[name] = append(definitions[name], g.Hosts[name])
+	}
+
+	for _, name := range sortedKeys(g.Children) {
+		g.Children[name].hostDefinitions(definitions)
+	}
+}
+
+// override returns the host with the values set in o replacing its own.
+func (h Host) override(o Host) Host {
+	set := func(value *string, other string) {
+		if other != "" {
+			*value = other
+		}
+	}
+
+	set(&h.AnsibleHost, o.AnsibleHost)
+	set(&h.AnsibleConnection, o.AnsibleConnection)
+	set(&h.AnsibleUser, o.AnsibleUser)
+	set(&h.AnsibleSSHPrivateKeyFile, o.AnsibleSSHPrivateKeyFile)
+	set(&h.AnsibleSSHPass, o.AnsibleSSHPass)
+	if o.AnsiblePort != 0 {
+		h.AnsiblePort = o.AnsiblePort
+	}
+	if o.WebitelServices != nil {
+		h.WebitelServices = o.WebitelServices
+	}
+
+	vars := make(map[string]any, len(h.Vars)+len(o.Vars))
+	for k, v := range h.Vars {
+		vars[k] = v
+	}
+	for k, v := range o.Vars {
+		vars[k] = v
+	}
+	h.Vars = vars
+
+	return h
+}
+
+// hostFromVars reads the variables of a group as the variables of a host. A value of the wrong type is
+// skipped, the validator reports it.
+func hostFromVars(vars map[string]any) Host {
+	var h Host
+	if len(vars) == 0 {
+		return h
+	}
+
+	content, err := yaml.Marshal(vars)
+	if err != nil {
+		return h
+	}
+	_ = yaml.Unmarshal(content, &h)
+
+	return h
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
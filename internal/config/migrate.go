@@ -0,0 +1,34 @@
+package config
+
+import (
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/yamldoc"
+	"sort"
+)
+
+// MigrateVars renames the keys of vars.RenamedKeys in the variables file in place, keeping their values and
+// comments. A key that is set under its new name too is left for the validator to report. It returns the old
+// names of the renamed keys, the previous content is kept as a revision.
+func (c *Config) MigrateVars() ([]string, error) {
+	renamed := make([]string, 0)
+	_, err := c.editDocument(VarsConfig, func(doc *yamldoc.Document) (bool, error) {
+		root := doc.Root()
+		for old, name := range vars.RenamedKeys {
+			if yamldoc.MappingValue(root, old) == nil || yamldoc.MappingValue(root, name) != nil {
+				continue
+			}
+
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if root.Content[i].Value == old {
+					root.Content[i].Value = name
+				}
+			}
+			renamed = append(renamed, old)
+		}
+		sort.Strings(renamed)
+
+		return len(renamed) > 0, nil
+	})
+
+	return renamed, err
+}
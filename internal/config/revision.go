@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/kirychukyurii/wdeploy/internal/lib/revision"
+	"path/filepath"
+)
+
+// Revisions returns the store of the previous contents of the config file.
+func (c Config) Revisions(configFileType int) revision.Store {
+	return revision.New(filepath.Join(c.RevisionsDirectory, ConfigFileNames[configFileType]))
+}
+
+// SaveRevision keeps the current content of the config file before it is changed, see revision.Store.Save.
+func (c Config) SaveRevision(configFileType int) error {
+	if c.RevisionsDirectory == "" {
+		return nil
+	}
+
+	_, err := c.Revisions(configFileType).Save(c.ConfigFiles[configFileType])
+
+	return err
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"github.com/kirychukyurii/wdeploy/internal/lib/yamldoc"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const secretMask = "********"
+
+var secretLineRegexp = regexp.MustCompile(`^(\s*(?:- )?)([\w.-]+):\s*(.*)$`)
+
+// loadVaultPassword reads the vault password from VaultPasswordFile or prompts for it when AskVaultPass is set.
+func (c *Config) loadVaultPassword() error {
+	switch {
+	case c.VaultPasswordFile != "":
+		content, err := os.ReadFile(c.VaultPasswordFile)
+		if err != nil {
+			return err
+		}
+
+		c.VaultPassword = strings.TrimRight(string(content), "\r\n")
+	case c.AskVaultPass:
+		fmt.Print("Vault password: ")
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return err
+		}
+
+		c.VaultPassword = string(password)
+	}
+
+	if (c.VaultPasswordFile != "" || c.AskVaultPass) && c.VaultPassword == "" {
+		return errors.New("vault password is empty")
+	}
+
+	return nil
+}
+
+// EncryptSecrets replaces plaintext values of vars.SecretKeys in the config file with vault-encrypted ones.
+// It does nothing without a vault password, and returns the number of encrypted values.
+func (c *Config) EncryptSecrets(configFileType int) (int, error) {
+	if c.VaultPassword == "" {
+		return 0, nil
+	}
+
+	path := c.ConfigFiles[configFileType]
+	doc, err := yamldoc.Read(path)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := encryptSecrets(doc.Node(), c.VaultPassword)
+	if err != nil || n == 0 {
+		return n, err
+	}
+
+	return n, doc.Write(path)
+}
+
+func encryptSecrets(node *yaml.Node, password string) (int, error) {
+	n := 0
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v := node.Content[i+1]
+			if !isSecretKey(node.Content[i].Value) || v.Kind != yaml.ScalarNode ||
+				v.Tag == vault.Tag || v.ShortTag() == "!!null" || v.Value == "" {
+				continue
+			}
+
+			encrypted, err := vault.Encrypt(v.Value, password)
+			if err != nil {
+				return n, err
+			}
+
+			v.Tag, v.Value, v.Style = vault.Tag, encrypted, yaml.LiteralStyle
+			n++
+		}
+	}
+
+	for _, child := range node.Content {
+		m, err := encryptSecrets(child, password)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// decryptSecrets replaces !vault scalars with their plaintext so the node can be decoded into a struct.
+// Without a password, or with a wrong one, the values are left encrypted; the first error is returned.
+func decryptSecrets(node *yaml.Node, password string) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == vault.Tag {
+		node.Tag = "!!str"
+		if password == "" {
+			return nil
+		}
+
+		plaintext, err := vault.Decrypt(node.Value, password)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = plaintext
+
+		return nil
+	}
+
+	var firstErr error
+	for _, child := range node.Content {
+		if err := decryptSecrets(child, password); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func isSecretKey(key string) bool {
+	for _, k := range vars.SecretKeys {
+		if key == k {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaskValue returns the scalar value of the key for display, values of vars.SecretKeys and vault-encrypted
+// values are masked.
+func MaskValue(key string, node *yaml.Node) string {
+	switch {
+	case node.Tag == vault.Tag:
+		return fmt.Sprintf("%s %s", vault.Tag, secretMask)
+	case isSecretKey(key) && node.Value != "":
+		return secretMask
+	}
+
+	return node.Value
+}
+
+// MaskSecrets hides the values of vars.SecretKeys in YAML content for display. With reveal, plaintext
+// values are shown as is and vault-encrypted ones are decrypted when the vault password is known.
+func (c Config) MaskSecrets(content string, reveal bool) string {
+	lines := strings.Split(content, "\n")
+	masked := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		m := secretLineRegexp.FindStringSubmatch(lines[i])
+		if m == nil || !isSecretKey(m[2]) || m[3] == "" || m[3] == `""` || m[3] == "''" {
+			masked = append(masked, lines[i])
+			continue
+		}
+
+		prefix := fmt.Sprintf("%s%s: ", m[1], m[2])
+		if !strings.HasPrefix(m[3], vault.Tag) {
+			if reveal {
+				masked = append(masked, lines[i])
+			} else {
+				masked = append(masked, prefix+secretMask)
+			}
+
+			continue
+		}
+
+		// The encrypted value is a block of lines indented deeper than its key.
+		block := make([]string, 0)
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" &&
+			len(lines[i+1])-len(strings.TrimLeft(lines[i+1], " ")) > len(m[1]) {
+			i++
+			block = append(block, lines[i])
+		}
+
+		value := fmt.Sprintf("%s %s", vault.Tag, secretMask)
+		if reveal && c.VaultPassword != "" {
+			if plaintext, err := vault.Decrypt(strings.Join(block, "\n"), c.VaultPassword); err == nil {
+				value = fmt.Sprintf("%q # %s", plaintext, vault.Tag)
+			}
+		}
+		masked = append(masked, prefix+value)
+	}
+
+	return strings.Join(masked, "\n")
+}
@@ -1 +1,31 @@
 package vars
+
+// HostKeys are the host variables known to the inventory templates.
+var HostKeys = []string{
+	"ansible_host",
+	"ansible_connection",
+	"ansible_user",
+	"ansible_port",
+	"ansible_ssh_pass",
+	"ansible_ssh_private_key_file",
+	"webitel_services",
+}
+
+// ExtraVarsKeys are the variables file keys that are not part of config.Variables.
+var ExtraVarsKeys = []string{
+	"inventory",
+}
+
+// SecretKeys are the variables stored vault-encrypted when an Ansible Vault password is given.
+var SecretKeys = []string{
+	"webitel_repository_password",
+	"ansible_ssh_pass",
+	"ansible_become_pass",
+}
+
+// RenamedKeys are the variables that earlier versions of wdeploy decoded under another name, by the old name.
+// The playbook and the vars template always used the new names, wdeploy renames the old ones on load.
+var RenamedKeys = map[string]string{
+	"rtp_engine_mode":     "rtpengine_mode",
+	"opensips_fail_2_ban": "opensips_fail2ban",
+}
@@ -1 +1,88 @@
 package vars
+
+// RTPEngineModes are the values accepted by rtpengine_mode.
+var RTPEngineModes = []string{
+	"global",
+	"local",
+}
+
+// WebitelServices are the services the playbook installs, in the order it deploys them.
+// The playbook tags the tasks of every service with its name, so they are also valid --tags values.
+var WebitelServices = []string{
+	"consul",
+	"rabbitmq",
+	"postgresql",
+	"postgresql_main",
+	"grafana",
+	"freeswitch",
+	"rtpengine",
+	"opensips",
+	"nginx",
+	"webitel_core",
+	"webitel_engine",
+	"webitel_call_center",
+	"webitel_flow_manager",
+	"webitel_storage",
+	"webitel_messages",
+}
+
+// RequiredServices must be placed on at least one host of the inventory, Webitel doesn't work without them.
+var RequiredServices = []string{
+	"postgresql",
+	"rabbitmq",
+	"consul",
+	"webitel_core",
+	"freeswitch",
+	"opensips",
+	"nginx",
+}
+
+// SingletonServices can be placed on one host only, the playbook doesn't set them up as a cluster.
+var SingletonServices = []string{
+	"postgresql_main",
+	"rabbitmq",
+	"grafana",
+}
+
+// ServiceDependencies are the services that must be placed on the same host as the service of the key.
+var ServiceDependencies = map[string][]string{
+	"postgresql_main": {"postgresql"},
+}
+
+// ServiceConflicts are the pairs of services that can't be placed on the same host when the inventory has several
+// hosts: FreeSWITCH takes the SIP port of OpenSIPS and its RTP port range overlaps the one of rtpengine. The all-in-one
+// inventory, a single host with every service, is the layout the playbook moves FreeSWITCH to other ports for.
+var ServiceConflicts = [][2]string{
+	{"opensips", "freeswitch"},
+	{"rtpengine", "freeswitch"},
+}
+
+// GrafanaDashboardsLanguages are the languages of the basic Grafana dashboards.
+var GrafanaDashboardsLanguages = []string{
+	"en",
+	"ru",
+	"uk",
+}
+
+// Locales are the locales offered for locales_gen, any other locale of the system can be written to the file.
+var Locales = []string{
+	"en_US.UTF-8",
+	"en_GB.UTF-8",
+	"uk_UA.UTF-8",
+	"ru_RU.UTF-8",
+	"kk_KZ.UTF-8",
+	"pl_PL.UTF-8",
+	"de_DE.UTF-8",
+	"fr_FR.UTF-8",
+	"es_ES.UTF-8",
+	"it_IT.UTF-8",
+	"pt_BR.UTF-8",
+}
+
+// Choices are the values offered for the variables that take one of a fixed set of values,
+// or a few of them for list variables.
+var Choices = map[string][]string{
+	"rtpengine_mode":                    RTPEngineModes,
+	"grafana_basic_dashboards_language": GrafanaDashboardsLanguages,
+	"locales_gen":                       Locales,
+}
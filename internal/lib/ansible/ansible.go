@@ -10,18 +10,21 @@ import (
 	"github.com/kirychukyurii/wdeploy/internal/config"
 	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
 	"io"
+	"os"
 	"path/filepath"
+	"time"
 )
 
 const (
-	playbookFileName    = "playbook.yml"
+	PlaybookFileName    = "playbook.yml"
 	unixyStdoutCallback = "unixy"
 )
 
 type Executor struct {
-	cfg    config.Config
-	logger logger.Logger
-	writer io.Writer
+	cfg        config.Config
+	logger     logger.Logger
+	writer     io.Writer
+	eventsFile string
 }
 
 func NewExecutor(cfg config.Config, logger logger.Logger, writer io.Writer) Executor {
@@ -32,38 +35,108 @@ func NewExecutor(cfg config.Config, logger logger.Logger, writer io.Writer) Exec
 	}
 }
 
-func (e Executor) RunPlaybook() error {
+// WithEventsFile returns a copy of the executor that writes playbook events as JSON lines to path,
+// see the events package.
+func (e Executor) WithEventsFile(path string) Executor {
+	e.eventsFile = path
+
+	return e
+}
+
+// RunPlaybook runs the playbook and returns how long ansible-playbook took.
+// Cancelling ctx stops ansible-playbook and RunPlaybook returns ErrAborted.
+func (e Executor) RunPlaybook(ctx context.Context) (time.Duration, error) {
 	ansiblePlaybookConnectionOptions := &options.AnsibleConnectionOptions{
 		SSHCommonArgs: e.cfg.AnsibleSSHExtraArgs,
 	}
 
+	vaultPasswordFile, err := e.vaultPasswordFile()
+	if err != nil {
+		return 0, err
+	}
+	if vaultPasswordFile != e.cfg.VaultPasswordFile {
+		defer os.Remove(vaultPasswordFile)
+	}
+
 	ansiblePlaybookOptions := &playbook.AnsiblePlaybookOptions{
-		Inventory:     e.cfg.ConfigFiles[config.InventoryConfig],
-		ExtraVarsFile: []string{fmt.Sprintf("@%s", e.cfg.ConfigFiles[config.VarsConfig])},
+		Inventory:         e.cfg.ConfigFiles[config.InventoryConfig],
+		ExtraVarsFile:     []string{fmt.Sprintf("@%s", e.cfg.ConfigFiles[config.VarsConfig])},
+		VaultPasswordFile: vaultPasswordFile,
+		Check:             e.cfg.CheckMode,
+		Diff:              e.cfg.CheckMode,
+		Limit:             e.cfg.Limit,
+		Tags:              e.cfg.Tags,
+	}
+
+	executeOptions := []execute.ExecuteOptions{
+		execute.WithEnvVar("ANSIBLE_FORCE_COLOR", "true"),
+		execute.WithEnvVar("ANSIBLE_STDOUT_CALLBACK", unixyStdoutCallback),
+		execute.WithWrite(e.writer),
+		execute.WithWriteError(e.writer),
+	}
+
+	if e.eventsFile != "" {
+		callbackDir, err := writeEventsCallback()
+		if err != nil {
+			return 0, err
+		}
+		defer os.RemoveAll(callbackDir)
+
+		executeOptions = append(executeOptions,
+			execute.WithEnvVar("ANSIBLE_CALLBACK_PLUGINS", appendEnvList("ANSIBLE_CALLBACK_PLUGINS", callbackDir, ":")),
+			execute.WithEnvVar("ANSIBLE_CALLBACKS_ENABLED", appendEnvList("ANSIBLE_CALLBACKS_ENABLED", eventsCallbackName, ",")),
+			// Name of the setting before Ansible 2.11.
+			execute.WithEnvVar("ANSIBLE_CALLBACK_WHITELIST", appendEnvList("ANSIBLE_CALLBACK_WHITELIST", eventsCallbackName, ",")),
+			execute.WithEnvVar(eventsFileEnvVar, e.eventsFile),
+		)
 	}
 
 	executorTimeMeasurement := measure.NewExecutorTimeMeasurement(
-		execute.NewDefaultExecute(
-			execute.WithEnvVar("ANSIBLE_FORCE_COLOR", "true"),
-			execute.WithEnvVar("ANSIBLE_STDOUT_CALLBACK", unixyStdoutCallback),
-			execute.WithWrite(e.writer),
-			execute.WithWriteError(e.writer),
-		),
+		newProcessGroupExecute(executeOptions...),
 	)
 
 	pb := &playbook.AnsiblePlaybookCmd{
-		Playbooks:         []string{filepath.Join(e.cfg.PlaybookTempDir, playbookFileName)},
+		Playbooks:         []string{filepath.Join(e.cfg.PlaybookTempDir, PlaybookFileName)},
 		ConnectionOptions: ansiblePlaybookConnectionOptions,
 		Options:           ansiblePlaybookOptions,
 		Exec:              executorTimeMeasurement,
 	}
 
-	err := pb.Run(context.TODO())
+	err = pb.Run(ctx)
+	e.logger.Zap.Info(executorTimeMeasurement.Duration())
+	if ctx.Err() != nil {
+		e.logger.Zap.Warn(ErrAborted)
+
+		return executorTimeMeasurement.Duration(), ErrAborted
+	}
+
 	if err != nil {
 		e.logger.Zap.Error(err)
+
+		return executorTimeMeasurement.Duration(), err
 	}
 
-	e.logger.Zap.Info(executorTimeMeasurement.Duration())
+	return executorTimeMeasurement.Duration(), nil
+}
+
+// vaultPasswordFile returns the file ansible-playbook reads the vault password from. A password
+// entered at the prompt is written to a temporary file, which the caller removes after the run.
+func (e Executor) vaultPasswordFile() (string, error) {
+	if e.cfg.VaultPasswordFile != "" || e.cfg.VaultPassword == "" {
+		return e.cfg.VaultPasswordFile, nil
+	}
+
+	f, err := os.CreateTemp("", "wdeploy-vault-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(e.cfg.VaultPassword); err != nil {
+		os.Remove(f.Name())
+
+		return "", err
+	}
 
-	return nil
+	return f.Name(), nil
 }
@@ -0,0 +1,115 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	eventsCallbackName = "wdeploy_events"
+	eventsFileEnvVar   = "WDEPLOY_EVENTS_FILE"
+)
+
+// eventsCallbackPlugin is an Ansible notification callback appending one JSON object per
+// playbook event to the file named by WDEPLOY_EVENTS_FILE, see the events package.
+// It runs next to the stdout callback, so the human-readable output stays as it is.
+const eventsCallbackPlugin = `from __future__ import (absolute_import, division, print_function)
+__metaclass__ = type
+
+DOCUMENTATION = '''
+    name: wdeploy_events
+    type: notification
+    short_description: write playbook events as JSON lines for wdeploy
+    description:
+      - Appends one JSON object per event to the file named by the WDEPLOY_EVENTS_FILE environment variable.
+'''
+
+import json
+import os
+import time
+
+from ansible.plugins.callback import CallbackBase
+
+
+class CallbackModule(CallbackBase):
+    CALLBACK_VERSION = 2.0
+    CALLBACK_TYPE = 'notification'
+    CALLBACK_NAME = 'wdeploy_events'
+    CALLBACK_NEEDS_ENABLED = True
+
+    def __init__(self):
+        super(CallbackModule, self).__init__()
+        path = os.environ.get('WDEPLOY_EVENTS_FILE')
+        self._file = open(path, 'a') if path else None
+        self._play = ''
+
+    def _emit(self, event, **fields):
+        if self._file is None:
+            return
+        fields['event'] = event
+        fields['time'] = time.time()
+        self._file.write(json.dumps(fields) + '\n')
+        self._file.flush()
+
+    def _task(self, task, handler):
+        self._emit('task_start', play=self._play, task=task.get_name().strip(), task_id=task._uuid, handler=handler)
+
+    def _runner(self, result, status, **fields):
+        msg = result._result.get('msg', '')
+        self._emit('runner', play=self._play, task=result._task.get_name().strip(), task_id=result._task._uuid,
+                   host=result._host.get_name(), status=status, msg=msg if isinstance(msg, str) else str(msg),
+                   **fields)
+
+    def v2_playbook_on_play_start(self, play):
+        self._play = play.get_name().strip()
+        self._emit('play_start', play=self._play)
+
+    def v2_playbook_on_task_start(self, task, is_conditional):
+        self._task(task, False)
+
+    def v2_playbook_on_handler_task_start(self, task):
+        self._task(task, True)
+
+    def v2_runner_on_ok(self, result):
+        self._runner(result, 'changed' if result._result.get('changed', False) else 'ok')
+
+    def v2_runner_on_failed(self, result, ignore_errors=False):
+        self._runner(result, 'failed', ignored=ignore_errors)
+
+    def v2_runner_on_skipped(self, result):
+        self._runner(result, 'skipped')
+
+    def v2_runner_on_unreachable(self, result):
+        self._runner(result, 'unreachable')
+
+    def v2_playbook_on_stats(self, stats):
+        self._emit('stats', stats=dict((h, stats.summarize(h)) for h in sorted(stats.processed.keys())))
+        if self._file is not None:
+            self._file.close()
+            self._file = None
+`
+
+// writeEventsCallback writes the callback plugin into a new temporary directory and returns it.
+func writeEventsCallback() (string, error) {
+	dir, err := os.MkdirTemp("", "wdeploy-callback-")
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, eventsCallbackName+".py"), []byte(eventsCallbackPlugin), 0644); err != nil {
+		os.RemoveAll(dir)
+
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// appendEnvList appends value to the list in the environment variable key, so settings of the user are kept.
+func appendEnvList(key, value, separator string) string {
+	if current := os.Getenv(key); current != "" {
+		return current + separator + value
+	}
+
+	return value
+}
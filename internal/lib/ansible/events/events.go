@@ -0,0 +1,161 @@
+// Package events reads playbook events written by the wdeploy_events callback plugin
+// and folds them into a per-host, per-task progress model.
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Type is the kind of event.
+type Type string
+
+const (
+	TypePlayStart Type = "play_start"
+	TypeTaskStart Type = "task_start"
+	TypeRunner    Type = "runner"
+	TypeStats     Type = "stats"
+)
+
+// Status is the result of a task on a host.
+type Status string
+
+const (
+	StatusPending     Status = ""
+	StatusOk          Status = "ok"
+	StatusChanged     Status = "changed"
+	StatusFailed      Status = "failed"
+	StatusSkipped     Status = "skipped"
+	StatusUnreachable Status = "unreachable"
+)
+
+// HostStats are the final counters of a host as reported by ansible-playbook.
+type HostStats struct {
+	Ok          int `json:"ok"`
+	Changed     int `json:"changed"`
+	Failures    int `json:"failures"`
+	Unreachable int `json:"unreachable"`
+	Skipped     int `json:"skipped"`
+	Rescued     int `json:"rescued"`
+	Ignored     int `json:"ignored"`
+}
+
+// Failed reports whether the host failed or was unreachable.
+func (s HostStats) Failed() bool {
+	return s.Failures > 0 || s.Unreachable > 0
+}
+
+// Event is a single line of the events file.
+type Event struct {
+	Type    Type                 `json:"event"`
+	Time    float64              `json:"time"`
+	Play    string               `json:"play,omitempty"`
+	Task    string               `json:"task,omitempty"`
+	TaskID  string               `json:"task_id,omitempty"`
+	Handler bool                 `json:"handler,omitempty"`
+	Host    string               `json:"host,omitempty"`
+	Status  Status               `json:"status,omitempty"`
+	Ignored bool                 `json:"ignored,omitempty"`
+	Message string               `json:"msg,omitempty"`
+	Stats   map[string]HostStats `json:"stats,omitempty"`
+}
+
+// Parse decodes one line of the events file.
+func Parse(line []byte) (Event, error) {
+	var e Event
+	if err := json.Unmarshal(line, &e); err != nil {
+		return e, err
+	}
+
+	switch e.Type {
+	case TypePlayStart, TypeTaskStart, TypeStats:
+	case TypeRunner:
+		if e.Host == "" || e.TaskID == "" {
+			return e, fmt.Errorf("events: runner event without host or task")
+		}
+	default:
+		return e, fmt.Errorf("events: unknown event %q", e.Type)
+	}
+
+	return e, nil
+}
+
+// Read parses every complete line of r, calling handle for each event. Lines that fail to parse are skipped.
+func Read(r io.Reader, handle func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if e, err := Parse(scanner.Bytes()); err == nil {
+			handle(e)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// pollInterval is how often Tail checks the events file for new lines.
+const pollInterval = 200 * time.Millisecond
+
+// Tail follows the events file at path until ctx is done, then reads what is left and returns.
+// The file may not exist yet when Tail starts.
+func Tail(ctx context.Context, path string, handle func(Event)) error {
+	var f *os.File
+	for f == nil {
+		done := ctx.Err() != nil
+
+		var err error
+		if f, err = os.Open(path); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			// The playbook finished without writing a single event.
+			if done {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var partial []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		partial = append(partial, line...)
+		if err == nil {
+			if e, err := Parse(bytes.TrimSpace(partial)); err == nil {
+				handle(e)
+			}
+			partial = partial[:0]
+
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			// The writer has finished, one more pass picks up the last lines.
+			rest, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+
+			return Read(io.MultiReader(bytes.NewReader(partial), bytes.NewReader(rest)), handle)
+		case <-time.After(pollInterval):
+		}
+	}
+}
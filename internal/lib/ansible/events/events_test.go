@@ -0,0 +1,274 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return content
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    Event
+		wantErr bool
+	}{
+		{
+			name: "play start",
+			line: `{"play": "Webitel", "event": "play_start", "time": 1697606633.1203}`,
+			want: Event{Type: TypePlayStart, Play: "Webitel", Time: 1697606633.1203},
+		},
+		{
+			name: "ignored failure",
+			line: `{"task": "check", "task_id": "1", "host": "node2", "status": "failed", "msg": "rc 1", "ignored": true, "event": "runner", "time": 1}`,
+			want: Event{Type: TypeRunner, Task: "check", TaskID: "1", Host: "node2", Status: StatusFailed, Message: "rc 1", Ignored: true, Time: 1},
+		},
+		{name: "not json", line: `not json at all`, wantErr: true},
+		{name: "partial line", line: `{"play": "Webitel", "task": "Gathering Facts", "task_id": "0242`, wantErr: true},
+		{name: "empty line", line: ``, wantErr: true},
+		{name: "unknown event", line: `{"event": "playbook_on_include"}`, wantErr: true},
+		{name: "runner without host", line: `{"event": "runner", "task_id": "1", "status": "ok"}`, wantErr: true},
+		{name: "runner without task", line: `{"event": "runner", "host": "node1", "status": "ok"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.line))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Type != tt.want.Type || got.Play != tt.want.Play || got.Task != tt.want.Task ||
+				got.TaskID != tt.want.TaskID || got.Host != tt.want.Host || got.Status != tt.want.Status ||
+				got.Message != tt.want.Message || got.Ignored != tt.want.Ignored || got.Time != tt.want.Time {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStats(t *testing.T) {
+	lines := bytes.Split(bytes.TrimSpace(readFixture(t, "deploy.jsonl")), []byte("\n"))
+
+	e, err := Parse(lines[len(lines)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]HostStats{
+		"node1": {Ok: 3, Changed: 2},
+		"node2": {Ok: 2, Failures: 1, Skipped: 1, Ignored: 1},
+		"node3": {Unreachable: 1},
+	}
+	if len(e.Stats) != len(want) {
+		t.Fatalf("Stats = %+v, want %+v", e.Stats, want)
+	}
+	for host, stats := range want {
+		if e.Stats[host] != stats {
+			t.Errorf("Stats[%s] = %+v, want %+v", host, e.Stats[host], stats)
+		}
+	}
+
+	if e.Stats["node1"].Failed() || !e.Stats["node2"].Failed() || !e.Stats["node3"].Failed() {
+		t.Errorf("Failed() of %+v is wrong", e.Stats)
+	}
+}
+
+func TestRead(t *testing.T) {
+	var types []Type
+	if err := Read(bytes.NewReader(readFixture(t, "deploy.jsonl")), func(e Event) {
+		types = append(types, e.Type)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(types) != 15 {
+		t.Fatalf("Read() handled %d events, want 15", len(types))
+	}
+	if types[0] != TypePlayStart || types[len(types)-1] != TypeStats {
+		t.Errorf("Read() events = %v, want play_start first and stats last", types)
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	var got []Event
+	if err := Read(bytes.NewReader(readFixture(t, "malformed.jsonl")), func(e Event) {
+		got = append(got, e)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid JSON, the unknown event, the empty line, the runner without a host and the cut off last line
+	// are skipped.
+	want := []Type{TypePlayStart, TypeTaskStart, TypeRunner}
+	if len(got) != len(want) {
+		t.Fatalf("Read() handled %+v, want events %v", got, want)
+	}
+	for i := range want {
+		if got[i].Type != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got[i].Type, want[i])
+		}
+	}
+	if got[2].Host != "node1" {
+		t.Errorf("runner host = %q, want node1", got[2].Host)
+	}
+}
+
+func TestTail(t *testing.T) {
+	lines := bytes.SplitAfter(bytes.TrimSpace(readFixture(t, "deploy.jsonl")), []byte("\n"))
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan Event, len(lines))
+	done := make(chan error, 1)
+	// The file doesn't exist yet when Tail starts.
+	go func() {
+		done <- Tail(ctx, path, func(e Event) { received <- e })
+	}()
+
+	wait := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("event %d of %d is not handled", i+1, n)
+			}
+		}
+	}
+
+	time.Sleep(2 * pollInterval)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	write := func(b []byte) {
+		t.Helper()
+		if _, err := f.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(bytes.Join(lines[:5], nil))
+	wait(5)
+
+	// A line written in two parts is handled once it is complete.
+	half := len(lines[5]) / 2
+	write(lines[5][:half])
+	time.Sleep(2 * pollInterval)
+	select {
+	case e := <-received:
+		t.Fatalf("partial line handled as %+v", e)
+	default:
+	}
+	write(lines[5][half:])
+	wait(1)
+
+	// The rest is written just before the playbook ends, the last line without a newline.
+	write(bytes.Join(lines[6:], nil))
+	cancel()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Tail didn't return after the context is done")
+	}
+
+	if n := len(received); n != len(lines)-6 {
+		t.Errorf("handled %d events after the context is done, want %d", n, len(lines)-6)
+	}
+}
+
+func TestTailMissingFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := Tail(ctx, filepath.Join(t.TempDir(), "events.jsonl"), func(Event) {}); err != nil {
+		t.Errorf("Tail() error = %v, want nil when no events were written", err)
+	}
+}
+
+func TestProgressApply(t *testing.T) {
+	p := NewProgress([]string{"node1", "node2"})
+	if err := Read(bytes.NewReader(readFixture(t, "deploy.jsonl")), p.Apply); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"node1", "node2", "node3"}; len(p.Hosts) != len(want) ||
+		p.Hosts[0] != want[0] || p.Hosts[1] != want[1] || p.Hosts[2] != want[2] {
+		t.Errorf("Hosts = %v, want %v", p.Hosts, want)
+	}
+
+	counts := []struct {
+		host   string
+		status Status
+		want   int
+	}{
+		{"node1", StatusOk, 1},
+		{"node1", StatusChanged, 2},
+		{"node1", StatusFailed, 0},
+		{"node2", StatusOk, 2}, // The ignored failure counts as ok
+		{"node2", StatusFailed, 1},
+		{"node2", StatusSkipped, 1},
+		{"node2", StatusChanged, 0},
+		{"node3", StatusUnreachable, 1},
+		{"node3", StatusOk, 0},
+	}
+	for _, c := range counts {
+		if got := p.Count(c.host, c.status); got != c.want {
+			t.Errorf("Count(%s, %q) = %d, want %d", c.host, c.status, got, c.want)
+		}
+	}
+
+	if len(p.Tasks) != 5 {
+		t.Fatalf("Tasks = %d, want 5", len(p.Tasks))
+	}
+	if current := p.Current(); current.Name != "restart nginx" || !current.Handler || current.Play != "Webitel" {
+		t.Errorf("Current() = %+v, want the restart nginx handler", current)
+	}
+	if !p.Done() {
+		t.Error("Done() = false after the stats event")
+	}
+}
+
+func TestProgressPending(t *testing.T) {
+	p := NewProgress(nil)
+	if p.Current() != nil || p.Done() {
+		t.Fatal("a new progress has a current task or is done")
+	}
+
+	p.Apply(Event{Type: TypePlayStart, Play: "Webitel"})
+	p.Apply(Event{Type: TypeTaskStart, TaskID: "1", Task: "install"})
+	if s, ok := p.Current().Results["node1"]; ok || s != StatusPending {
+		t.Errorf("result of a started task = %q, want pending", s)
+	}
+
+	p.Apply(Event{Type: TypeRunner, TaskID: "1", Host: "node1", Status: StatusOk})
+	if p.Count("node1", StatusOk) != 1 || p.Current().Play != "Webitel" {
+		t.Errorf("progress = %+v, want node1 ok in the Webitel play", p.Current())
+	}
+}
@@ -0,0 +1,114 @@
+package events
+
+// Task is a task of the playbook with its result on every host it ran on.
+type Task struct {
+	ID      string
+	Name    string
+	Play    string
+	Handler bool
+	Results map[string]Status
+}
+
+// Progress is the state of a playbook run built from its events.
+type Progress struct {
+	Hosts []string
+	Tasks []*Task
+	Play  string
+	Stats map[string]HostStats
+
+	tasks map[string]*Task
+	hosts map[string]bool
+}
+
+// NewProgress creates a progress for hosts, hosts first seen in events are appended.
+func NewProgress(hosts []string) *Progress {
+	p := &Progress{
+		Hosts: make([]string, 0, len(hosts)),
+		tasks: make(map[string]*Task),
+		hosts: make(map[string]bool),
+	}
+
+	for _, h := range hosts {
+		p.addHost(h)
+	}
+
+	return p
+}
+
+// Apply updates the progress with an event.
+func (p *Progress) Apply(e Event) {
+	switch e.Type {
+	case TypePlayStart:
+		p.Play = e.Play
+	case TypeTaskStart:
+		p.task(e)
+	case TypeRunner:
+		p.addHost(e.Host)
+		status := e.Status
+		if status == StatusFailed && e.Ignored {
+			status = StatusOk
+		}
+		p.task(e).Results[e.Host] = status
+	case TypeStats:
+		p.Stats = e.Stats
+		for h := range e.Stats {
+			p.addHost(h)
+		}
+	}
+}
+
+// Current returns the last started task, nil before the first one.
+func (p *Progress) Current() *Task {
+	if len(p.Tasks) == 0 {
+		return nil
+	}
+
+	return p.Tasks[len(p.Tasks)-1]
+}
+
+// Count returns how many tasks ended with status on host.
+func (p *Progress) Count(host string, status Status) int {
+	n := 0
+	for _, t := range p.Tasks {
+		if s, ok := t.Results[host]; ok && s == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Done reports whether the final stats have been received.
+func (p *Progress) Done() bool {
+	return p.Stats != nil
+}
+
+func (p *Progress) task(e Event) *Task {
+	if t, ok := p.tasks[e.TaskID]; ok {
+		return t
+	}
+
+	t := &Task{
+		ID:      e.TaskID,
+		Name:    e.Task,
+		Play:    e.Play,
+		Handler: e.Handler,
+		Results: make(map[string]Status),
+	}
+	if t.Play == "" {
+		t.Play = p.Play
+	}
+	p.tasks[e.TaskID] = t
+	p.Tasks = append(p.Tasks, t)
+
+	return t
+}
+
+func (p *Progress) addHost(host string) {
+	if host == "" || p.hosts[host] {
+		return
+	}
+
+	p.hosts[host] = true
+	p.Hosts = append(p.Hosts, host)
+}
@@ -0,0 +1,138 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/apenella/go-ansible/pkg/execute"
+	"github.com/apenella/go-ansible/pkg/stdoutcallback"
+	"github.com/apenella/go-ansible/pkg/stdoutcallback/results"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// killTimeout is how long an interrupted ansible-playbook has to stop its workers before it is killed.
+const killTimeout = 10 * time.Second
+
+// ErrAborted is returned by RunPlaybook when the run is aborted through its context.
+var ErrAborted = errors.New("playbook run aborted")
+
+// processGroupExecute runs ansible-playbook in a process group of its own. When the context is
+// cancelled the whole group is interrupted, like Ctrl+C in a terminal does, so the forks and ssh
+// connections of ansible-playbook stop too. The group is killed if it is still running after killTimeout.
+type processGroupExecute struct {
+	*execute.DefaultExecute
+}
+
+func newProcessGroupExecute(options ...execute.ExecuteOptions) processGroupExecute {
+	return processGroupExecute{execute.NewDefaultExecute(options...)}
+}
+
+// Execute implements execute.Executor.
+func (e processGroupExecute) Execute(ctx context.Context, command []string, resultsFunc stdoutcallback.StdoutCallbackResultsFunc, options ...execute.ExecuteOptions) error {
+	for _, opt := range options {
+		opt(e.DefaultExecute)
+	}
+
+	if resultsFunc == nil {
+		resultsFunc = results.DefaultStdoutCallbackResults
+	}
+
+	if e.Write == nil {
+		e.Write = os.Stdout
+	}
+
+	if e.WriterError == nil {
+		e.WriterError = os.Stderr
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = e.CmdRunDir
+	cmd.Env = append(os.Environ(), e.EnvVars.Environ()...)
+	// Stdin is left unset: a process outside the foreground group must not read the terminal,
+	// which belongs to the TUI anyway.
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		if err := interruptProcessGroup(cmd.Process); err != nil {
+			return err
+		}
+
+		time.AfterFunc(killTimeout, func() {
+			_ = killProcessGroup(cmd.Process)
+		})
+
+		return nil
+	}
+	// Stops waiting for the output of processes that survived the kill.
+	cmd.WaitDelay = killTimeout + time.Second
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+
+	var (
+		wg        sync.WaitGroup
+		outputErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// The context is not passed on, the output is read until ansible-playbook closes it.
+		outputErr = resultsFunc(context.Background(), stdout, e.Write, e.Transformers...)
+	}()
+	go func() {
+		defer wg.Done()
+		_ = results.DefaultStdoutCallbackResults(context.Background(), stderr, e.WriterError)
+	}()
+	wg.Wait()
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err != nil {
+		return exitError(err)
+	}
+
+	return outputErr
+}
+
+// exitError describes the exit code of ansible-playbook.
+func exitError(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	switch exitErr.ExitCode() {
+	case execute.AnsiblePlaybookErrorCodeGeneralError:
+		return fmt.Errorf("%s: %w", execute.AnsiblePlaybookErrorMessageGeneralError, err)
+	case execute.AnsiblePlaybookErrorCodeOneOrMoreHostFailed:
+		return fmt.Errorf("%s: %w", execute.AnsiblePlaybookErrorMessageOneOrMoreHostFailed, err)
+	case execute.AnsiblePlaybookErrorCodeOneOrMoreHostUnreachable:
+		return fmt.Errorf("%s: %w", execute.AnsiblePlaybookErrorMessageOneOrMoreHostUnreachable, err)
+	case execute.AnsiblePlaybookErrorCodeParserError:
+		return fmt.Errorf("%s: %w", execute.AnsiblePlaybookErrorMessageParserError, err)
+	case execute.AnsiblePlaybookErrorCodeBadOrIncompleteOptions:
+		return fmt.Errorf("%s: %w", execute.AnsiblePlaybookErrorMessageBadOrIncompleteOptions, err)
+	case execute.AnsiblePlaybookErrorCodeUserInterruptedExecution:
+		return fmt.Errorf("%s: %w", execute.AnsiblePlaybookErrorMessageUserInterruptedExecution, err)
+	case execute.AnsiblePlaybookErrorCodeUnexpectedError:
+		return fmt.Errorf("%s: %w", execute.AnsiblePlaybookErrorMessageUnexpectedError, err)
+	}
+
+	return fmt.Errorf("ansible-playbook error: %w", err)
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package ansible
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// interruptProcessGroup stops the process tree of p, Windows has no signal to interrupt it gracefully.
+func interruptProcessGroup(p *os.Process) error {
+	return killProcessGroup(p)
+}
+
+// killProcessGroup kills the process tree of p.
+func killProcessGroup(p *os.Process) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(p.Pid)).Run()
+}
@@ -0,0 +1,475 @@
+// Package configdiff compares the variables and inventory files with their snapshots in the history, so the changes
+// since the last successful deploy are known before the next one starts.
+package configdiff
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"github.com/kirychukyurii/wdeploy/internal/lib/yamldoc"
+	"gopkg.in/yaml.v3"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const secretMask = "********"
+
+// Kind is what happened to a host, a service or a variable.
+type Kind string
+
+const (
+	KindAdded   Kind = "added"
+	KindRemoved Kind = "removed"
+	KindChanged Kind = "changed"
+	KindMoved   Kind = "moved" // A service is placed on other hosts
+)
+
+// Change is a difference between the snapshot and the current config.
+type Change struct {
+	Kind Kind
+	Key  string // Host name, service name or path of the variable, e.g. node1.ansible_port
+	Old  string // Value of the snapshot, hosts of a service are comma separated
+	New  string // Current value
+}
+
+// File is the line diff of a config file that can't be compared as YAML.
+type File struct {
+	Name  string
+	Err   error // Why the file is compared line by line
+	Lines []string
+}
+
+// Diff is the difference between the config files of a run and the current ones.
+type Diff struct {
+	Since     history.Run
+	Hosts     []Change
+	Services  []Change
+	Variables []Change
+	Raw       []File
+}
+
+// New compares the config with the snapshots of the last successful deploy. It returns history.ErrNotFound
+// when there is no such deploy.
+func New(cfg config.Config) (Diff, error) {
+	run, err := history.New(cfg).LastSucceeded()
+	if err != nil {
+		return Diff{}, err
+	}
+
+	return Compare(cfg, run)
+}
+
+// Compare compares the config with the snapshots of the run.
+func Compare(cfg config.Config, run history.Run) (Diff, error) {
+	d := Diff{Since: run}
+
+	files := []struct {
+		name     string
+		old, new string
+		compare  func(old, new *yaml.Node, password string)
+	}{
+		{"vars.yml", run.VarsFile(), cfg.ConfigFiles[config.VarsConfig], d.compareVariables},
+		{"inventory.yml", run.InventoryFile(), cfg.ConfigFiles[config.InventoryConfig], d.compareInventory},
+	}
+
+	for _, f := range files {
+		oldContent, err := os.ReadFile(f.old)
+		if err != nil {
+			return Diff{}, err
+		}
+		newContent, err := os.ReadFile(f.new)
+		if err != nil {
+			return Diff{}, err
+		}
+
+		oldDoc, oldErr := parse(oldContent)
+		newDoc, newErr := parse(newContent)
+		if oldErr != nil || newErr != nil {
+			parseErr := newErr
+			if parseErr == nil {
+				parseErr = fmt.Errorf("snapshot: %w", oldErr)
+			}
+
+			// Secrets are masked before the comparison, the lines of a changed secret show no value.
+			lines := Lines(cfg.MaskSecrets(string(oldContent), false), cfg.MaskSecrets(string(newContent), false))
+			if len(lines) > 0 {
+				d.Raw = append(d.Raw, File{Name: f.name, Err: parseErr, Lines: lines})
+			}
+
+			continue
+		}
+
+		f.compare(oldDoc, newDoc, cfg.VaultPassword)
+	}
+
+	return d, nil
+}
+
+// Empty reports whether the config is the same as in the snapshots, comments and formatting aside.
+func (d Diff) Empty() bool {
+	return len(d.Hosts) == 0 && len(d.Services) == 0 && len(d.Variables) == 0 && len(d.Raw) == 0
+}
+
+// String returns the changes in the diff format: the old values prefixed with "-", the new ones with "+".
+func (d Diff) String() string {
+	s := strings.Builder{}
+	section := func(title string, changes []Change) {
+		if len(changes) == 0 {
+			return
+		}
+
+		if s.Len() > 0 {
+			s.WriteString("\n")
+		}
+		s.WriteString(title + "\n")
+		for _, c := range changes {
+			if c.Kind != KindAdded {
+				s.WriteString(fmt.Sprintf("- %s: %s\n", c.Key, c.Old))
+			}
+			if c.Kind != KindRemoved {
+				s.WriteString(fmt.Sprintf("+ %s: %s\n", c.Key, c.New))
+			}
+		}
+	}
+
+	section("Hosts", d.Hosts)
+	section("Services", d.Services)
+	section("Variables", d.Variables)
+
+	for _, f := range d.Raw {
+		if s.Len() > 0 {
+			s.WriteString("\n")
+		}
+		s.WriteString(fmt.Sprintf("%s (compared line by line: %s)\n", f.Name, f.Err))
+		s.WriteString(strings.Join(f.Lines, "\n") + "\n")
+	}
+
+	return s.String()
+}
+
+// Markdown returns the diff as a section of the deploy plan.
+func (d Diff) Markdown() string {
+	since := fmt.Sprintf("the deploy of %s (%s)", d.Since.Start.Format(time.DateTime), d.Since.ID)
+	if d.Empty() {
+		return fmt.Sprintf("## Changes\n\nThe config is the same as at %s.\n\n", since)
+	}
+
+	return fmt.Sprintf("## Changes\n\nSince %s:\n\n```diff\n%s```\n\n", since, d.String())
+}
+
+// compareVariables compares every value of the variables file by its path.
+func (d *Diff) compareVariables(old, new *yaml.Node, password string) {
+	d.Variables = append(d.Variables, compareValues(flatten(old, "", password), flatten(new, "", password))...)
+}
+
+// compareInventory compares the hosts of all.hosts, the services placed on them and the other values by path.
+// The values of a host are prefixed with its name.
+func (d *Diff) compareInventory(old, new *yaml.Node, password string) {
+	oldHosts, newHosts := inventoryHosts(old), inventoryHosts(new)
+
+	for _, h := range newHosts.names {
+		if _, ok := oldHosts.nodes[h]; !ok {
+			d.Hosts = append(d.Hosts, Change{Kind: KindAdded, Key: h, New: hostAddress(newHosts.nodes[h])})
+		}
+	}
+	for _, h := range oldHosts.names {
+		if _, ok := newHosts.nodes[h]; !ok {
+			d.Hosts = append(d.Hosts, Change{Kind: KindRemoved, Key: h, Old: hostAddress(oldHosts.nodes[h])})
+		}
+	}
+
+	// Values of hosts that are in both files, the added and removed hosts are reported as a whole.
+	oldValues, newValues := make(map[string]value), make(map[string]value)
+	for _, h := range newHosts.names {
+		if _, ok := oldHosts.nodes[h]; ok {
+			merge(oldValues, flatten(withoutKey(oldHosts.nodes[h], "webitel_services"), h, password))
+			merge(newValues, flatten(withoutKey(newHosts.nodes[h], "webitel_services"), h, password))
+		}
+	}
+	d.Hosts = append(d.Hosts, compareValues(oldValues, newValues)...)
+
+	d.Services = compareServices(oldHosts, newHosts)
+
+	// The rest of the inventory: group variables, children.
+	d.Variables = append(d.Variables, compareValues(
+		flatten(withoutHosts(old), "", password), flatten(withoutHosts(new), "", password))...)
+}
+
+// hosts are the hosts of an inventory in the order of the file.
+type hosts struct {
+	names []string
+	nodes map[string]*yaml.Node
+}
+
+func inventoryHosts(doc *yaml.Node) hosts {
+	h := hosts{nodes: make(map[string]*yaml.Node)}
+	node := yamldoc.MappingValue(yamldoc.MappingValue(doc, "all"), "hosts")
+	if node == nil || node.Kind != yaml.MappingNode {
+		return h
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		h.names = append(h.names, node.Content[i].Value)
+		h.nodes[node.Content[i].Value] = node.Content[i+1]
+	}
+
+	return h
+}
+
+// compareServices reports the services placed on other hosts, in the order of vars.WebitelServices
+// followed by the unknown services by name.
+func compareServices(old, new hosts) []Change {
+	oldPlacement, newPlacement := placement(old), placement(new)
+
+	names := make([]string, 0)
+	for _, s := range vars.WebitelServices {
+		if oldPlacement[s] != nil || newPlacement[s] != nil {
+			names = append(names, s)
+		}
+	}
+
+	unknown := make([]string, 0)
+	for _, p := range []map[string][]string{oldPlacement, newPlacement} {
+		for s := range p {
+			if !contains(names, s) && !contains(unknown, s) {
+				unknown = append(unknown, s)
+			}
+		}
+	}
+	sort.Strings(unknown)
+
+	changes := make([]Change, 0)
+	for _, s := range append(names, unknown...) {
+		before, after := strings.Join(oldPlacement[s], ", "), strings.Join(newPlacement[s], ", ")
+		switch {
+		case before == after:
+		case before == "":
+			changes = append(changes, Change{Kind: KindAdded, Key: s, New: after})
+		case after == "":
+			changes = append(changes, Change{Kind: KindRemoved, Key: s, Old: before})
+		default:
+			changes = append(changes, Change{Kind: KindMoved, Key: s, Old: before, New: after})
+		}
+	}
+
+	return changes
+}
+
+// placement returns the hosts of every service in the order of the file.
+func placement(h hosts) map[string][]string {
+	p := make(map[string][]string)
+	for _, name := range h.names {
+		services := yamldoc.MappingValue(h.nodes[name], "webitel_services")
+		if services == nil || services.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, s := range services.Content {
+			if s.Kind == yaml.ScalarNode && !contains(p[s.Value], name) {
+				p[s.Value] = append(p[s.Value], name)
+			}
+		}
+	}
+
+	return p
+}
+
+// value is a value of a config file with the order it is found in.
+type value struct {
+	index  int
+	text   string
+	secret bool // The value is compared, only a mask is shown
+}
+
+// flatten returns the values of the node by their dot separated path. Mappings are walked, any other node
+// is a single value written in the YAML flow style. Secrets are masked, encrypted values are decrypted
+// with the password to be compared.
+func flatten(node *yaml.Node, prefix string, password string) map[string]value {
+	values := make(map[string]value)
+	var walk func(node *yaml.Node, path string, secret bool)
+	walk = func(node *yaml.Node, path string, secret bool) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				p := key
+				if path != "" {
+					p = path + "." + key
+				}
+
+				walk(node.Content[i+1], p, secret || contains(vars.SecretKeys, key))
+			}
+
+			return
+		}
+
+		v := value{index: len(values)}
+		v.text, v.secret = text(node, secret, password)
+		values[path] = v
+	}
+
+	if node != nil {
+		walk(node, prefix, false)
+	}
+
+	return values
+}
+
+// text returns the value of the node on one line and whether it is a secret.
+func text(node *yaml.Node, secret bool, password string) (string, bool) {
+	if node.Kind == yaml.ScalarNode && node.Tag == vault.Tag {
+		secret = true
+		if plaintext, err := vault.Decrypt(node.Value, password); err == nil {
+			node = &yaml.Node{Kind: yaml.ScalarNode, Value: plaintext}
+		}
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		if node.ShortTag() == "!!null" {
+			return "null", secret
+		}
+
+		return node.Value, secret
+	}
+
+	content, err := yaml.Marshal(flowStyle(node))
+	if err != nil {
+		return node.Value, secret
+	}
+
+	return strings.TrimSpace(string(content)), secret
+}
+
+// compareValues returns the changed values in the order of the new file, then the removed ones.
+func compareValues(old, new map[string]value) []Change {
+	changes := make([]Change, 0)
+	for _, path := range sortedPaths(new) {
+		before, ok := old[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: KindAdded, Key: path, New: new[path].display()})
+		case before.text != new[path].text:
+			changes = append(changes, Change{Kind: KindChanged, Key: path, Old: before.display(), New: new[path].display()})
+		}
+	}
+
+	for _, path := range sortedPaths(old) {
+		if _, ok := new[path]; !ok {
+			changes = append(changes, Change{Kind: KindRemoved, Key: path, Old: old[path].display()})
+		}
+	}
+
+	return changes
+}
+
+// display returns the text of the value to show.
+func (v value) display() string {
+	switch {
+	case v.secret && v.text != "":
+		return secretMask
+	case v.text == "":
+		return `""`
+	}
+
+	return v.text
+}
+
+func sortedPaths(values map[string]value) []string {
+	paths := make([]string, 0, len(values))
+	for p := range values {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return values[paths[i]].index < values[paths[j]].index
+	})
+
+	return paths
+}
+
+func merge(dst, src map[string]value) {
+	n := len(dst)
+	for p, v := range src {
+		v.index += n
+		dst[p] = v
+	}
+}
+
+// parse returns the top level node of the YAML document, an empty mapping for an empty file.
+func parse(content []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode}, nil
+	}
+
+	return doc.Content[0], nil
+}
+
+func hostAddress(node *yaml.Node) string {
+	if address := yamldoc.MappingValue(node, "ansible_host"); address != nil && address.Value != "" {
+		return address.Value
+	}
+
+	return "-"
+}
+
+// withoutKey returns a copy of the mapping without the key.
+func withoutKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return node
+	}
+
+	c := *node
+	c.Content = make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			c.Content = append(c.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	return &c
+}
+
+// withoutHosts returns a copy of the inventory without all.hosts.
+func withoutHosts(doc *yaml.Node) *yaml.Node {
+	all := yamldoc.MappingValue(doc, "all")
+	if all == nil {
+		return doc
+	}
+
+	c := *withoutKey(doc, "all")
+	c.Content = append(c.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "all"}, withoutKey(all, "hosts"))
+
+	return &c
+}
+
+// flowStyle returns a copy of the node written on one line.
+func flowStyle(node *yaml.Node) *yaml.Node {
+	c := *node
+	if c.Kind == yaml.SequenceNode || c.Kind == yaml.MappingNode {
+		c.Style = yaml.FlowStyle
+	}
+
+	c.Content = make([]*yaml.Node, 0, len(node.Content))
+	for _, child := range node.Content {
+		c.Content = append(c.Content, flowStyle(child))
+	}
+
+	return &c
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
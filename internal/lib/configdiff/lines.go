@@ -0,0 +1,59 @@
+package configdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lines returns the lines removed from old prefixed with "-" and the lines added in new prefixed with "+",
+// every group of changed lines starts with the number of its first line in new.
+func Lines(old, new string) []string {
+	a, b := strings.Split(old, "\n"), strings.Split(new, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]string, 0)
+	inHunk := false
+	hunk := func(j int) {
+		if !inHunk {
+			lines = append(lines, fmt.Sprintf("@@ line %d @@", j+1))
+			inHunk = true
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			inHunk = false
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			hunk(j)
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			hunk(j)
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+
+	return lines
+}
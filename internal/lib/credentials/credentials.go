@@ -0,0 +1,142 @@
+// Package credentials verifies Webitel package repository credentials
+// with an authenticated request before anything is deployed.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a credential probe.
+type Status int
+
+const (
+	StatusOK Status = iota
+	StatusAuthFailed
+	StatusUnreachable
+	StatusUnexpected // The repository answered with a status that says nothing about the credentials
+	StatusUnverified // The URL is served without credentials, so accepting them proves nothing
+)
+
+func (s Status) String() string {
+	return []string{
+		"ok",
+		"authentication failed",
+		"unreachable",
+		"unexpected response",
+		"not verified",
+	}[s]
+}
+
+// DefaultTimeout limits a single probe request.
+const DefaultTimeout = 10 * time.Second
+
+// Suite is the distribution of the packages, its Release file is probed.
+const Suite = "bookworm"
+
+// ReleaseURL returns the URL of the Release file of the Webitel version in the package repository, it is served
+// to licensed users only. A repository URL that already points at a Release file is returned as is, and so is
+// the repository URL when the version is not known.
+func ReleaseURL(repository, version string) string {
+	if version == "" || strings.HasSuffix(repository, "/Release") {
+		return repository
+	}
+
+	return fmt.Sprintf("%s/%s/dists/%s/Release", strings.TrimSuffix(repository, "/"), version, Suite)
+}
+
+// Result is the outcome of Prober.Probe.
+type Result struct {
+	Status     Status
+	URL        string
+	StatusCode int
+	Err        error
+}
+
+// String returns a message describing the result.
+func (r Result) String() string {
+	switch r.Status {
+	case StatusOK:
+		return fmt.Sprintf("%s: credentials accepted", r.URL)
+	case StatusAuthFailed:
+		return fmt.Sprintf("%s: credentials rejected (HTTP %d), check repository user and password", r.URL, r.StatusCode)
+	case StatusUnexpected:
+		return fmt.Sprintf("%s: unexpected response (HTTP %d), credentials not verified", r.URL, r.StatusCode)
+	case StatusUnverified:
+		return fmt.Sprintf("%s: served without credentials, credentials not verified", r.URL)
+	}
+
+	return fmt.Sprintf("%s: repository is unreachable: %s", r.URL, r.Err)
+}
+
+// Prober sends authenticated requests to the package repository.
+type Prober struct {
+	Client *http.Client
+}
+
+// NewProber creates a Prober with an HTTP client limited by DefaultTimeout.
+func NewProber() Prober {
+	return Prober{
+		Client: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Probe requests url with basic authentication. 401 and 403 responses mean the credentials were rejected, a 2xx
+// response means they were accepted unless url is also served without them. Any other response is unexpected,
+// e.g. 404 for a wrong path, and a transport error means the repository is unreachable.
+func (p Prober) Probe(ctx context.Context, url, user, password string) Result {
+	result := Result{URL: url}
+
+	code, err := p.get(ctx, url, user, password)
+	if err != nil {
+		result.Status, result.Err = StatusUnreachable, err
+		return result
+	}
+
+	result.StatusCode = code
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		result.Status = StatusAuthFailed
+	case code >= 200 && code < 300:
+		result.Status = StatusOK
+		if anonymous, err := p.get(ctx, url, "", ""); err == nil && anonymous >= 200 && anonymous < 300 {
+			result.Status = StatusUnverified
+		}
+	default:
+		result.Status = StatusUnexpected
+	}
+
+	return result
+}
+
+// get requests url, with basic authentication when user is given, and returns the status code of the response.
+func (p Prober) get(ctx context.Context, url, user, password string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return 0, unwrapURLError(err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// unwrapURLError drops the method and URL that *url.Error repeats in front of the cause.
+func unwrapURLError(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+
+	return err
+}
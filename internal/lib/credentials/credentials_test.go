@@ -0,0 +1,114 @@
+package credentials
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newRepository starts a repository that accepts webitel:demo and answers other requests with status.
+func newRepository(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); ok && user == "webitel" && password == "demo" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int // Answer of the repository to the wrong credentials
+		password       string
+		wantStatus     Status
+		wantStatusCode int
+	}{
+		{"accepted", http.StatusUnauthorized, "demo", StatusOK, http.StatusOK},
+		{"unauthorized", http.StatusUnauthorized, "wrong", StatusAuthFailed, http.StatusUnauthorized},
+		{"forbidden", http.StatusForbidden, "wrong", StatusAuthFailed, http.StatusForbidden},
+		{"server error", http.StatusInternalServerError, "wrong", StatusUnexpected, http.StatusInternalServerError},
+		{"bad gateway", http.StatusBadGateway, "wrong", StatusUnexpected, http.StatusBadGateway},
+		{"not found", http.StatusNotFound, "wrong", StatusUnexpected, http.StatusNotFound},
+		{"served without credentials", http.StatusOK, "wrong", StatusUnverified, http.StatusOK},
+		{"public with valid credentials", http.StatusOK, "demo", StatusUnverified, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRepository(t, tt.status)
+
+			result := NewProber().Probe(context.Background(), srv.URL, "webitel", tt.password)
+			if result.Status != tt.wantStatus || result.StatusCode != tt.wantStatusCode {
+				t.Errorf("Probe() = %s (HTTP %d), want %s (HTTP %d)", result.Status, result.StatusCode,
+					tt.wantStatus, tt.wantStatusCode)
+			}
+			if result.Err != nil {
+				t.Errorf("Probe() error = %v, want none for an answer", result.Err)
+			}
+			if !strings.HasPrefix(result.String(), srv.URL+": ") {
+				t.Errorf("String() = %q, want the URL first", result.String())
+			}
+			if tt.wantStatus == StatusUnexpected && !strings.Contains(result.String(), "unexpected response") {
+				t.Errorf("String() = %q, want an unexpected response", result.String())
+			}
+		})
+	}
+}
+
+func TestReleaseURL(t *testing.T) {
+	tests := []struct {
+		repository string
+		version    string
+		want       string
+	}{
+		{"https://deb.webitel.com", "23.02", "https://deb.webitel.com/23.02/dists/bookworm/Release"},
+		{"https://deb.webitel.com/", "23.02", "https://deb.webitel.com/23.02/dists/bookworm/Release"},
+		{"https://deb.webitel.com", "", "https://deb.webitel.com"},
+		{"https://mirror.example.com/webitel/dists/bullseye/Release", "23.02", "https://mirror.example.com/webitel/dists/bullseye/Release"},
+	}
+
+	for _, tt := range tests {
+		if got := ReleaseURL(tt.repository, tt.version); got != tt.want {
+			t.Errorf("ReleaseURL(%q, %q) = %q, want %q", tt.repository, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestProbeClosedPort(t *testing.T) {
+	// A port that was just free and is closed now.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + l.Addr().String()
+	l.Close()
+
+	result := NewProber().Probe(context.Background(), url, "webitel", "demo")
+	if result.Status != StatusUnreachable || result.Err == nil {
+		t.Fatalf("Probe() = %s (%v), want %s with an error", result.Status, result.Err, StatusUnreachable)
+	}
+	if s := result.String(); !strings.Contains(s, "repository is unreachable") || strings.Contains(s, "Get ") {
+		t.Errorf("String() = %q, want the cause without the request", s)
+	}
+}
+
+func TestProbeCanceled(t *testing.T) {
+	srv := newRepository(t, http.StatusUnauthorized)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if result := NewProber().Probe(ctx, srv.URL, "webitel", "demo"); result.Status != StatusUnreachable {
+		t.Errorf("Probe() = %s, want %s for a canceled context", result.Status, StatusUnreachable)
+	}
+}
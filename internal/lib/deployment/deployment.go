@@ -0,0 +1,148 @@
+// Package deployment runs the playbook for the TUI and the headless deploy command alike,
+// recording every run in the deployment history.
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible/events"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Options of a run.
+type Options struct {
+	// Context aborts the run when cancelled. Defaults to context.Background.
+	Context context.Context
+	// Output receives the ansible-playbook output.
+	Output io.Writer
+	// OnEvent, if set, is called for every playbook event while the playbook runs.
+	OnEvent func(events.Event)
+}
+
+// Run runs the playbook and records the run in the history.
+// A failure to record the run is logged and does not stop the deploy.
+func Run(cfg config.Config, logger logger.Logger, opts Options) (history.Run, error) {
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+
+	runCtx := opts.Context
+	if runCtx == nil {
+		runCtx = context.Background()
+	}
+
+	recorder, err := history.New(cfg).Begin(cfg)
+	if err != nil {
+		logger.Zap.Errorf("Failed to record the run in history: %s", err)
+	} else {
+		logger.Zap.Infof("Recording the run in %s", recorder.Run().Dir())
+		output = io.MultiWriter(output, recorder.Writer())
+	}
+
+	eventsFile, err := eventsFile(recorder)
+	if err != nil {
+		return history.Run{}, err
+	}
+	if recorder == nil {
+		defer os.Remove(eventsFile)
+	}
+
+	progress := events.NewProgress(hosts(cfg))
+	ctx, stopTail := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := events.Tail(ctx, eventsFile, func(e events.Event) {
+			progress.Apply(e)
+			if opts.OnEvent != nil {
+				opts.OnEvent(e)
+			}
+		})
+		if err != nil {
+			logger.Zap.Errorf("Failed to read playbook events: %s", err)
+		}
+	}()
+
+	duration, runErr := ansible.NewExecutor(cfg, logger, output).WithEventsFile(eventsFile).RunPlaybook(runCtx)
+	stopTail()
+	wg.Wait()
+
+	if recorder == nil {
+		return history.Run{Check: cfg.CheckMode, Duration: duration, Stats: progress.Stats}, runErr
+	}
+
+	run, err := recorder.Finish(duration, progress.Stats, runErr)
+	if err != nil {
+		logger.Zap.Errorf("Failed to record the run in history: %s", err)
+	}
+
+	return run, runErr
+}
+
+// eventsFile returns the file the playbook events are written to: the one of the run
+// or a temporary file when the run is not recorded.
+func eventsFile(recorder *history.Recorder) (string, error) {
+	if recorder != nil {
+		return recorder.Run().EventsFile(), nil
+	}
+
+	f, err := os.CreateTemp("", "wdeploy-events-")
+	if err != nil {
+		return "", err
+	}
+
+	return f.Name(), f.Close()
+}
+
+// DryRunSummary tells for every host whether a dry run found something to change.
+func DryRunSummary(stats map[string]events.HostStats) string {
+	if len(stats) == 0 {
+		return "No results per host, the playbook did not finish\n"
+	}
+
+	hosts := make([]string, 0, len(stats))
+	for h := range stats {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	s := strings.Builder{}
+	for _, h := range hosts {
+		st := stats[h]
+		result := "unchanged"
+		switch {
+		case st.Unreachable > 0:
+			result = "unreachable"
+		case st.Failures > 0:
+			result = fmt.Sprintf("failed, %d task(s) would change", st.Changed)
+		case st.Changed > 0:
+			result = fmt.Sprintf("%d task(s) would change", st.Changed)
+		}
+
+		s.WriteString(fmt.Sprintf("%-20s %s\n", h, result))
+	}
+
+	return s.String()
+}
+
+func hosts(cfg config.Config) []string {
+	inventory := cfg.Inventory.Hosts()
+	hosts := make([]string, 0, len(inventory))
+	for h := range inventory {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
@@ -0,0 +1,65 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Runs keeps track of the playbook runs of the application, so it doesn't exit while ansible-playbook runs:
+// ansible-playbook is started in its own process group and would keep running without anyone watching it.
+type Runs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+}
+
+// NewRuns creates a Runs.
+func NewRuns() *Runs {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Runs{ctx: ctx, cancel: cancel}
+}
+
+// Start registers a run. Its context is canceled by cancel or by Stop, done must be called once the run is over.
+// A run started after Stop gets a canceled context.
+func (r *Runs) Start() (ctx context.Context, cancel context.CancelFunc, done func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel = context.WithCancel(r.ctx)
+	if r.ctx.Err() != nil {
+		return ctx, cancel, func() {}
+	}
+
+	r.wg.Add(1)
+	var once sync.Once
+
+	return ctx, cancel, func() { once.Do(r.wg.Done) }
+}
+
+// Done is closed when Stop is called, the runs are not watched anymore then.
+func (r *Runs) Done() <-chan struct{} {
+	return r.ctx.Done()
+}
+
+// Stop aborts the runs and waits until they are over or ctx is done.
+func (r *Runs) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	r.cancel()
+	r.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("playbook didn't stop in time: %w", ctx.Err())
+	}
+}
@@ -1,9 +1,13 @@
 package file
 
 import (
+	"archive/tar"
 	"bufio"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -18,6 +22,15 @@ func IsFile(fp string) bool {
 	return !f.IsDir()
 }
 
+// IsDir checks whether the path is a directory.
+func IsDir(fp string) bool {
+	f, e := os.Stat(fp)
+	if e != nil {
+		return false
+	}
+	return f.IsDir()
+}
+
 func EnsureDirRW(dataDir string) error {
 	err := EnsureDir(dataDir)
 	if err != nil {
@@ -110,3 +123,106 @@ func ReadFileContent(name string) (string, error) {
 
 	return fullText, nil
 }
+
+// ExtractTarGz unpacks the .tar.gz archive into the destination directory
+func ExtractTarGz(archive, destination string) error {
+	f, err := Open(archive)
+	if err != nil {
+		return err
+	}
+	defer Close(f)
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	root, err := filepath.Abs(destination)
+	if err != nil {
+		return err
+	}
+	if err = EnsureDir(root); err != nil {
+		return err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(root, header.Name)
+		if !within(root, target) {
+			return fmt.Errorf("%s: illegal file path in archive", header.Name)
+		}
+		if target == root {
+			continue
+		}
+
+		// A link extracted before may lead the entry out of the destination.
+		if err = EnsureDir(filepath.Dir(target)); err != nil {
+			return err
+		}
+		dir, err := filepath.EvalSymlinks(filepath.Dir(target))
+		if err != nil || !within(root, dir) {
+			return fmt.Errorf("%s: illegal file path in archive", header.Name)
+		}
+		target = filepath.Join(dir, filepath.Base(target))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = EnsureDir(target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			// A file replaces a link of the same name instead of being written where it points.
+			if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if err = os.Remove(target); err != nil {
+					return err
+				}
+			}
+			if err = writeFile(target, tr, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if filepath.IsAbs(header.Linkname) || !within(root, filepath.Join(dir, header.Linkname)) {
+				return fmt.Errorf("%s: illegal link to %s in archive", header.Name, header.Linkname)
+			}
+			if err = os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			// The link may still get out through another link on its way.
+			if resolved, err := filepath.EvalSymlinks(target); err == nil && !within(root, resolved) {
+				_ = os.Remove(target)
+				return fmt.Errorf("%s: illegal link to %s in archive", header.Name, header.Linkname)
+			}
+		}
+	}
+}
+
+// within reports whether path is the directory dir or is inside it, both are clean absolute paths.
+func within(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func writeFile(name string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(f, r); err != nil {
+		Close(f)
+		return err
+	}
+
+	return Close(f)
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package file
+
+import (
+	"golang.org/x/sys/windows"
+	"os"
+)
+
+// Lock takes an exclusive lock on the file at path, creating it, and waits while another process holds it.
+// The returned function releases the lock, it is also released when the process exits.
+func Lock(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0,
+		&windows.Overlapped{}); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer f.Close()
+		return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{})
+	}, nil
+}
@@ -1,14 +1,238 @@
 package git
 
-import "github.com/go-git/go-git/v5"
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io"
+	"os"
+	"path/filepath"
+)
 
-func CloneGitRepo(repository, destination string) error {
-	_, err := git.PlainClone(destination, false, &git.CloneOptions{
-		URL: repository,
+// CloneGitRepo clones repository into destination and checks out ref: a branch, a tag or a commit hash.
+// An empty ref keeps the default branch. It returns the hash of the checked out commit.
+func CloneGitRepo(repository, destination, ref string) (string, error) {
+	r, err := git.PlainClone(destination, false, &git.CloneOptions{
+		URL:  repository,
+		Tags: git.AllTags,
 	})
+	if err != nil {
+		return "", err
+	}
+
+	if ref != "" {
+		if err = checkout(r, ref); err != nil {
+			return "", err
+		}
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.Hash().String(), nil
+}
+
+func checkout(r *git.Repository, ref string) error {
+	hash, err := resolveRef(r, ref)
+	if err != nil {
+		return err
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+
+	return w.Checkout(&git.CheckoutOptions{
+		Hash:  *hash,
+		Force: true,
+	})
+}
+
+// resolveRef resolves ref to a commit hash. A branch resolves to the fetched remote-tracking branch: a local
+// branch of the same name may be left from an earlier checkout of the cached repository and be behind it.
+// Tags, hashes and other revisions are resolved as they are.
+func resolveRef(r *git.Repository, ref string) (*plumbing.Hash, error) {
+	if remote, err := r.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref), true); err == nil {
+		hash := remote.Hash()
+		return &hash, nil
+	}
+
+	hash, err := r.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("playbook ref %q: %w", ref, plumbing.ErrReferenceNotFound)
+	}
+
+	return hash, nil
+}
+
+// HeadCommit returns the hash of the commit checked out in the repository at path.
+func HeadCommit(path string) (string, error) {
+	r, err := git.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.Hash().String(), nil
+}
+
+// Fetch downloads new commits and tags from the origin remote of the repository at path.
+func Fetch(path string) error {
+	r, err := git.PlainOpen(path)
 	if err != nil {
 		return err
 	}
 
+	err = r.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		Tags:       git.AllTags,
+		Force:      true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+
 	return nil
 }
+
+// Checkout checks out ref in the repository at path. An empty ref fast-forwards the default
+// branch to its remote-tracking branch. It returns the hash of the checked out commit.
+func Checkout(path, ref string) (string, error) {
+	r, err := git.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+
+	if ref != "" {
+		err = checkout(r, ref)
+	} else {
+		err = checkoutDefaultBranch(r)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.Hash().String(), nil
+}
+
+// checkoutDefaultBranch checks out the local branch created by clone and resets it to the remote one.
+func checkoutDefaultBranch(r *git.Repository) error {
+	branches, err := r.Branches()
+	if err != nil {
+		return err
+	}
+
+	branch, err := branches.Next()
+	branches.Close()
+	if err != nil {
+		return err
+	}
+
+	remote, err := r.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch.Name().Short()), true)
+	if err != nil {
+		return err
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if err = w.Checkout(&git.CheckoutOptions{Branch: branch.Name(), Force: true}); err != nil {
+		return err
+	}
+
+	return w.Reset(&git.ResetOptions{Commit: remote.Hash(), Mode: git.HardReset})
+}
+
+// Export writes the files of the commit of the repository at path to destination, without the repository
+// itself, so later checkouts of the repository don't change them. Submodules are not exported.
+func Export(path, commit, destination string) error {
+	r, err := git.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+
+	c, err := r.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return fmt.Errorf("commit %s: %w", commit, err)
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+
+	return tree.Files().ForEach(func(f *object.File) error {
+		target := filepath.Join(destination, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if f.Mode == filemode.Symlink {
+			link, err := f.Contents()
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		}
+
+		mode := os.FileMode(0644)
+		if f.Mode == filemode.Executable {
+			mode = 0755
+		}
+
+		return exportFile(f, target, mode)
+	})
+}
+
+func exportFile(f *object.File, target string, mode os.FileMode) error {
+	r, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// RemoteURL returns the URL of the origin remote of the repository at path.
+func RemoteURL(path string) (string, error) {
+	r, err := git.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+
+	remote, err := r.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return "", err
+	}
+
+	return remote.Config().URLs[0], nil
+}
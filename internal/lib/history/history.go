@@ -0,0 +1,395 @@
+// Package history records deploys under the profile data directory: one directory per run
+// with its metadata, the ansible-playbook output, the plan and snapshots of the config files.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible/events"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"github.com/kirychukyurii/wdeploy/internal/lib/plan"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a run.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusAborted   Status = "aborted"
+)
+
+const (
+	runFileName       = "run.json"
+	logFileName       = "ansible.log"
+	planFileName      = "plan.md"
+	varsFileName      = "vars.yml"
+	inventoryFileName = "inventory.yml"
+	eventsFileName    = "events.jsonl"
+
+	idFormat = "20060102-150405"
+
+	// Runs keep copies of the config files with passwords and repository credentials, only the user reads them.
+	dirMode  = 0700
+	fileMode = 0600
+)
+
+// ErrNotFound is returned by Store.Get when no run matches the ID.
+var ErrNotFound = errors.New("history: run not found")
+
+// Run is a record of a single deploy.
+type Run struct {
+	ID             string        `json:"id"`
+	Start          time.Time     `json:"start"`
+	End            time.Time     `json:"end"`
+	Duration       time.Duration `json:"duration"`
+	Status         Status        `json:"status"`
+	Error          string        `json:"error,omitempty"`
+	WebitelVersion string        `json:"webitel_version"`
+	Playbook       string        `json:"playbook"`
+	PlaybookRef    string        `json:"playbook_ref,omitempty"`
+	PlaybookCommit string        `json:"playbook_commit,omitempty"`
+	Hosts          []string      `json:"hosts"`
+	Check          bool          `json:"check,omitempty"` // Dry run with --check --diff
+	Limit          string        `json:"limit,omitempty"` // Hosts the run was limited to
+	Tags           string        `json:"tags,omitempty"`  // Services the run was limited to
+
+	Stats map[string]events.HostStats `json:"stats,omitempty"` // Final counters per host, if the playbook got that far
+
+	dir string
+}
+
+// Mode returns "dry run" for check mode runs and "deploy" for the others.
+func (r Run) Mode() string {
+	if r.Check {
+		return "dry run"
+	}
+
+	return "deploy"
+}
+
+// Dir returns the directory of the run.
+func (r Run) Dir() string {
+	return r.dir
+}
+
+// LogFile returns the path of the ansible-playbook output of the run.
+func (r Run) LogFile() string {
+	return filepath.Join(r.dir, logFileName)
+}
+
+// EventsFile returns the path of the playbook events of the run.
+func (r Run) EventsFile() string {
+	return filepath.Join(r.dir, eventsFileName)
+}
+
+// VarsFile returns the path of the variables file snapshot.
+func (r Run) VarsFile() string {
+	return filepath.Join(r.dir, varsFileName)
+}
+
+// InventoryFile returns the path of the inventory file snapshot.
+func (r Run) InventoryFile() string {
+	return filepath.Join(r.dir, inventoryFileName)
+}
+
+// Log returns the ansible-playbook output of the run.
+func (r Run) Log() (string, error) {
+	return file.ReadFileContent(r.LogFile())
+}
+
+// Summary returns the outcome of the run followed by the plan it deployed, in Markdown.
+func (r Run) Summary() (string, error) {
+	plan, err := file.ReadFileContent(filepath.Join(r.dir, planFileName))
+	if err != nil {
+		return "", err
+	}
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("# RUN %s\n\n", r.ID))
+	s.WriteString("| Mode | Status | Started | Duration | Commit |\n")
+	s.WriteString("|------|--------|---------|----------|--------|\n")
+	s.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n\n", r.Mode(), r.Status, r.Start.Format(time.DateTime),
+		r.Duration.Round(time.Second), r.PlaybookCommit))
+	if r.Limit != "" {
+		s.WriteString(fmt.Sprintf("Hosts: `%s`\n\n", r.Limit))
+	}
+	if r.Tags != "" {
+		s.WriteString(fmt.Sprintf("Services: `%s`\n\n", r.Tags))
+	}
+	if r.Error != "" {
+		// The executor error repeats the command and its environment, the first line is enough here.
+		s.WriteString(fmt.Sprintf("Error: `%s`\n\n", strings.SplitN(r.Error, "\n", 2)[0]))
+	}
+	if len(r.Stats) > 0 {
+		s.WriteString("| Host | Ok | Changed | Failed | Unreachable | Skipped |\n")
+		s.WriteString("|------|----|---------|--------|-------------|---------|\n")
+		for _, host := range sortedHosts(r.Stats) {
+			st := r.Stats[host]
+			s.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | %d |\n",
+				host, st.Ok, st.Changed, st.Failures, st.Unreachable, st.Skipped))
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString(plan)
+
+	return s.String(), nil
+}
+
+// Store keeps runs in a directory.
+type Store struct {
+	dir string
+}
+
+// New returns the store of the profile the config belongs to.
+func New(cfg config.Config) Store {
+	return Store{dir: cfg.HistoryDirectory}
+}
+
+// Begin creates a run with snapshots of the config files and its plan.
+func (s Store) Begin(cfg config.Config) (*Recorder, error) {
+	run := Run{
+		Start:          time.Now(),
+		Status:         StatusRunning,
+		WebitelVersion: cfg.WebitelVersion,
+		Playbook:       cfg.PlaybookRepositoryUrl,
+		PlaybookRef:    cfg.PlaybookRef,
+		PlaybookCommit: cfg.PlaybookCommit,
+		Hosts:          make([]string, 0),
+		Check:          cfg.CheckMode,
+		Limit:          cfg.Limit,
+		Tags:           cfg.Tags,
+	}
+	if cfg.PlaybookPath != "" {
+		run.Playbook = cfg.PlaybookPath
+	}
+	for name := range cfg.Inventory.Hosts() {
+		run.Hosts = append(run.Hosts, name)
+	}
+	sort.Strings(run.Hosts)
+
+	if err := s.mkdir(&run); err != nil {
+		return nil, err
+	}
+
+	if err := copyFile(cfg.ConfigFiles[config.VarsConfig], run.VarsFile()); err != nil {
+		return nil, err
+	}
+	if err := copyFile(cfg.ConfigFiles[config.InventoryConfig], run.InventoryFile()); err != nil {
+		return nil, err
+	}
+
+	content, err := plan.Render(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(filepath.Join(run.dir, planFileName), []byte(content), fileMode); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(run.LogFile(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{run: run, log: log}
+	if err = r.save(); err != nil {
+		log.Close()
+
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// mkdir creates the run directory named after the start time, with a suffix when runs start within a second.
+func (s Store) mkdir(run *Run) error {
+	if err := file.EnsureDir(s.dir); err != nil {
+		return err
+	}
+
+	id := run.Start.Format(idFormat)
+	for i := 1; ; i++ {
+		err := os.Mkdir(filepath.Join(s.dir, id), dirMode)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		id = fmt.Sprintf("%s-%d", run.Start.Format(idFormat), i)
+	}
+	run.ID, run.dir = id, filepath.Join(s.dir, id)
+
+	return nil
+}
+
+// List returns the runs, the latest first.
+func (s Store) List() ([]Run, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	runs := make([]Run, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		run, err := s.read(e.Name())
+		if err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Start.After(runs[j].Start)
+	})
+
+	return runs, nil
+}
+
+// Get returns the run with the ID or a unique prefix of it, "last" is the latest run.
+func (s Store) Get(id string) (Run, error) {
+	runs, err := s.List()
+	if err != nil {
+		return Run{}, err
+	}
+
+	if id == "last" && len(runs) > 0 {
+		return runs[0], nil
+	}
+
+	matches := make([]Run, 0)
+	for _, run := range runs {
+		if run.ID == id {
+			return run, nil
+		}
+		if strings.HasPrefix(run.ID, id) {
+			matches = append(matches, run)
+		}
+	}
+
+	if len(matches) > 1 {
+		return Run{}, fmt.Errorf("history: run ID %q is ambiguous", id)
+	}
+	if len(matches) == 0 {
+		return Run{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return matches[0], nil
+}
+
+// LastSucceeded returns the latest successful run that changed the hosts, dry runs are skipped.
+func (s Store) LastSucceeded() (Run, error) {
+	runs, err := s.List()
+	if err != nil {
+		return Run{}, err
+	}
+
+	for _, run := range runs {
+		if run.Status == StatusSucceeded && !run.Check {
+			return run, nil
+		}
+	}
+
+	return Run{}, ErrNotFound
+}
+
+func (s Store) read(id string) (Run, error) {
+	dir := filepath.Join(s.dir, id)
+	content, err := os.ReadFile(filepath.Join(dir, runFileName))
+	if err != nil {
+		return Run{}, err
+	}
+
+	var run Run
+	if err = json.Unmarshal(content, &run); err != nil {
+		return Run{}, err
+	}
+	run.dir = dir
+
+	return run, nil
+}
+
+// Recorder writes a run while it is in progress.
+type Recorder struct {
+	run Run
+	log *os.File
+}
+
+// Run returns the run being recorded.
+func (r *Recorder) Run() Run {
+	return r.run
+}
+
+// Writer returns the writer for the ansible-playbook output.
+func (r *Recorder) Writer() io.Writer {
+	return r.log
+}
+
+// Finish records the outcome of the run and closes its log.
+func (r *Recorder) Finish(duration time.Duration, stats map[string]events.HostStats, err error) (Run, error) {
+	r.run.End = time.Now()
+	r.run.Duration = duration
+	r.run.Stats = stats
+	r.run.Status = StatusSucceeded
+	if err != nil {
+		r.run.Status = StatusFailed
+		if errors.Is(err, ansible.ErrAborted) {
+			r.run.Status = StatusAborted
+		}
+		r.run.Error = err.Error()
+	}
+
+	if closeErr := r.log.Close(); closeErr != nil {
+		return r.run, closeErr
+	}
+
+	return r.run, r.save()
+}
+
+func (r *Recorder) save() error {
+	content, err := json.MarshalIndent(r.run, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(r.run.dir, runFileName), content, fileMode)
+}
+
+func sortedHosts(stats map[string]events.HostStats) []string {
+	hosts := make([]string, 0, len(stats))
+	for h := range stats {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+func copyFile(src, dst string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, content, fileMode)
+}
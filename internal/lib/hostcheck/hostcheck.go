@@ -0,0 +1,422 @@
+// Package hostcheck checks that the hosts of the inventory accept SSH connections with the user, port,
+// key and password Ansible would use, so an unreachable host is found before the playbook runs.
+package hostcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the time given to a host to accept the connection and the credentials.
+const DefaultTimeout = 10 * time.Second
+
+// Status is the outcome of the check of a host.
+type Status string
+
+const (
+	StatusReachable       Status = "reachable"
+	StatusAuthFailed      Status = "auth failed"
+	StatusTimeout         Status = "timeout"
+	StatusHostKeyMismatch Status = "host key mismatch"
+	StatusUnreachable     Status = "unreachable"
+	StatusLocal           Status = "local" // The host uses ansible_connection: local, there is nothing to connect to
+)
+
+// OK reports whether Ansible can run tasks on a host with the status.
+func (s Status) OK() bool {
+	return s == StatusReachable || s == StatusLocal
+}
+
+// Target is a host with its connection settings.
+type Target struct {
+	Name     string
+	Address  string
+	Port     int
+	User     string
+	KeyFile  string // Private key file, the default keys of the user are tried when empty
+	Password string
+	Local    bool
+}
+
+// Addr returns the address to dial.
+func (t Target) Addr() string {
+	return net.JoinHostPort(t.Address, strconv.Itoa(t.Port))
+}
+
+// Result is the check of a target.
+type Result struct {
+	Target
+	Status   Status
+	Detail   string
+	Duration time.Duration
+}
+
+// Results are the checks of several targets.
+type Results []Result
+
+// Failed returns the number of the targets Ansible can't run tasks on.
+func (r Results) Failed() int {
+	n := 0
+	for _, result := range r {
+		if !result.Status.OK() {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Dialer opens the connections to the targets, net.Dialer is used by default.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Checker checks targets.
+type Checker struct {
+	Dialer         Dialer
+	KnownHostsFile string // Host keys are not verified when it is empty or doesn't exist
+	AgentSocket    string // SSH agent to take the keys from, the agent is not used when it is empty
+	Timeout        time.Duration
+}
+
+// New returns a Checker with the known hosts and the SSH agent of the current user.
+func New() *Checker {
+	c := &Checker{
+		Dialer:      &net.Dialer{},
+		AgentSocket: os.Getenv("SSH_AUTH_SOCK"),
+		Timeout:     DefaultTimeout,
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		c.KnownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	return c
+}
+
+// Targets returns the hosts of the inventory with the connection settings Ansible would use, see
+// config.Config.HostConnection. limit is a comma separated list of host names,
+// all hosts are returned when it is empty.
+func Targets(cfg config.Config, limit string) ([]Target, error) {
+	cfg.Variables, cfg.Inventory = config.Variables{}, config.Inventory{}
+	for _, configFileType := range []int{config.VarsConfig, config.InventoryConfig} {
+		// Secrets that fail to decrypt stay encrypted and are not used.
+		if err := cfg.ReadToStruct(configFileType); err != nil && !errors.Is(err, vault.ErrWrongPassword) {
+			return nil, err
+		}
+	}
+
+	// The order of the file, the decoded inventory is a map.
+	hosts, err := cfg.InventoryHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(limit, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			selected[name] = true
+		}
+	}
+
+	inventory := cfg.Inventory.Hosts()
+	targets := make([]Target, 0, len(hosts))
+	for _, h := range hosts {
+		if len(selected) > 0 && !selected[h.Name] {
+			continue
+		}
+
+		conn := cfg.HostConnection(inventory[h.Name])
+		port, _ := strconv.Atoi(conn.Port.Value)
+		t := Target{
+			Name:     h.Name,
+			Address:  firstOf(inventory[h.Name].AnsibleHost, h.Name),
+			Port:     port,
+			User:     conn.User.Value,
+			KeyFile:  conn.KeyFile.Value,
+			Password: conn.Password.Value,
+			Local:    conn.Local,
+		}
+
+		if t.User == "" {
+			if u, err := user.Current(); err == nil {
+				t.User = u.Username
+			}
+		}
+		if vault.IsEncrypted(t.Password) {
+			t.Password = ""
+		}
+
+		targets = append(targets, t)
+	}
+
+	return targets, nil
+}
+
+// Check checks the targets concurrently, the results are in the order of targets.
+func (c *Checker) Check(ctx context.Context, targets []Target) Results {
+	results := make(Results, len(targets))
+	wg := sync.WaitGroup{}
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			results[i] = c.CheckTarget(ctx, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CheckTarget connects to the target and authenticates, no command is run.
+func (c *Checker) CheckTarget(ctx context.Context, t Target) Result {
+	start := time.Now()
+	result := Result{Target: t}
+	if t.Local {
+		result.Status, result.Detail = StatusLocal, "ansible_connection: local"
+		return result
+	}
+
+	var agentSigners []ssh.Signer
+	if c.AgentSocket != "" {
+		// The agent signs during the handshake, the connection stays open until the check is over.
+		if conn, err := net.Dial("unix", c.AgentSocket); err == nil {
+			defer conn.Close()
+			agentSigners, _ = agent.NewClient(conn).Signers()
+		}
+	}
+
+	auth, notes := authMethods(t, agentSigners)
+	keys := hostKeys{}
+	hostKey, err := c.hostKeyCallback(&keys)
+	if err != nil {
+		result.Status, result.Detail = StatusUnreachable, err.Error()
+		return result
+	}
+
+	err = c.connect(ctx, t, &ssh.ClientConfig{
+		User:            t.User,
+		Auth:            auth,
+		HostKeyCallback: hostKey,
+	})
+	result.Duration = time.Since(start)
+	result.Status, result.Detail = classify(err)
+	switch {
+	case keys.mismatch:
+		result.Status = StatusHostKeyMismatch
+		result.Detail = "host key differs from known_hosts, the host was reinstalled or the connection is intercepted"
+	case result.Status == StatusReachable && keys.unknown:
+		result.Detail = "host key is not in known_hosts"
+	}
+	if result.Status == StatusAuthFailed && len(notes) > 0 {
+		result.Detail = fmt.Sprintf("%s (%s)", result.Detail, strings.Join(notes, ", "))
+	}
+
+	return result
+}
+
+// connect opens the SSH connection to the target and closes it once authenticated.
+func (c *Checker) connect(ctx context.Context, t Target, clientConfig *ssh.ClientConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout())
+	defer cancel()
+
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", t.Addr())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The handshake has no context, the connection is closed to interrupt it.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.Addr(), clientConfig)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	return ssh.NewClient(sshConn, chans, reqs).Close()
+}
+
+// authMethods returns the ways to authenticate as the target user in the order ssh tries them,
+// with notes on the keys that can't be used.
+func authMethods(t Target, agentSigners []ssh.Signer) ([]ssh.AuthMethod, []string) {
+	notes := make([]string, 0)
+	signers := make([]ssh.Signer, 0)
+
+	keyFiles := []string{t.KeyFile}
+	if t.KeyFile == "" {
+		keyFiles = defaultKeyFiles()
+	}
+
+	for _, path := range keyFiles {
+		signer, err := readKey(path)
+		switch {
+		case err == nil:
+			signers = append(signers, signer)
+		case t.KeyFile != "":
+			// A key missing from the default locations is expected, a configured one is not.
+			notes = append(notes, err.Error())
+		}
+	}
+
+	signers = append(signers, agentSigners...)
+
+	auth := make([]ssh.AuthMethod, 0, 2)
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	if t.Password != "" {
+		auth = append(auth, ssh.Password(t.Password))
+	}
+	if len(auth) == 0 {
+		notes = append(notes, "no key or password to authenticate with")
+	}
+
+	return auth, notes
+}
+
+// hostKeys is what the host key callback found out during the handshake.
+type hostKeys struct {
+	unknown  bool // The host is missing from the known hosts file
+	mismatch bool // The host key differs from the one in the known hosts file
+}
+
+// hostKeyCallback verifies host keys against the known hosts file. A host missing from the file is accepted
+// as ssh with StrictHostKeyChecking=no does. The handshake error doesn't keep the cause, so it is recorded in keys.
+func (c *Checker) hostKeyCallback(keys *hostKeys) (ssh.HostKeyCallback, error) {
+	if c.KnownHostsFile == "" {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	if _, err := os.Stat(c.KnownHostsFile); os.IsNotExist(err) {
+		keys.unknown = true
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	known, err := knownhosts.New(c.KnownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := known(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				keys.unknown = true
+				return nil
+			}
+
+			keys.mismatch = true
+		}
+
+		return err
+	}, nil
+}
+
+func (c *Checker) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+
+	return c.Timeout
+}
+
+// classify maps a connection error to the status of the host.
+func classify(err error) (Status, string) {
+	if err == nil {
+		return StatusReachable, ""
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return StatusTimeout, "no answer in time"
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return StatusAuthFailed, "the key and the password were rejected"
+	}
+
+	return StatusUnreachable, err.Error()
+}
+
+// readKey reads an unencrypted private key.
+func readKey(path string) (ssh.Signer, error) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.ParsePrivateKey(content)
+	var passphraseErr *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseErr) {
+		return nil, fmt.Errorf("%s is protected by a passphrase, add it to ssh-agent", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return signer, nil
+}
+
+// defaultKeyFiles returns the keys ssh tries when no identity file is given.
+func defaultKeyFiles() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	files := make([]string, 0, 3)
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		files = append(files, filepath.Join(home, ".ssh", name))
+	}
+
+	return files
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
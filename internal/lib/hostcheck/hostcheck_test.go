@@ -0,0 +1,230 @@
+package hostcheck
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const password = "pAssw0rd"
+
+// startServer starts an SSH server on 127.0.0.1 that accepts the password and returns its address and host key.
+func startServer(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, p []byte) (*ssh.Permissions, error) {
+			if conn.User() == "webitel" && string(p) == password {
+				return nil, nil
+			}
+
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	signer := newSigner(t)
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					_ = ch.Reject(ssh.Prohibited, "no sessions")
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String(), signer.PublicKey()
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+// redirectDialer dials addr whatever address is asked for, so targets keep their inventory names.
+type redirectDialer struct {
+	addr  string
+	dials []string
+}
+
+func (d *redirectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials = append(d.dials, address)
+	return (&net.Dialer{}).DialContext(ctx, network, d.addr)
+}
+
+func newChecker(t *testing.T, dialer Dialer) *Checker {
+	t.Helper()
+	// The default keys of the user running the tests must not be tried.
+	t.Setenv("HOME", t.TempDir())
+
+	return &Checker{Dialer: dialer, Timeout: 5 * time.Second}
+}
+
+func target(password string) Target {
+	return Target{Name: "node1", Address: "node1.example.com", Port: 22, User: "webitel", Password: password}
+}
+
+func writeKnownHosts(t *testing.T, key ssh.PublicKey) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{target("").Addr()}, key)
+	if err := os.WriteFile(path, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestCheckTargetReachable(t *testing.T) {
+	addr, hostKey := startServer(t)
+	dialer := &redirectDialer{addr: addr}
+	c := newChecker(t, dialer)
+	c.KnownHostsFile = writeKnownHosts(t, hostKey)
+
+	result := c.CheckTarget(context.Background(), target(password))
+	if result.Status != StatusReachable || !result.Status.OK() {
+		t.Fatalf("Status = %q (%s), want %q", result.Status, result.Detail, StatusReachable)
+	}
+	if result.Detail != "" {
+		t.Errorf("Detail = %q, want none for a known host", result.Detail)
+	}
+	if len(dialer.dials) != 1 || dialer.dials[0] != "node1.example.com:22" {
+		t.Errorf("dialed %v, want node1.example.com:22", dialer.dials)
+	}
+}
+
+func TestCheckTargetUnknownHostKey(t *testing.T) {
+	addr, _ := startServer(t)
+	c := newChecker(t, &redirectDialer{addr: addr})
+	c.KnownHostsFile = filepath.Join(t.TempDir(), "known_hosts")
+
+	result := c.CheckTarget(context.Background(), target(password))
+	if result.Status != StatusReachable || result.Detail != "host key is not in known_hosts" {
+		t.Errorf("result = %q (%s), want reachable with an unknown host key", result.Status, result.Detail)
+	}
+}
+
+func TestCheckTargetAuthFailed(t *testing.T) {
+	addr, _ := startServer(t)
+	c := newChecker(t, &redirectDialer{addr: addr})
+
+	result := c.CheckTarget(context.Background(), target("wrong"))
+	if result.Status != StatusAuthFailed || result.Status.OK() {
+		t.Errorf("Status = %q (%s), want %q", result.Status, result.Detail, StatusAuthFailed)
+	}
+
+	// Without a key or a password there is nothing to try.
+	result = c.CheckTarget(context.Background(), target(""))
+	if result.Status != StatusAuthFailed || !strings.Contains(result.Detail, "no key or password") {
+		t.Errorf("result = %q (%s), want %q with a note", result.Status, result.Detail, StatusAuthFailed)
+	}
+}
+
+func TestCheckTargetTimeout(t *testing.T) {
+	// The listener accepts connections and never answers.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	}()
+
+	c := newChecker(t, &redirectDialer{addr: l.Addr().String()})
+	c.Timeout = 300 * time.Millisecond
+
+	start := time.Now()
+	result := c.CheckTarget(context.Background(), target(password))
+	if result.Status != StatusTimeout {
+		t.Errorf("Status = %q (%s), want %q", result.Status, result.Detail, StatusTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("the check took %s, want about the timeout", elapsed)
+	}
+}
+
+func TestCheckTargetHostKeyMismatch(t *testing.T) {
+	addr, _ := startServer(t)
+	c := newChecker(t, &redirectDialer{addr: addr})
+	c.KnownHostsFile = writeKnownHosts(t, newSigner(t).PublicKey())
+
+	result := c.CheckTarget(context.Background(), target(password))
+	if result.Status != StatusHostKeyMismatch {
+		t.Errorf("Status = %q (%s), want %q", result.Status, result.Detail, StatusHostKeyMismatch)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	addr, _ := startServer(t)
+	c := newChecker(t, &net.Dialer{})
+
+	host, port, _ := net.SplitHostPort(addr)
+	p, _ := strconv.Atoi(port)
+	up := Target{Name: "node1", Address: host, Port: p, User: "webitel", Password: password}
+	wrong := up
+	wrong.Name, wrong.Password = "node2", "wrong"
+	local := Target{Name: "node3", Local: true}
+
+	results := c.Check(context.Background(), []Target{up, wrong, local})
+	want := []Status{StatusReachable, StatusAuthFailed, StatusLocal}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s: Status = %q (%s), want %q", r.Name, r.Status, r.Detail, want[i])
+		}
+	}
+	if results.Failed() != 1 {
+		t.Errorf("Failed() = %d, want 1", results.Failed())
+	}
+}
@@ -0,0 +1,193 @@
+package hostimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"io"
+	"strings"
+)
+
+// Columns of a CSV host list.
+const (
+	columnName = iota
+	columnAddress
+	columnUser
+	columnPort
+	columnKey
+	columnPassword
+	columnServices
+	columnConnection
+)
+
+// columnAliases are the headers a spreadsheet may use for the columns, compared case-insensitively with spaces
+// and dashes read as underscores.
+var columnAliases = map[string]int{
+	"name":                         columnName,
+	"hostname":                     columnName,
+	"inventory_hostname":           columnName,
+	"node":                         columnName,
+	"address":                      columnAddress,
+	"ip":                           columnAddress,
+	"ip_address":                   columnAddress,
+	"ansible_host":                 columnAddress,
+	"user":                         columnUser,
+	"username":                     columnUser,
+	"login":                        columnUser,
+	"ansible_user":                 columnUser,
+	"port":                         columnPort,
+	"ssh_port":                     columnPort,
+	"ansible_port":                 columnPort,
+	"key":                          columnKey,
+	"private_key":                  columnKey,
+	"identity_file":                columnKey,
+	"key_file":                     columnKey,
+	"ansible_ssh_private_key_file": columnKey,
+	"password":                     columnPassword,
+	"ansible_ssh_pass":             columnPassword,
+	"ansible_password":             columnPassword,
+	"services":                     columnServices,
+	"webitel_services":             columnServices,
+	"roles":                        columnServices,
+	"connection":                   columnConnection,
+	"ansible_connection":           columnConnection,
+}
+
+// ParseCSV reads a host list with a header row, the columns are separated by commas, semicolons or tabs.
+// A "host" column is the name of the host, or its address when the list has a name column too.
+func ParseCSV(r io.Reader) (Result, error) {
+	result := Result{}
+	br := bufio.NewReader(r)
+	header, err := br.Peek(br.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return result, err
+	}
+	if len(strings.TrimSpace(string(header))) == 0 {
+		return result, errors.New("csv: the file is empty")
+	}
+
+	reader := csv.NewReader(br)
+	reader.Comma = delimiter(string(header))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return result, fmt.Errorf("csv: %w", err)
+	}
+
+	columns, err := headerColumns(records[0], &result)
+	if err != nil {
+		return result, err
+	}
+
+	for n, record := range records[1:] {
+		row := make(map[int]string)
+		for i, value := range record {
+			if c, ok := columns[i]; ok && strings.TrimSpace(value) != "" {
+				row[c] = strings.TrimSpace(value)
+			}
+		}
+
+		name := firstOf(row[columnName], row[columnAddress])
+		if name == "" {
+			// Spreadsheets often end with empty rows.
+			if len(row) > 0 {
+				result.warnf("row %d: no name or address, skipped", n+2)
+			}
+
+			continue
+		}
+
+		h := config.InventoryHost{Name: name}
+		h.AnsibleHost = row[columnAddress]
+		h.AnsibleUser = row[columnUser]
+		h.AnsibleSSHPrivateKeyFile = row[columnKey]
+		h.AnsibleSSHPass = row[columnPassword]
+		h.AnsibleConnection = row[columnConnection]
+		if row[columnPort] != "" {
+			if h.AnsiblePort, err = parsePort(row[columnPort]); err != nil {
+				result.warnf("row %d: %s", n+2, err)
+			}
+		}
+
+		for _, s := range splitServices(row[columnServices], reader.Comma) {
+			switch {
+			case !contains(vars.WebitelServices, s):
+				result.warnf("row %d: service %s is unknown, skipped", n+2, s)
+			case !contains(h.WebitelServices, s):
+				h.WebitelServices = append(h.WebitelServices, s)
+			}
+		}
+
+		result.add(h)
+	}
+
+	return result, nil
+}
+
+// headerColumns maps the indexes of the header fields onto the columns.
+func headerColumns(header []string, result *Result) (map[int]int, error) {
+	hasName := false
+	for _, field := range header {
+		if c, ok := columnAliases[headerAlias(field)]; ok && c == columnName {
+			hasName = true
+		}
+	}
+
+	columns := make(map[int]int)
+	for i, field := range header {
+		alias := headerAlias(field)
+		c, ok := columnAliases[alias]
+		switch {
+		case alias == "host" && hasName:
+			c, ok = columnAddress, true
+		case alias == "host":
+			c, ok = columnName, true
+		}
+
+		if !ok {
+			result.warnf("column %s is not imported", field)
+			continue
+		}
+
+		columns[i] = c
+	}
+
+	for _, c := range columns {
+		if c == columnName || c == columnAddress {
+			return columns, nil
+		}
+	}
+
+	return nil, fmt.Errorf("csv: the header has no name or address column: %s", strings.Join(header, ", "))
+}
+
+func headerAlias(field string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(field)))
+}
+
+// delimiter returns the separator used in the first line of the content.
+func delimiter(content string) rune {
+	line, _, _ := strings.Cut(content, "\n")
+	best, count := ',', 0
+	for _, d := range []rune{',', ';', '\t'} {
+		if n := strings.Count(line, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+
+	return best
+}
+
+// splitServices splits a list of services separated by semicolons, pipes, spaces or commas, unless the
+// comma separates the columns.
+func splitServices(value string, comma rune) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ';' || r == '|' || r == ' ' || r == '\t' || r == ',' && comma != ','
+	})
+}
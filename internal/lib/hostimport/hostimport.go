@@ -0,0 +1,200 @@
+// Package hostimport reads hosts from the places teams keep them before wdeploy: Ansible INI inventories, CSV host
+// lists exported from spreadsheets and the Host blocks of ssh_config. The hosts are mapped onto the inventory hosts
+// of wdeploy, the services are assigned afterwards.
+package hostimport
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Formats of the sources.
+const (
+	FormatINI       = "ini"
+	FormatCSV       = "csv"
+	FormatSSHConfig = "ssh-config"
+)
+
+// Formats are the formats Parse accepts.
+var Formats = []string{FormatINI, FormatCSV, FormatSSHConfig}
+
+// Result is the hosts read from a source with what could not be imported. Groups are the groups of an INI
+// inventory that are not Webitel services, with their hosts, variables and the imported groups around them.
+type Result struct {
+	Hosts    []config.InventoryHost
+	Groups   []config.InventoryGroup
+	Warnings []string
+}
+
+func (r *Result) warnf(format string, a ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, a...))
+}
+
+// Parse reads the hosts of the source in the format, in the order of the source.
+func Parse(format string, r io.Reader) (Result, error) {
+	switch format {
+	case FormatINI:
+		return ParseINI(r)
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatSSHConfig:
+		return ParseSSHConfig(r)
+	}
+
+	return Result{}, fmt.Errorf("import format %q is unknown, expected one of: %s", format, strings.Join(Formats, ", "))
+}
+
+var sshConfigLineRegexp = regexp.MustCompile(`(?i)^\s*(host|match|hostname|user|port|identityfile|include)(\s|=)`)
+
+// Detect guesses the format of the source from the name of the file and its first line.
+func Detect(path string, content []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+		return FormatCSV, nil
+	case ".ini", ".cfg":
+		return FormatINI, nil
+	}
+
+	base := filepath.Base(path)
+	if base == "ssh_config" || base == "config" && filepath.Base(filepath.Dir(path)) == ".ssh" {
+		return FormatSSHConfig, nil
+	}
+
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		l := strings.TrimSpace(string(line))
+		switch {
+		case l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, ";"):
+			continue
+		case strings.HasPrefix(l, "["):
+			return FormatINI, nil
+		case sshConfigLineRegexp.MatchString(l):
+			return FormatSSHConfig, nil
+		case strings.ContainsAny(l, ",;\t") && !strings.Contains(l, "="):
+			return FormatCSV, nil
+		}
+
+		// A list of host names is an INI inventory without groups.
+		return FormatINI, nil
+	}
+
+	return "", fmt.Errorf("%s: can't tell the format of an empty file", path)
+}
+
+// Merge adds the imported hosts to the hosts of the inventory. A host with the name of an imported one gets
+// its connection settings and the services it doesn't have yet. It returns the names of the
+// added and the updated hosts. An added host without an address is reached by its name, as Ansible and ssh do.
+func Merge(hosts, imported []config.InventoryHost) ([]config.InventoryHost, []string, []string) {
+	merged := append([]config.InventoryHost(nil), hosts...)
+	added, updated := make([]string, 0), make([]string, 0)
+	for _, h := range imported {
+		i := indexOf(merged, h.Name)
+		if i < 0 {
+			h.AnsibleHost = firstOf(h.AnsibleHost, h.Name)
+			merged = append(merged, config.InventoryHost{Name: h.Name, Host: h.Host})
+			added = append(added, h.Name)
+
+			continue
+		}
+
+		existing := &merged[i].Host
+		existing.AnsibleHost = firstOf(h.AnsibleHost, existing.AnsibleHost)
+		existing.AnsibleConnection = firstOf(h.AnsibleConnection, existing.AnsibleConnection)
+		existing.AnsibleUser = firstOf(h.AnsibleUser, existing.AnsibleUser)
+		existing.AnsibleSSHPrivateKeyFile = firstOf(h.AnsibleSSHPrivateKeyFile, existing.AnsibleSSHPrivateKeyFile)
+		existing.AnsibleSSHPass = firstOf(h.AnsibleSSHPass, existing.AnsibleSSHPass)
+		if h.AnsiblePort != 0 {
+			existing.AnsiblePort = h.AnsiblePort
+		}
+		for _, s := range h.WebitelServices {
+			if !contains(existing.WebitelServices, s) {
+				existing.WebitelServices = append(existing.WebitelServices, s)
+			}
+		}
+		updated = append(updated, h.Name)
+	}
+
+	return merged, added, updated
+}
+
+// add appends the host to the result, a host listed again is merged into the first one.
+func (r *Result) add(h config.InventoryHost) {
+	if i := indexOf(r.Hosts, h.Name); i >= 0 {
+		hosts, _, _ := Merge(r.Hosts, []config.InventoryHost{h})
+		r.Hosts = hosts
+		return
+	}
+
+	r.Hosts = append(r.Hosts, h)
+}
+
+// setVar maps an Ansible connection variable onto the host, it reports whether the variable is known.
+// The variables renamed in Ansible 2.0 are accepted too.
+func setVar(h *config.Host, key, value string) (bool, error) {
+	switch key {
+	case "ansible_host", "ansible_ssh_host":
+		h.AnsibleHost = value
+	case "ansible_user", "ansible_ssh_user":
+		h.AnsibleUser = value
+	case "ansible_port", "ansible_ssh_port":
+		port, err := parsePort(value)
+		if err != nil {
+			return true, err
+		}
+		h.AnsiblePort = port
+	case "ansible_ssh_private_key_file", "ansible_private_key_file":
+		h.AnsibleSSHPrivateKeyFile = value
+	case "ansible_ssh_pass", "ansible_password":
+		h.AnsibleSSHPass = value
+	case "ansible_connection":
+		h.AnsibleConnection = value
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port: expected a number between 1 and 65535, got %q", value)
+	}
+
+	return port, nil
+}
+
+func indexOf(hosts []config.InventoryHost, name string) int {
+	for i, h := range hosts {
+		if h.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
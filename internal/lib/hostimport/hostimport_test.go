@@ -0,0 +1,383 @@
+package hostimport
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// parseFile parses the file in testdata in the format.
+func parseFile(t *testing.T, format, name string) Result {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	result, err := Parse(format, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+// hostLines formats the hosts as "name key=value ...", leaving out the values that are not set.
+func hostLines(hosts []config.InventoryHost) []string {
+	lines := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		fields := []string{h.Name}
+		for _, kv := range [][2]string{
+			{"host", h.AnsibleHost},
+			{"connection", h.AnsibleConnection},
+			{"user", h.AnsibleUser},
+			{"key", h.AnsibleSSHPrivateKeyFile},
+			{"pass", h.AnsibleSSHPass},
+			{"services", strings.Join(h.WebitelServices, ",")},
+		} {
+			if kv[1] != "" {
+				fields = append(fields, kv[0]+"="+kv[1])
+			}
+		}
+		if h.AnsiblePort != 0 {
+			fields = append(fields, fmt.Sprintf("port=%d", h.AnsiblePort))
+		}
+
+		lines = append(lines, strings.Join(fields, " "))
+	}
+
+	return lines
+}
+
+// groupLines formats the groups as "name parents=... children=... hosts=... vars=key:value,...".
+func groupLines(groups []config.InventoryGroup) []string {
+	lines := make([]string, 0, len(groups))
+	for _, g := range groups {
+		variables := make([]string, 0, len(g.Vars))
+		for k, v := range g.Vars {
+			variables = append(variables, fmt.Sprintf("%s:%v", k, v))
+		}
+		sort.Strings(variables)
+
+		lines = append(lines, fmt.Sprintf("%s parents=%s children=%s hosts=%s vars=%s", g.Name,
+			strings.Join(g.Parents, ","), strings.Join(g.Children, ","), strings.Join(g.Hosts, ","),
+			strings.Join(variables, ",")))
+	}
+
+	return lines
+}
+
+func compare(t *testing.T, what string, got, want []string) {
+	t.Helper()
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s:\n%s\nwant:\n%s", what, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	result := parseFile(t, FormatINI, "hosts.ini")
+
+	compare(t, "hosts", hostLines(result.Hosts), []string{
+		// Variables of the host line win over the groups, all comes last.
+		"db.example.com user=postgres",
+		// web keeps ansible_user=www for the hosts that don't set it, nginx is a service and places it.
+		"web01 user=deploy key=/keys/nginx.pem services=nginx port=2200",
+		"web02 user=deploy key=/keys/nginx.pem services=nginx port=2200",
+		"web03 user=deploy key=/keys/nginx.pem services=nginx port=2200",
+		"10.0.0.10 key=/keys/nginx.pem services=nginx port=2222",
+		"2001:db8::1 key=/keys/nginx.pem services=nginx port=2200",
+		"db-a.example.com user=root services=postgresql",
+		"db-b.example.com user=root services=postgresql",
+		"db-c.example.com user=root services=postgresql",
+		"node1 user=root services=postgresql",
+		"node3 user=root services=postgresql",
+		"node5 user=root services=postgresql",
+	})
+
+	compare(t, "groups", groupLines(result.Groups), []string{
+		"web parents=webitel children= hosts=web01,web02,web03,10.0.0.10,2001:db8::1 vars=ansible_user:www,http_port:8080",
+		"db parents=webitel children= hosts=db-a.example.com,db-b.example.com,db-c.example.com,node1,node3,node5 vars=",
+		// nginx is a service, its child web becomes a child of webitel.
+		"webitel parents= children=web,db hosts= vars=",
+	})
+
+	compare(t, "warnings", result.Warnings, []string{
+		"db.example.com: variable ansible_python_interpreter is not imported",
+		`group webitel: port: expected a number between 1 and 65535, got "bad"`,
+	})
+}
+
+func TestParseINIHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "host and port",
+			content: "node1:2222\nnode2.example.com:22 ansible_host=10.0.0.2",
+			want:    []string{"node1 port=2222", "node2.example.com host=10.0.0.2 port=22"},
+		},
+		{
+			name:    "IPv6",
+			content: "2001:db8::1\n::1 ansible_port=2200",
+			want:    []string{"2001:db8::1", "::1 port=2200"},
+		},
+		{
+			name:    "numeric range",
+			content: "web[01:03]",
+			want:    []string{"web01", "web02", "web03"},
+		},
+		{
+			name:    "letter range",
+			content: "db-[a:c].example.com",
+			want:    []string{"db-a.example.com", "db-b.example.com", "db-c.example.com"},
+		},
+		{
+			name:    "range with step",
+			content: "node[0:10:5]",
+			want:    []string{"node0", "node5", "node10"},
+		},
+		{
+			name:    "two ranges",
+			content: "dc[1:2]-node[a:b]:2222",
+			want:    []string{"dc1-nodea port=2222", "dc1-nodeb port=2222", "dc2-nodea port=2222", "dc2-nodeb port=2222"},
+		},
+		{
+			name:    "quoted values and comments",
+			content: "# nodes\nnode1 ansible_user='web deploy' ansible_ssh_pass=\"p#ss\" # the first one\n; node2",
+			want:    []string{"node1 user=web deploy pass=p#ss"},
+		},
+		{
+			name:    "old variable names",
+			content: "node1 ansible_ssh_host=10.0.0.1 ansible_ssh_user=root ansible_ssh_port=2222",
+			want:    []string{"node1 host=10.0.0.1 user=root port=2222"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseINI(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compare(t, "hosts", hostLines(result.Hosts), tt.want)
+		})
+	}
+}
+
+func TestParseINIGroups(t *testing.T) {
+	content := `
+[europe:children]
+germany
+france
+
+[germany]
+de1
+
+[france]
+fr[1:2]
+
+[france:vars]
+ansible_user=paris
+
+[europe:vars]
+ansible_user=eu
+ansible_port=2222
+
+[all:vars]
+ansible_user=root
+ansible_port=22
+`
+
+	result, err := ParseINI(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The variables set in the groups stay there, all only fills in the rest.
+	compare(t, "hosts", hostLines(result.Hosts), []string{"de1", "fr1", "fr2"})
+	compare(t, "groups", groupLines(result.Groups), []string{
+		"europe parents= children=germany,france hosts= vars=ansible_port:2222,ansible_user:eu",
+		"germany parents=europe children= hosts=de1 vars=",
+		"france parents=europe children= hosts=fr1,fr2 vars=ansible_user:paris",
+	})
+	compare(t, "warnings", result.Warnings, nil)
+}
+
+func TestParseINIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "section kind",
+			content: "[web:hosts]\nnode1",
+			want:    `line 1: section kind "hosts" is unknown, expected vars or children`,
+		},
+		{
+			name:    "group variable",
+			content: "[web:vars]\nansible_user",
+			want:    "line 2: expected key=value in [web:vars]",
+		},
+		{
+			name:    "host variable",
+			content: "\n\nnode1 ansible_user",
+			want:    `line 3: expected key=value after the host, got "ansible_user"`,
+		},
+		{
+			name:    "range step",
+			content: "node[1:3:0]",
+			want:    "line 1: host range [1:3:0]: step must be positive",
+		},
+		{
+			name:    "range of letters and numbers",
+			content: "node[1:c]",
+			want:    "line 1: host range [1:c]: mixes letters and numbers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseINI(strings.NewReader(tt.content))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseINI() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	result := parseFile(t, FormatCSV, "hosts.csv")
+
+	compare(t, "hosts", hostLines(result.Hosts), []string{
+		// The second row of node1 adds its port and grafana.
+		"node1 host=10.0.0.1 user=root services=postgresql,rabbitmq,consul,grafana port=2222",
+		"node2 host=10.0.0.2 user=admin services=nginx,freeswitch",
+		"10.0.0.3 host=10.0.0.3 services=webitel_core",
+	})
+	compare(t, "warnings", result.Warnings, []string{
+		"column Location is not imported",
+		`row 3: port: expected a number between 1 and 65535, got "70000"`,
+	})
+}
+
+func TestParseCSVHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		want     []string
+		warnings []string
+		err      string
+	}{
+		{
+			name:    "host is the name",
+			content: "host,user,services\nnode1,root,nginx;opensips",
+			want:    []string{"node1 user=root services=nginx,opensips"},
+		},
+		{
+			name:    "host is the address next to a name",
+			content: "Name,Host,ansible_port\nnode1,10.0.0.1,2222",
+			want:    []string{"node1 host=10.0.0.1 port=2222"},
+		},
+		{
+			name:    "tabs and ansible names",
+			content: "inventory_hostname\tansible_host\tansible_ssh_private_key_file\tansible_connection\nnode1\t10.0.0.1\t~/.ssh/id\tlocal",
+			want:    []string{"node1 host=10.0.0.1 connection=local key=~/.ssh/id"},
+		},
+		{
+			name:     "unknown service",
+			content:  "Node,IP,Webitel services\nnode1,10.0.0.1,nginx postgres",
+			want:     []string{"node1 host=10.0.0.1 services=nginx"},
+			warnings: []string{"row 2: service postgres is unknown, skipped"},
+		},
+		{
+			name:     "row without a name",
+			content:  "name,ip,user\n,,root",
+			want:     []string{},
+			warnings: []string{"row 2: no name or address, skipped"},
+		},
+		{
+			name:    "no name column",
+			content: "user,port\nroot,22",
+			err:     "csv: the header has no name or address column: user, port",
+		},
+		{
+			name:    "empty",
+			content: "\n",
+			err:     "csv: the file is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseCSV(strings.NewReader(tt.content))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("ParseCSV() error = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compare(t, "hosts", hostLines(result.Hosts), tt.want)
+			compare(t, "warnings", result.Warnings, tt.warnings)
+		})
+	}
+}
+
+func TestParseSSHConfig(t *testing.T) {
+	result := parseFile(t, FormatSSHConfig, "ssh_config")
+
+	compare(t, "hosts", hostLines(result.Hosts), []string{
+		// The options before the first Host block apply to every host and win, as in ssh.
+		"bastion host=203.0.113.5 user=admin port=2222",
+		"web1 host=web1.example.com user=admin key=~/.ssh/web.pem",
+		"web2 host=web2.example.com user=admin key=~/.ssh/web.pem port=22",
+		// The Match block is skipped, db1 keeps the defaults.
+		"db1 host=10.0.0.20 user=admin",
+	})
+	compare(t, "warnings", result.Warnings, []string{
+		"line 16: Match blocks are not imported",
+		"line 25: Include conf.d/* is not followed, import the included file separately",
+		`web1: port: expected a number between 1 and 65535, got "abc"`,
+		`db1: port: expected a number between 1 and 65535, got "abc"`,
+	})
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    string
+	}{
+		{"hosts.csv", "", FormatCSV},
+		{"hosts.ini", "", FormatINI},
+		{"/home/webitel/.ssh/config", "", FormatSSHConfig},
+		{"hosts", "# nodes\n[web]\nnode1", FormatINI},
+		{"hosts", "Host node1\n  HostName 10.0.0.1", FormatSSHConfig},
+		{"hosts", "name;ip\nnode1;10.0.0.1", FormatCSV},
+		{"hosts", "node1 ansible_host=10.0.0.1", FormatINI},
+	}
+
+	for _, tt := range tests {
+		got, err := Detect(tt.path, []byte(tt.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
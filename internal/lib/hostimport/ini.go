@@ -0,0 +1,370 @@
+package hostimport
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// iniGroup is a section of an INI inventory with its hosts, variables and child groups.
+type iniGroup struct {
+	hosts    []string
+	vars     [][2]string
+	children []string
+}
+
+var hostRangeRegexp = regexp.MustCompile(`\[([0-9]+|[a-z]):([0-9]+|[a-z])(?::([0-9]+))?]`)
+
+// ParseINI reads an Ansible INI inventory. A group named after a Webitel service places the service on its hosts,
+// the other groups are imported as groups with their hosts, variables and children. Variables of the hosts are
+// taken from the host lines, then from the [group:vars] sections of their service groups and parents, then from
+// [all:vars], the variables of the imported groups stay in the groups.
+func ParseINI(r io.Reader) (Result, error) {
+	result := Result{}
+	groups := map[string]*iniGroup{"all": {}, "ungrouped": {}}
+	order := []string{"all", "ungrouped"}
+	hostVars := make(map[string][][2]string)
+	hostNames := make([]string, 0)
+
+	group, kind := "ungrouped", ""
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group, kind, _ = strings.Cut(line[1:len(line)-1], ":")
+			if kind != "" && kind != "vars" && kind != "children" {
+				return result, fmt.Errorf("line %d: section kind %q is unknown, expected vars or children", n, kind)
+			}
+			if groups[group] == nil {
+				groups[group] = &iniGroup{}
+				order = append(order, group)
+			}
+
+			continue
+		}
+
+		g := groups[group]
+		switch kind {
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return result, fmt.Errorf("line %d: expected key=value in [%s:vars]", n, group)
+			}
+			g.vars = append(g.vars, [2]string{strings.TrimSpace(key), unquote(strings.TrimSpace(value))})
+		case "children":
+			child := strings.Fields(line)[0]
+			g.children = append(g.children, child)
+			if groups[child] == nil {
+				groups[child] = &iniGroup{}
+				order = append(order, child)
+			}
+		default:
+			fields := splitFields(line)
+			pattern, port := fields[0], ""
+			// host:port is a shorthand of ansible_port, an IPv6 address has more than one colon. The colons
+			// of the host ranges don't count.
+			outside := hostRangeRegexp.ReplaceAllString(pattern, "")
+			if i := strings.LastIndex(pattern, ":"); i > 0 && strings.Count(outside, ":") == 1 && !strings.Contains(pattern[i:], "]") {
+				pattern, port = pattern[:i], pattern[i+1:]
+			}
+
+			names, err := expandRange(pattern)
+			if err != nil {
+				return result, fmt.Errorf("line %d: %w", n, err)
+			}
+
+			lineVars := make([][2]string, 0, len(fields))
+			if port != "" {
+				lineVars = append(lineVars, [2]string{"ansible_port", port})
+			}
+			for _, f := range fields[1:] {
+				key, value, ok := strings.Cut(f, "=")
+				if !ok {
+					return result, fmt.Errorf("line %d: expected key=value after the host, got %q", n, f)
+				}
+				lineVars = append(lineVars, [2]string{key, unquote(value)})
+			}
+
+			for _, name := range names {
+				if _, ok := hostVars[name]; !ok {
+					hostNames = append(hostNames, name)
+				}
+				hostVars[name] = append(hostVars[name], lineVars...)
+				if !contains(g.hosts, name) {
+					g.hosts = append(g.hosts, name)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+
+	imported := func(group string) bool {
+		return group != "all" && group != "ungrouped" && !contains(vars.WebitelServices, group)
+	}
+
+	for _, name := range hostNames {
+		memberOf := make([]string, 0)
+		for _, g := range order {
+			if g != "all" && memberOfGroup(groups, g, name, 0) {
+				memberOf = append(memberOf, g)
+			}
+		}
+
+		h := config.InventoryHost{Name: name}
+		// The first value of a variable wins: the host, its service groups in the order of the file, then all.
+		// The imported groups keep their variables, Ansible prefers them to the variables of all.
+		variables := append([][2]string(nil), hostVars[name]...)
+		inGroups := make(map[string]bool)
+		for _, g := range memberOf {
+			for _, kv := range groups[g].vars {
+				if imported(g) {
+					inGroups[kv[0]] = true
+				} else {
+					variables = append(variables, kv)
+				}
+			}
+		}
+		for _, kv := range groups["all"].vars {
+			if !inGroups[kv[0]] {
+				variables = append(variables, kv)
+			}
+		}
+
+		set := make(map[string]bool)
+		for _, kv := range variables {
+			if set[kv[0]] {
+				continue
+			}
+			set[kv[0]] = true
+
+			known, err := setVar(&h.Host, kv[0], kv[1])
+			switch {
+			case err != nil:
+				result.warnf("%s: %s", name, err)
+			case !known:
+				result.warnf("%s: variable %s is not imported", name, kv[0])
+			}
+		}
+
+		for _, s := range vars.WebitelServices {
+			if contains(memberOf, s) {
+				h.WebitelServices = append(h.WebitelServices, s)
+			}
+		}
+
+		result.add(h)
+	}
+
+	children := make(map[string][]string)
+	hosts := make(map[string][]string)
+	for _, name := range order {
+		if imported(name) {
+			hosts[name], children[name] = groupMembers(groups, name, imported, 0)
+		}
+	}
+
+	for _, name := range order {
+		if !imported(name) {
+			continue
+		}
+
+		g := config.InventoryGroup{Name: name, Children: children[name], Hosts: hosts[name], Vars: make(map[string]any)}
+		for _, parent := range order {
+			if contains(children[parent], name) {
+				g.Parents = append(g.Parents, parent)
+			}
+		}
+
+		for _, kv := range groups[name].vars {
+			value, err := groupVar(kv[0], kv[1])
+			if err != nil {
+				result.warnf("group %s: %s", name, err)
+				continue
+			}
+			g.Vars[kv[0]] = value
+		}
+
+		result.Groups = append(result.Groups, g)
+	}
+
+	return result, nil
+}
+
+// groupMembers returns the hosts and the imported child groups of the group. A child group that is not imported
+// places its service on its hosts instead, its hosts and children become members of the group.
+func groupMembers(groups map[string]*iniGroup, group string, imported func(string) bool, depth int) ([]string, []string) {
+	g := groups[group]
+	if g == nil || depth > len(groups) {
+		return nil, nil
+	}
+
+	hosts, children := append([]string(nil), g.hosts...), make([]string, 0)
+	for _, child := range g.children {
+		if imported(child) {
+			if !contains(children, child) {
+				children = append(children, child)
+			}
+			continue
+		}
+
+		h, c := groupMembers(groups, child, imported, depth+1)
+		for _, name := range h {
+			if !contains(hosts, name) {
+				hosts = append(hosts, name)
+			}
+		}
+		for _, name := range c {
+			if !contains(children, name) {
+				children = append(children, name)
+			}
+		}
+	}
+
+	return hosts, children
+}
+
+// groupVar returns the value of a [group:vars] variable, Ansible reads them as strings. The port is a number
+// as in the variables of the hosts.
+func groupVar(key, value string) (any, error) {
+	switch key {
+	case "ansible_port", "ansible_ssh_port":
+		return parsePort(value)
+	}
+
+	return value, nil
+}
+
+// memberOfGroup reports whether the host is in the group or one of its children.
+func memberOfGroup(groups map[string]*iniGroup, group, host string, depth int) bool {
+	g := groups[group]
+	// Ansible refuses cyclic groups, the depth only stops the walk.
+	if g == nil || depth > len(groups) {
+		return false
+	}
+
+	if contains(g.hosts, host) {
+		return true
+	}
+
+	for _, child := range g.children {
+		if memberOfGroup(groups, child, host, depth+1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandRange expands a host pattern with a range, e.g. web[01:03] or db-[a:c], into the host names.
+func expandRange(pattern string) ([]string, error) {
+	m := hostRangeRegexp.FindStringSubmatchIndex(pattern)
+	if m == nil {
+		return []string{pattern}, nil
+	}
+
+	start, end := pattern[m[2]:m[3]], pattern[m[4]:m[5]]
+	step := 1
+	if m[6] >= 0 {
+		step, _ = strconv.Atoi(pattern[m[6]:m[7]])
+	}
+	if step < 1 {
+		return nil, fmt.Errorf("host range %s: step must be positive", pattern[m[0]:m[1]])
+	}
+
+	items := make([]string, 0)
+	first, errFirst := strconv.Atoi(start)
+	last, errLast := strconv.Atoi(end)
+	switch {
+	case errFirst == nil && errLast == nil:
+		// web[01:10] keeps the leading zeros.
+		width := 0
+		if len(start) > 1 && start[0] == '0' {
+			width = len(start)
+		}
+		for i := first; i <= last; i += step {
+			items = append(items, fmt.Sprintf("%0*d", width, i))
+		}
+	case errFirst != nil && errLast != nil:
+		for c := start[0]; c <= end[0]; c += byte(step) {
+			items = append(items, string(c))
+		}
+	default:
+		return nil, fmt.Errorf("host range %s: mixes letters and numbers", pattern[m[0]:m[1]])
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		// The rest of the pattern may have another range.
+		rest, err := expandRange(pattern[m[1]:])
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rest {
+			names = append(names, pattern[:m[0]]+item+r)
+		}
+	}
+
+	return names, nil
+}
+
+// splitFields splits a host line on spaces outside quotes.
+func splitFields(line string) []string {
+	fields := make([]string, 0)
+	var quote rune
+	current := strings.Builder{}
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && (r == ' ' || r == '\t'):
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+
+			continue
+		}
+
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+
+	return fields
+}
+
+// stripComment removes a comment started with # or ; at the start of the line or after a space.
+func stripComment(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+		return ""
+	}
+
+	if i := strings.Index(line, " #"); i >= 0 {
+		return line[:i]
+	}
+
+	return line
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
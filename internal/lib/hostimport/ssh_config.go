@@ -0,0 +1,129 @@
+package hostimport
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"io"
+	"path"
+	"strings"
+)
+
+// sshBlock is a Host block of ssh_config, the options before the first block have the pattern "*".
+type sshBlock struct {
+	patterns []string
+	options  [][2]string
+}
+
+// ParseSSHConfig reads the Host blocks of ssh_config. Every name without wildcards becomes a host, its
+// HostName, User, Port and IdentityFile are resolved like ssh does: the first value found in the blocks
+// matching the name wins.
+func ParseSSHConfig(r io.Reader) (Result, error) {
+	result := Result{}
+	blocks := []sshBlock{{patterns: []string{"*"}}}
+	names := make([]string, 0)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, value := splitSSHOption(line)
+		switch strings.ToLower(keyword) {
+		case "host":
+			patterns := strings.Fields(value)
+			if len(patterns) == 0 {
+				return result, fmt.Errorf("line %d: Host without a pattern", n)
+			}
+			blocks = append(blocks, sshBlock{patterns: patterns})
+			for _, p := range patterns {
+				if !strings.ContainsAny(p, "*?!") && !contains(names, p) {
+					names = append(names, p)
+				}
+			}
+		case "match":
+			// The options of a Match block can't be applied without a connection, they are skipped.
+			blocks = append(blocks, sshBlock{})
+			result.warnf("line %d: Match blocks are not imported", n)
+		case "include":
+			result.warnf("line %d: Include %s is not followed, import the included file separately", n, value)
+		default:
+			b := &blocks[len(blocks)-1]
+			b.options = append(b.options, [2]string{strings.ToLower(keyword), unquote(value)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+
+	for _, name := range names {
+		h := config.InventoryHost{Name: name}
+		set := make(map[string]bool)
+		for _, b := range blocks {
+			if !matchSSHPatterns(b.patterns, name) {
+				continue
+			}
+
+			for _, o := range b.options {
+				if set[o[0]] {
+					continue
+				}
+
+				switch o[0] {
+				case "hostname":
+					h.AnsibleHost = strings.ReplaceAll(o[1], "%h", name)
+				case "user":
+					h.AnsibleUser = o[1]
+				case "port":
+					port, err := parsePort(o[1])
+					if err != nil {
+						result.warnf("%s: %s", name, err)
+						continue
+					}
+					h.AnsiblePort = port
+				case "identityfile":
+					h.AnsibleSSHPrivateKeyFile = o[1]
+				default:
+					continue
+				}
+				set[o[0]] = true
+			}
+		}
+
+		result.add(h)
+	}
+
+	return result, nil
+}
+
+// splitSSHOption splits a line into the keyword and its value, they are separated by spaces or an equal sign.
+func splitSSHOption(line string) (string, string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+
+	value := strings.TrimSpace(line[i:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+
+	return line[:i], value
+}
+
+// matchSSHPatterns reports whether the name matches the patterns of a Host block, a negated pattern that
+// matches excludes the name.
+func matchSSHPatterns(patterns []string, name string) bool {
+	matched := false
+	for _, p := range patterns {
+		negated := strings.HasPrefix(p, "!")
+		if ok, _ := path.Match(strings.TrimPrefix(p, "!"), name); ok {
+			if negated {
+				return false
+			}
+			matched = true
+		}
+	}
+
+	return matched
+}
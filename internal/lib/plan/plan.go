@@ -0,0 +1,361 @@
+// Package plan describes what a deploy is going to do: the Webitel version, which service goes to which host,
+// how Ansible connects to the hosts and which features are enabled, with the problems of the config inline.
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/validator"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"github.com/kirychukyurii/wdeploy/internal/templates/view"
+	"sort"
+	"strings"
+)
+
+// Host is a host of the inventory with its effective connection settings.
+type Host struct {
+	Name       string
+	Address    string
+	Connection config.Connection
+	Groups     []string
+	Vars       []string // Other variables of the host, their values may be secret
+	Warnings   []string
+}
+
+// User describes the user Ansible connects as.
+func (h Host) User() string {
+	switch {
+	case h.Connection.Local:
+		return "-"
+	case h.Connection.User.Source == config.SourceDefault:
+		return "current user"
+	}
+
+	return fmt.Sprintf("%s (%s)", h.Connection.User.Value, h.Connection.User.Source)
+}
+
+// Port describes the port Ansible connects to.
+func (h Host) Port() string {
+	switch {
+	case h.Connection.Local:
+		return "-"
+	case h.Connection.Port.Source == config.SourceDefault:
+		return h.Connection.Port.Value
+	}
+
+	return fmt.Sprintf("%s (%s)", h.Connection.Port.Value, h.Connection.Port.Source)
+}
+
+// Auth describes how Ansible authenticates on the host.
+func (h Host) Auth() string {
+	c := h.Connection
+	switch {
+	case c.Local:
+		return "local connection"
+	case c.KeyFile.Source != config.SourceDefault && c.Password.Source != config.SourceDefault:
+		return fmt.Sprintf("key %s (%s), password (%s)", c.KeyFile.Value, c.KeyFile.Source, c.Password.Source)
+	case c.KeyFile.Source != config.SourceDefault:
+		return fmt.Sprintf("key %s (%s)", c.KeyFile.Value, c.KeyFile.Source)
+	case c.Password.Source != config.SourceDefault:
+		if vault.IsEncrypted(c.Password.Value) {
+			return fmt.Sprintf("vault password (%s)", c.Password.Source)
+		}
+
+		return fmt.Sprintf("password (%s)", c.Password.Source)
+	}
+
+	return "ssh-agent or default keys"
+}
+
+// Service is a row of the host by service matrix.
+type Service struct {
+	Name     string
+	OnHosts  []bool // Whether the service is placed on the host of Plan.Hosts with the same index
+	Warnings []string
+}
+
+// Feature is an optional part of the deployment.
+type Feature struct {
+	Name     string
+	Value    string
+	keys     []string // Variables the feature is configured with, their problems are shown with it
+	Warnings []string
+}
+
+// Group is a group of the inventory other than all.
+type Group struct {
+	Name     string
+	Parents  []string
+	Hosts    []string // Hosts listed in the group, the hosts of its children are in their groups
+	Vars     []string // Variables of the group, their values may be secret
+	Warnings []string
+}
+
+// Plan is the description of a deploy.
+type Plan struct {
+	WebitelVersion string
+	Playbook       string
+	PlaybookRef    string
+	PlaybookCommit string
+	Hosts          []Host
+	Groups         []Group
+	Services       []Service
+	Features       []Feature
+	Locales        []string
+	Warnings       []string // Problems that belong to no host, service or feature
+}
+
+// New describes the deploy of the config, the config files are read again so the plan shows what is saved.
+func New(cfg config.Config) (Plan, error) {
+	cfg.Variables, cfg.Inventory = config.Variables{}, config.Inventory{}
+	for _, configFileType := range []int{config.VarsConfig, config.InventoryConfig} {
+		// Secrets that fail to decrypt stay encrypted, the plan doesn't show them.
+		if err := cfg.ReadToStruct(configFileType); err != nil && !errors.Is(err, vault.ErrWrongPassword) {
+			return Plan{}, err
+		}
+	}
+
+	hosts, err := cfg.InventoryHosts()
+	if err != nil {
+		return Plan{}, err
+	}
+
+	p := Plan{
+		WebitelVersion: cfg.WebitelVersion,
+		Playbook:       cfg.PlaybookRepositoryUrl,
+		PlaybookRef:    cfg.PlaybookRef,
+		PlaybookCommit: cfg.PlaybookCommit,
+		Hosts:          make([]Host, 0, len(hosts)),
+		Locales:        cfg.LocalesGen,
+		Features:       features(cfg.Variables),
+	}
+	if cfg.PlaybookPath != "" {
+		p.Playbook = cfg.PlaybookPath
+	}
+
+	// The values a host gets from its groups are used, the hosts keep the order of the file.
+	inventory := cfg.Inventory.Hosts()
+	for i, h := range hosts {
+		hosts[i].Host = inventory[h.Name]
+		p.Hosts = append(p.Hosts, Host{
+			Name:       h.Name,
+			Address:    inventory[h.Name].AnsibleHost,
+			Connection: cfg.HostConnection(inventory[h.Name]),
+			Groups:     inventory[h.Name].Groups,
+			Vars:       keys(inventory[h.Name].Vars),
+		})
+	}
+
+	for _, g := range cfg.Inventory.Groups() {
+		if g.Name == "all" {
+			continue
+		}
+
+		p.Groups = append(p.Groups, Group{
+			Name:    g.Name,
+			Parents: g.Parents,
+			Hosts:   g.Hosts,
+			Vars:    keys(g.Vars),
+		})
+	}
+
+	p.Services = services(hosts)
+
+	problems, err := validator.Validate(cfg)
+	if err != nil {
+		return Plan{}, err
+	}
+	for _, problem := range problems {
+		p.addProblem(problem)
+	}
+
+	return p, nil
+}
+
+// Render returns the plan in Markdown.
+func Render(cfg config.Config) (string, error) {
+	p, err := New(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	return view.Render(p)
+}
+
+// services returns the rows of the matrix: the services of vars.WebitelServices placed on a host or required,
+// then the unknown services in the order of the inventory.
+func services(hosts []config.InventoryHost) []Service {
+	names := make([]string, 0, len(vars.WebitelServices))
+	placed := make(map[string]bool)
+	for _, h := range hosts {
+		for _, s := range h.WebitelServices {
+			placed[s] = true
+		}
+	}
+
+	for _, s := range vars.WebitelServices {
+		if placed[s] || contains(vars.RequiredServices, s) {
+			names = append(names, s)
+		}
+	}
+	for _, h := range hosts {
+		for _, s := range h.WebitelServices {
+			if !contains(names, s) {
+				names = append(names, s)
+			}
+		}
+	}
+
+	rows := make([]Service, 0, len(names))
+	for _, name := range names {
+		row := Service{Name: name, OnHosts: make([]bool, len(hosts))}
+		for i, h := range hosts {
+			row.OnHosts[i] = contains(h.WebitelServices, name)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func keys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// features returns the optional parts of the deployment with the variables they are configured with.
+func features(v config.Variables) []Feature {
+	letsencrypt := "no"
+	if v.NginxLetsencrypt {
+		letsencrypt = fmt.Sprintf("yes, %s", orDash(v.NginxMailAddress))
+	}
+
+	dashboards := "no"
+	if v.GrafanaBasicDashboards {
+		dashboards = fmt.Sprintf("yes, %s", orDash(v.GrafanaBasicDashboardsLanguage))
+	}
+
+	f := []Feature{
+		{Name: "Site", Value: orDash(v.NginxSiteName), keys: []string{"nginx_site_name"}},
+		{Name: "Let's Encrypt", Value: letsencrypt, keys: []string{"nginx_letsencrypt", "nginx_mail_address"}},
+		{Name: "Grafana", Value: yesNo(v.GrafanaEnable), keys: []string{"grafana_enable"}},
+		{Name: "Grafana basic dashboards", Value: dashboards,
+			keys: []string{"grafana_basic_dashboards", "grafana_basic_dashboards_language"}},
+		{Name: "OpenSIPS", Value: orDash(v.OpensipsVersion), keys: []string{"opensips_version"}},
+		{Name: "OpenSIPS fail2ban", Value: yesNo(v.OpensipsFail2ban), keys: []string{"opensips_fail2ban"}},
+		{Name: "RTPEngine mode", Value: orDash(v.RTPEngineMode), keys: []string{"rtpengine_mode"}},
+	}
+
+	// Combinations the playbook accepts but that don't do what is expected.
+	if v.NginxLetsencrypt && v.NginxMailAddress == "" {
+		f[1].Warnings = append(f[1].Warnings, "⚠ nginx_mail_address is needed to register the certificate")
+	}
+	if v.GrafanaBasicDashboards && !v.GrafanaEnable {
+		f[3].Warnings = append(f[3].Warnings, "⚠ dashboards are not installed while grafana_enable is false")
+	}
+
+	return f
+}
+
+// addProblem shows the problem with the host, the service or the feature it is about.
+func (p *Plan) addProblem(problem validator.Problem) {
+	severity := "✗"
+	if problem.Severity == validator.SeverityWarning {
+		severity = "⚠"
+	}
+
+	host, key, _ := strings.Cut(problem.Key, ".")
+	for i := range p.Hosts {
+		if p.Hosts[i].Name != host || key == "webitel_services" {
+			continue
+		}
+
+		note := fmt.Sprintf("%s %s", severity, problem.Message)
+		if key != "" {
+			note = fmt.Sprintf("%s %s: %s", severity, key, problem.Message)
+		}
+		p.Hosts[i].Warnings = append(p.Hosts[i].Warnings, note)
+
+		return
+	}
+
+	// Problems of a group have the path of the group as the key, e.g. all.children.web.vars.
+	if i := strings.LastIndex(problem.Key, ".children."); i >= 0 {
+		group, key, _ := strings.Cut(problem.Key[i+len(".children."):], ".")
+		for j := range p.Groups {
+			if p.Groups[j].Name != group {
+				continue
+			}
+
+			note := fmt.Sprintf("%s %s", severity, problem.Message)
+			if key != "" {
+				note = fmt.Sprintf("%s %s: %s", severity, key, problem.Message)
+			}
+			p.Groups[j].Warnings = append(p.Groups[j].Warnings, note)
+
+			return
+		}
+	}
+
+	for i := range p.Services {
+		name := p.Services[i].Name
+		if !strings.HasPrefix(problem.Message, name+" ") && !strings.HasPrefix(problem.Message, fmt.Sprintf("unknown service %q", name)) {
+			continue
+		}
+
+		note := fmt.Sprintf("%s %s", severity, problem.Message)
+		if key == "webitel_services" {
+			note = fmt.Sprintf("%s %s: %s", severity, host, problem.Message)
+		}
+		p.Services[i].Warnings = append(p.Services[i].Warnings, note)
+
+		return
+	}
+
+	for i := range p.Features {
+		if contains(p.Features[i].keys, problem.Key) {
+			p.Features[i].Warnings = append(p.Features[i].Warnings, fmt.Sprintf("%s %s", severity, problem.Message))
+			return
+		}
+	}
+
+	note := fmt.Sprintf("%s %s", severity, problem.Message)
+	if problem.Key != "" {
+		note = fmt.Sprintf("%s %s: %s", severity, problem.Key, problem.Message)
+	}
+	p.Warnings = append(p.Warnings, note)
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
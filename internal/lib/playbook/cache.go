@@ -0,0 +1,216 @@
+package playbook
+
+import (
+	"github.com/adrg/xdg"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"github.com/kirychukyurii/wdeploy/internal/lib/git"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// staleAfter is the age after which a temporary playbook directory left by a previous run is removed.
+const staleAfter = 24 * time.Hour
+
+// CacheInfo describes the state of the playbook cache.
+type CacheInfo struct {
+	Dir       string
+	URL       string
+	Commit    string
+	Size      int64
+	StaleDirs []string
+}
+
+// CacheDir returns the directory of the cached clone of the playbook repository.
+func CacheDir(config config.Config) string {
+	return filepath.Join(xdg.CacheHome, constants.AppName, "playbooks", repositoryName(config))
+}
+
+// prepareCache exports the commit of config.PlaybookRef from the cached clone into a temporary directory of the
+// run in config.PlaybookTempDir. The cache is fetched and fast-forwarded first, when the remote is unreachable
+// the cached copy is used as is. Other runs may check out another commit in the cache meanwhile, the exported
+// directory is not changed by them.
+func prepareCache(logger logger.Logger, config *config.Config) error {
+	unlock, err := lockCache(*config)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	commit, err := syncCache(logger, *config, true)
+	if err != nil {
+		return err
+	}
+
+	dir, err := file.CreateTempDir(tempDirPattern(*config))
+	if err != nil {
+		return err
+	}
+	config.PlaybookTempDir, config.PlaybookCommit = dir, commit
+
+	if err = git.Export(CacheDir(*config), commit, dir); err == nil {
+		err = checkPlaybookDir(dir)
+	}
+	if err != nil {
+		Cleanup(logger, *config)
+		return err
+	}
+	logger.Zap.Infof("Using cached Ansible code for deploying Webitel services: %s (ref: %q, commit: %s)",
+		config.PlaybookTempDir, config.PlaybookRef, config.PlaybookCommit)
+
+	return nil
+}
+
+// RefreshCache fetches the playbook repository into the cache, cloning it when the cache is empty,
+// and checks out config.PlaybookRef. It returns the hash of the checked out commit.
+func RefreshCache(logger logger.Logger, config config.Config) (string, error) {
+	unlock, err := lockCache(config)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	return syncCache(logger, config, false)
+}
+
+// lockCache waits until no other process uses the cache and locks it, the returned function unlocks it.
+func lockCache(config config.Config) (func() error, error) {
+	dir := CacheDir(config)
+	if err := file.EnsureDir(filepath.Dir(dir)); err != nil {
+		return nil, err
+	}
+
+	return file.Lock(dir + ".lock")
+}
+
+func syncCache(logger logger.Logger, config config.Config, offline bool) (string, error) {
+	dir := CacheDir(config)
+	if url, err := git.RemoteURL(dir); err != nil || url != config.PlaybookRepositoryUrl {
+		return cloneCache(logger, config)
+	}
+
+	if err := git.Fetch(dir); err != nil {
+		if !offline {
+			return "", err
+		}
+		logger.Zap.Warnf("Failed to fetch %s, using the cached copy: %s", config.PlaybookRepositoryUrl, err)
+	} else {
+		logger.Zap.Infof("Fetched %s into %s", config.PlaybookRepositoryUrl, dir)
+	}
+
+	return git.Checkout(dir, config.PlaybookRef)
+}
+
+func cloneCache(logger logger.Logger, config config.Config) (string, error) {
+	dir := CacheDir(config)
+	if err := clearCache(config); err != nil {
+		return "", err
+	}
+
+	commit, err := git.CloneGitRepo(config.PlaybookRepositoryUrl, dir, config.PlaybookRef)
+	if err != nil {
+		// Do not leave a partial clone behind, it would be mistaken for the cache next time.
+		if rmErr := clearCache(config); rmErr != nil {
+			logger.Zap.Error(rmErr)
+		}
+
+		return "", err
+	}
+	logger.Zap.Infof("Cloned %s into %s", config.PlaybookRepositoryUrl, dir)
+
+	return commit, nil
+}
+
+// ClearCache removes the cached clone of the playbook repository, runs started from it are not affected.
+func ClearCache(config config.Config) error {
+	unlock, err := lockCache(config)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return clearCache(config)
+}
+
+func clearCache(config config.Config) error {
+	return file.RemoveAll(CacheDir(config))
+}
+
+// Cache returns the state of the playbook cache, Commit is empty when nothing is cached yet.
+func Cache(config config.Config) (CacheInfo, error) {
+	info := CacheInfo{Dir: CacheDir(config)}
+
+	stale, err := staleDirs(config)
+	if err != nil {
+		return info, err
+	}
+	info.StaleDirs = stale
+
+	if !file.IsDir(info.Dir) {
+		return info, nil
+	}
+
+	if info.URL, err = git.RemoteURL(info.Dir); err != nil {
+		return info, err
+	}
+
+	if info.Commit, err = git.HeadCommit(info.Dir); err != nil {
+		return info, err
+	}
+
+	err = filepath.WalkDir(info.Dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		info.Size += fi.Size()
+
+		return nil
+	})
+
+	return info, err
+}
+
+// CollectGarbage removes temporary playbook directories left behind by crashed runs.
+func CollectGarbage(logger logger.Logger, config config.Config) error {
+	stale, err := staleDirs(config)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range stale {
+		if err = file.RemoveAll(dir); err != nil {
+			return err
+		}
+		logger.Zap.Infof("Deleted stale temporary directory: %s", dir)
+	}
+
+	return nil
+}
+
+func staleDirs(config config.Config) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(os.TempDir(), tempDirPattern(config)+"*"))
+	if err != nil {
+		return nil, err
+	}
+
+	stale := make([]string, 0)
+	for _, m := range matches {
+		fi, err := os.Stat(m)
+		if err != nil || !fi.IsDir() || time.Since(fi.ModTime()) < staleAfter {
+			continue
+		}
+
+		stale = append(stale, m)
+	}
+
+	return stale, nil
+}
@@ -0,0 +1,143 @@
+// Package playbook makes the Ansible code for deploying Webitel services available on disk:
+// a managed clone of the playbook repository, a local directory or an unpacked archive.
+package playbook
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"github.com/kirychukyurii/wdeploy/internal/lib/git"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Prepare makes Ansible code for deploying Webitel services available in config.PlaybookTempDir:
+// either a local playbook directory, an unpacked archive or the cached clone of the playbook repository.
+func Prepare(logger logger.Logger, config *config.Config) error {
+	if err := CollectGarbage(logger, *config); err != nil {
+		logger.Zap.Warnf("Failed to remove stale temporary directories: %s", err)
+	}
+
+	if config.PlaybookPath != "" {
+		return prepareLocalPlaybook(logger, config)
+	}
+
+	return prepareCache(logger, config)
+}
+
+// prepareLocalPlaybook uses a local playbook directory in place or unpacks a .tar.gz archive of it.
+func prepareLocalPlaybook(logger logger.Logger, config *config.Config) error {
+	path, err := filepath.Abs(config.PlaybookPath)
+	if err != nil {
+		return err
+	}
+	config.PlaybookPath = path
+
+	if file.IsDir(path) {
+		config.PlaybookTempDir = path
+		if config.PlaybookCommit, err = git.HeadCommit(path); err != nil {
+			logger.Zap.Debugf("Local playbook directory is not a git repository: %s", err)
+		}
+		logger.Zap.Infof("Using local Ansible code for deploying Webitel services: %s (commit: %s)",
+			path, config.PlaybookCommit)
+
+		return checkPlaybookDir(path)
+	}
+
+	if !file.IsFile(path) || !(strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")) {
+		return fmt.Errorf("%s: playbook path must be a directory or a .tar.gz archive", path)
+	}
+
+	config.PlaybookTempDir, err = file.CreateTempDir(tempDirPattern(*config))
+	if err != nil {
+		return err
+	}
+	logger.Zap.Infof("Created temporary directory: %s", config.PlaybookTempDir)
+
+	if err = file.ExtractTarGz(path, config.PlaybookTempDir); err == nil {
+		err = unwrapArchiveRoot(config.PlaybookTempDir)
+	}
+	if err == nil {
+		err = checkPlaybookDir(config.PlaybookTempDir)
+	}
+	if err != nil {
+		Cleanup(logger, *config)
+
+		return err
+	}
+	config.PlaybookCommit, _ = git.HeadCommit(config.PlaybookTempDir)
+	logger.Zap.Infof("Unpacked Ansible code for deploying Webitel services: %s (commit: %s)", path, config.PlaybookCommit)
+
+	return nil
+}
+
+// Cleanup removes the temporary directory created by Prepare. The cache and
+// a local playbook directory are left in place.
+func Cleanup(logger logger.Logger, config config.Config) {
+	if config.PlaybookTempDir == "" || config.PlaybookTempDir == config.PlaybookPath ||
+		config.PlaybookTempDir == CacheDir(config) {
+		return
+	}
+
+	if err := file.RemoveAll(config.PlaybookTempDir); err != nil {
+		logger.Zap.Error(err)
+	}
+	logger.Zap.Infof("Deleted temporary directory: %s", config.PlaybookTempDir)
+}
+
+func tempDirPattern(config config.Config) string {
+	return fmt.Sprintf("%s-", repositoryName(config))
+}
+
+func repositoryName(config config.Config) string {
+	name := regexp.MustCompile(`.*/(.*?)(\.git)?/?$`).FindStringSubmatch(config.PlaybookRepositoryUrl)
+	if name == nil || name[1] == "" {
+		return "playbook"
+	}
+
+	return name[1]
+}
+
+func checkPlaybookDir(dir string) error {
+	if !file.IsFile(filepath.Join(dir, ansible.PlaybookFileName)) {
+		return fmt.Errorf("%s: %s not found", dir, ansible.PlaybookFileName)
+	}
+
+	return nil
+}
+
+// unwrapArchiveRoot moves the content of a single top-level directory, as in archives
+// downloaded from GitHub, up to dir.
+func unwrapArchiveRoot(dir string) error {
+	if file.IsFile(filepath.Join(dir, ansible.PlaybookFileName)) {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return err
+	}
+
+	// Rename the root first, it may contain an entry with the same name.
+	root := filepath.Join(dir, ".archive-root")
+	if err = os.Rename(filepath.Join(dir, entries[0].Name()), root); err != nil {
+		return err
+	}
+
+	children, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range children {
+		if err = os.Rename(filepath.Join(root, c.Name()), filepath.Join(dir, c.Name())); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(root)
+}
@@ -0,0 +1,230 @@
+// Package profile manages deployment profiles: named data directories under config.ProfilesDir,
+// each with its own vars, inventory, logs and history.
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+var (
+	// ErrNotFound is returned when the profile doesn't exist.
+	ErrNotFound = errors.New("profile: not found")
+	// ErrExists is returned when a profile with the name already exists.
+	ErrExists = errors.New("profile: already exists")
+
+	nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+)
+
+// Directories of a profile copied by Clone, logs and history stay with the source profile.
+var configDirs = []string{"vars", "inventory"}
+
+// Profile is a named set of config files.
+type Profile struct {
+	Name string
+	Dir  string
+}
+
+// VarsFile returns the variables file of the profile.
+func (p Profile) VarsFile() string {
+	return filepath.Join(p.Dir, "vars", "all.yml")
+}
+
+// InventoryFile returns the inventory file of the profile.
+func (p Profile) InventoryFile() string {
+	return filepath.Join(p.Dir, "inventory", "all.yml")
+}
+
+// LastRun returns the latest deploy or dry run of the profile, history.ErrNotFound when there is none.
+func (p Profile) LastRun() (history.Run, error) {
+	return history.New(config.Config{HistoryDirectory: filepath.Join(p.Dir, "history")}).Get("last")
+}
+
+// ValidateName returns an error when name can't be used as a profile name.
+func ValidateName(name string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("profile: invalid name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+
+	return nil
+}
+
+// List returns the profiles sorted by name. A directory is a profile when it has a vars or an inventory file.
+func List() ([]Profile, error) {
+	entries, err := os.ReadDir(config.ProfilesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	profiles := make([]Profile, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		p := newProfile(e.Name())
+		if file.IsFile(p.VarsFile()) || file.IsFile(p.InventoryFile()) {
+			profiles = append(profiles, p)
+		}
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+
+	return profiles, nil
+}
+
+// Get returns the profile with the name.
+func Get(name string) (Profile, error) {
+	profiles, err := List()
+	if err != nil {
+		return Profile{}, err
+	}
+
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return Profile{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Create creates the profile from cfg with its config files generated from the templates.
+// Paths of the created files are written to out.
+func Create(cfg config.Config, name string, out io.Writer) (Profile, error) {
+	if err := checkNew(name); err != nil {
+		return Profile{}, err
+	}
+
+	cfg.Profile = name
+	cfg.ConfigFiles = make([]string, len(cfg.ConfigFiles))
+	config.Load(cfg, out)
+
+	return Get(name)
+}
+
+// Clone creates the profile dst with copies of the vars and the inventory of src.
+func Clone(src, dst string) (Profile, error) {
+	from, err := Get(src)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	if err = checkNew(dst); err != nil {
+		return Profile{}, err
+	}
+
+	to := newProfile(dst)
+	for _, dir := range configDirs {
+		if !file.IsDir(filepath.Join(from.Dir, dir)) {
+			continue
+		}
+
+		if err = copyDir(filepath.Join(from.Dir, dir), filepath.Join(to.Dir, dir)); err != nil {
+			_ = file.RemoveAll(to.Dir)
+
+			return Profile{}, err
+		}
+	}
+
+	return to, nil
+}
+
+// Rename renames the profile oldName to newName, with its logs and history.
+func Rename(oldName, newName string) (Profile, error) {
+	from, err := Get(oldName)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	if err = checkNew(newName); err != nil {
+		return Profile{}, err
+	}
+
+	to := newProfile(newName)
+	if err = os.Rename(from.Dir, to.Dir); err != nil {
+		return Profile{}, err
+	}
+
+	return to, nil
+}
+
+// Delete removes the profile with its config files, logs and history.
+func Delete(name string) error {
+	p, err := Get(name)
+	if err != nil {
+		return err
+	}
+
+	return file.RemoveAll(p.Dir)
+}
+
+func newProfile(name string) Profile {
+	return Profile{
+		Name: name,
+		Dir:  config.ProfileDir(name),
+	}
+}
+
+// checkNew returns an error when name is invalid or its directory is already taken.
+func checkNew(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(config.ProfileDir(name)); err == nil {
+		return fmt.Errorf("%w: %s", ErrExists, name)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// copyDir copies the regular files of src to dst recursively.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return file.EnsureDir(target)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, content, info.Mode().Perm())
+	})
+}
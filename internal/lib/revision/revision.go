@@ -0,0 +1,211 @@
+// Package revision keeps the previous contents of a config file, so a change made by wdeploy or in the editor
+// can be reviewed and rolled back.
+package revision
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxRevisions is the number of revisions kept per file, the oldest ones are removed.
+const MaxRevisions = 50
+
+const (
+	idFormat = "20060102-150405.000"
+	ext      = ".yml"
+)
+
+// ErrNotFound is returned by Store.Get when no revision matches the ID.
+var ErrNotFound = errors.New("revision: not found")
+
+// Revision is a previous content of a config file.
+type Revision struct {
+	ID   string
+	Time time.Time
+	Size int64
+
+	path string
+}
+
+// Path returns the path of the file with the content of the revision.
+func (r Revision) Path() string {
+	return r.path
+}
+
+// Content returns the content of the file at the time of the revision.
+func (r Revision) Content() (string, error) {
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// Store keeps the revisions of a config file in a directory.
+type Store struct {
+	dir string
+}
+
+// New returns the store of the revisions in dir.
+func New(dir string) Store {
+	return Store{dir: dir}
+}
+
+// Dir returns the directory of the store.
+func (s Store) Dir() string {
+	return s.dir
+}
+
+// Save keeps the current content of the file at path as a revision. Nothing is saved when the file doesn't
+// exist or its content is the same as the latest revision, it reports whether a revision was saved.
+func (s Store) Save(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	revisions, err := s.List()
+	if err != nil {
+		return false, err
+	}
+	if len(revisions) > 0 {
+		latest, err := revisions[0].Content()
+		if err == nil && latest == string(content) {
+			return false, nil
+		}
+	}
+
+	if err = file.EnsureDir(s.dir); err != nil {
+		return false, err
+	}
+
+	// Revisions saved within a millisecond get the next free ID.
+	t := time.Now()
+	target := filepath.Join(s.dir, t.Format(idFormat)+ext)
+	for file.IsFile(target) {
+		t = t.Add(time.Millisecond)
+		target = filepath.Join(s.dir, t.Format(idFormat)+ext)
+	}
+
+	// The file may hold secrets that are not encrypted yet.
+	if err = os.WriteFile(target, content, 0600); err != nil {
+		return false, err
+	}
+
+	return true, s.prune(append([]Revision{{}}, revisions...))
+}
+
+// List returns the revisions, the latest first.
+func (s Store) List() ([]Revision, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	revisions := make([]Revision, 0, len(entries))
+	for _, e := range entries {
+		id := strings.TrimSuffix(e.Name(), ext)
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ext) {
+			continue
+		}
+
+		t, err := time.ParseInLocation(idFormat, id, time.Local)
+		if err != nil {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		revisions = append(revisions, Revision{ID: id, Time: t, Size: info.Size(), path: filepath.Join(s.dir, e.Name())})
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Time.After(revisions[j].Time)
+	})
+
+	return revisions, nil
+}
+
+// Get returns the revision with the ID or a unique prefix of it, "last" is the latest revision.
+func (s Store) Get(id string) (Revision, error) {
+	revisions, err := s.List()
+	if err != nil {
+		return Revision{}, err
+	}
+
+	if id == "last" && len(revisions) > 0 {
+		return revisions[0], nil
+	}
+
+	matches := make([]Revision, 0)
+	for _, r := range revisions {
+		if r.ID == id {
+			return r, nil
+		}
+		if strings.HasPrefix(r.ID, id) {
+			matches = append(matches, r)
+		}
+	}
+
+	if len(matches) > 1 {
+		return Revision{}, fmt.Errorf("revision: ID %q is ambiguous", id)
+	}
+	if len(matches) == 0 {
+		return Revision{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return matches[0], nil
+}
+
+// Restore writes the content of the revision to the file at path. The current content is saved as a revision
+// first, so the restore can be undone.
+func (s Store) Restore(id, path string) error {
+	r, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	content, err := r.Content()
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.Save(path); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// prune removes the revisions beyond MaxRevisions, revisions are the latest first.
+func (s Store) prune(revisions []Revision) error {
+	if len(revisions) <= MaxRevisions {
+		return nil
+	}
+
+	for _, r := range revisions[MaxRevisions:] {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package summary describes a deployment for handover: the Webitel version, the hosts with their services,
+// the main variables and the outcome of the last run, rendered as Markdown, HTML or JSON.
+package summary
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible/events"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	tsummary "github.com/kirychukyurii/wdeploy/internal/templates/summary"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// fileMode is the mode of the exported summaries, they list the hosts and addresses of the deployment,
+// so only the user reads them as the history of the runs.
+const fileMode = 0600
+
+// Formats are the formats Render accepts.
+var Formats = []string{FormatMarkdown, FormatHTML, FormatJSON}
+
+// Host is a host of the inventory.
+type Host struct {
+	Name     string   `json:"name"`
+	Address  string   `json:"address"`
+	User     string   `json:"user,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	Services []string `json:"services"`
+}
+
+// Settings are the variables that tell how the deployment is reached and what is enabled, secrets are left out.
+type Settings struct {
+	NginxSiteName                  string   `json:"nginx_site_name"`
+	NginxLetsencrypt               bool     `json:"nginx_letsencrypt"`
+	NginxMailAddress               string   `json:"nginx_mail_address,omitempty"`
+	GrafanaEnable                  bool     `json:"grafana_enable"`
+	GrafanaBasicDashboards         bool     `json:"grafana_basic_dashboards"`
+	GrafanaBasicDashboardsLanguage string   `json:"grafana_basic_dashboards_language,omitempty"`
+	RTPEngineMode                  string   `json:"rtpengine_mode"`
+	OpensipsVersion                string   `json:"opensips_version"`
+	OpensipsFail2ban               bool     `json:"opensips_fail2ban"`
+	LocalesGen                     []string `json:"locales_gen,omitempty"`
+}
+
+// Run is the outcome of the last run.
+type Run struct {
+	ID       string                      `json:"id"`
+	Mode     string                      `json:"mode"`
+	Status   history.Status              `json:"status"`
+	Start    time.Time                   `json:"start"`
+	Duration time.Duration               `json:"duration"`
+	Error    string                      `json:"error,omitempty"`
+	Limit    string                      `json:"limit,omitempty"`
+	Tags     string                      `json:"tags,omitempty"`
+	Stats    map[string]events.HostStats `json:"stats,omitempty"`
+}
+
+// Summary is the description of a deployment.
+type Summary struct {
+	Generated      time.Time `json:"generated"`
+	Profile        string    `json:"profile"`
+	WebitelVersion string    `json:"webitel_version"`
+	Playbook       string    `json:"playbook"`
+	PlaybookRef    string    `json:"playbook_ref,omitempty"`
+	PlaybookCommit string    `json:"playbook_commit,omitempty"`
+	Hosts          []Host    `json:"hosts"`
+	Settings       Settings  `json:"settings"`
+	LastRun        *Run      `json:"last_run,omitempty"`
+}
+
+// New describes the deployment of the config, the config files are read again so the summary shows
+// what is saved. The last run is taken from the history of the config profile.
+func New(cfg config.Config) (Summary, error) {
+	cfg.Variables = config.Variables{}
+	if err := cfg.ReadToStruct(config.VarsConfig); err != nil {
+		return Summary{}, err
+	}
+
+	hosts, err := cfg.InventoryHosts()
+	if err != nil {
+		return Summary{}, err
+	}
+
+	s := Summary{
+		Generated:      time.Now(),
+		Profile:        cfg.Profile,
+		WebitelVersion: cfg.WebitelVersion,
+		Playbook:       cfg.PlaybookRepositoryUrl,
+		PlaybookRef:    cfg.PlaybookRef,
+		PlaybookCommit: cfg.PlaybookCommit,
+		Hosts:          make([]Host, 0, len(hosts)),
+		Settings: Settings{
+			NginxSiteName:                  cfg.NginxSiteName,
+			NginxLetsencrypt:               cfg.NginxLetsencrypt,
+			NginxMailAddress:               cfg.NginxMailAddress,
+			GrafanaEnable:                  cfg.GrafanaEnable,
+			GrafanaBasicDashboards:         cfg.GrafanaBasicDashboards,
+			GrafanaBasicDashboardsLanguage: cfg.GrafanaBasicDashboardsLanguage,
+			RTPEngineMode:                  cfg.RTPEngineMode,
+			OpensipsVersion:                cfg.OpensipsVersion,
+			OpensipsFail2ban:               cfg.OpensipsFail2ban,
+			LocalesGen:                     cfg.LocalesGen,
+		},
+	}
+	if cfg.PlaybookPath != "" {
+		s.Playbook = cfg.PlaybookPath
+	}
+
+	for _, h := range hosts {
+		host := Host{
+			Name:     h.Name,
+			Address:  h.AnsibleHost,
+			User:     h.AnsibleUser,
+			Port:     h.AnsiblePort,
+			Services: h.WebitelServices,
+		}
+		if host.User == "" {
+			host.User = cfg.AnsibleUser
+		}
+		if host.Port == 0 {
+			host.Port = cfg.AnsiblePort
+		}
+		if host.Services == nil {
+			host.Services = []string{}
+		}
+
+		s.Hosts = append(s.Hosts, host)
+	}
+
+	run, err := history.New(cfg).Get("last")
+	switch {
+	case err == nil:
+		s.LastRun = &Run{
+			ID:       run.ID,
+			Mode:     run.Mode(),
+			Status:   run.Status,
+			Start:    run.Start,
+			Duration: run.Duration,
+			// The executor error repeats the command and its environment, the first line is enough here.
+			Error: strings.SplitN(run.Error, "\n", 2)[0],
+			Limit: run.Limit,
+			Tags:  run.Tags,
+			Stats: run.Stats,
+		}
+		// A run started before the summary was generated has its commit, the config may not.
+		if s.PlaybookCommit == "" {
+			s.PlaybookCommit = run.PlaybookCommit
+		}
+	case !errors.Is(err, history.ErrNotFound):
+		return Summary{}, err
+	}
+
+	return s, nil
+}
+
+// Render returns the summary in one of Formats.
+func (s Summary) Render(format string) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return s.execute(template.New("").Funcs(funcs).Parse(tsummary.Markdown))
+	case FormatHTML:
+		tpl, err := htmltemplate.New("").Funcs(htmltemplate.FuncMap(funcs)).Parse(tsummary.HTML)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := bytes.Buffer{}
+		if err = tpl.Execute(&buf, s); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case FormatJSON:
+		return json.MarshalIndent(s, "", "  ")
+	}
+
+	return nil, fmt.Errorf("summary: unknown format %q, use one of: %s", format, strings.Join(Formats, ", "))
+}
+
+func (s Summary) execute(tpl *template.Template, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	if err = tpl.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	"date": func(t time.Time) string {
+		return t.Format(time.DateTime)
+	},
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Second).String()
+	},
+	"yesno": func(b bool) string {
+		if b {
+			return "yes"
+		}
+
+		return "no"
+	},
+}
+
+// WriteFile writes the rendered summary to path, readable by the user only. A summary exported before
+// gets its mode narrowed too.
+func WriteFile(path string, content []byte) error {
+	if err := os.WriteFile(path, content, fileMode); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, fileMode)
+}
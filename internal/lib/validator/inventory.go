@@ -0,0 +1,197 @@
+package validator
+
+import (
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/yamldoc"
+	"gopkg.in/yaml.v3"
+)
+
+// groupKeys are the keys of a group of the inventory.
+var groupKeys = []string{"hosts", "vars", "children"}
+
+// inventoryHost is a host of the inventory with the nodes of every group it is listed in.
+type inventoryHost struct {
+	name   *yaml.Node   // Where the host is first listed
+	values []*yaml.Node // Values of the host in every group, null when it is only listed
+}
+
+// inventoryWalk is what checkGroup collects from the groups of the inventory.
+type inventoryWalk struct {
+	names []string
+	hosts map[string]*inventoryHost
+	vars  map[string][]*yaml.Node // Variables of every group by name
+}
+
+// ValidateInventory checks the Ansible inventory file.
+func ValidateInventory(path string) (Problems, error) {
+	root, problems, err := parse(path)
+	if err != nil || problems != nil {
+		return problems, err
+	}
+
+	c := &problemCollector{path: path}
+	if !c.checkMapping(root, "") {
+		return c.problems, nil
+	}
+
+	all := yamldoc.MappingValue(root, "all")
+	if all == nil {
+		c.errorf(root, "all", "the inventory must define the all group")
+		return c.problems, nil
+	}
+
+	if !c.checkMapping(all, "all") {
+		return c.problems, nil
+	}
+
+	w := &inventoryWalk{hosts: make(map[string]*inventoryHost), vars: make(map[string][]*yaml.Node)}
+	c.checkGroup(w, "all", all, "all", nil)
+	if len(w.names) == 0 {
+		c.errorf(all, "all.hosts", "no hosts defined")
+		return c.problems, nil
+	}
+
+	// The values a host gets from its groups, a wrong type is reported where it is set.
+	var inventory config.Inventory
+	_ = root.Decode(&inventory)
+	resolved := inventory.Hosts()
+
+	services := make([]hostServices, 0, len(w.names))
+	for _, name := range w.names {
+		h := w.hosts[name]
+		if resolved[name].AnsibleHost == "" {
+			c.errorf(h.name, name, "ansible_host is not set")
+		}
+
+		services = append(services, hostServices{name: name, node: w.servicesNode(name, resolved[name].Groups)})
+	}
+
+	hosts := yamldoc.MappingValue(all, "hosts")
+	if hosts == nil {
+		hosts = all
+	}
+	c.checkPlacement(hosts, services)
+
+	return c.problems, nil
+}
+
+// checkGroup checks a group and its children and collects their hosts and variables. A group listed again
+// inside itself is reported, Ansible refuses it.
+func (c *problemCollector) checkGroup(w *inventoryWalk, name string, group *yaml.Node, key string, path []string) {
+	for _, p := range path {
+		if p == name {
+			c.errorf(group, key, "group %s is its own ancestor", name)
+			return
+		}
+	}
+
+	if group.Kind == yaml.ScalarNode && group.ShortTag() == "!!null" {
+		return
+	}
+	if !c.checkMapping(group, key) {
+		return
+	}
+
+	for i := 0; i+1 < len(group.Content); i += 2 {
+		k, v := group.Content[i], group.Content[i+1]
+		c.checkUnknownKey(k, key+"."+k.Value, groupKeys)
+		if v.Kind == yaml.ScalarNode && v.ShortTag() == "!!null" {
+			continue
+		}
+
+		switch k.Value {
+		case "hosts":
+			if !c.checkMapping(v, key+".hosts") {
+				continue
+			}
+
+			for j := 0; j+1 < len(v.Content); j += 2 {
+				c.checkInventoryHost(w, v.Content[j], v.Content[j+1])
+			}
+		case "vars":
+			if !c.checkMapping(v, key+".vars") {
+				continue
+			}
+
+			w.vars[name] = append(w.vars[name], v)
+			c.checkHostVars(v, key+".vars")
+		case "children":
+			if !c.checkMapping(v, key+".children") {
+				continue
+			}
+
+			for j := 0; j+1 < len(v.Content); j += 2 {
+				child := v.Content[j].Value
+				c.checkGroup(w, child, v.Content[j+1], key+".children."+child, append(path, name))
+			}
+		}
+	}
+}
+
+func (c *problemCollector) checkInventoryHost(w *inventoryWalk, name, host *yaml.Node) {
+	h := w.hosts[name.Value]
+	if h == nil {
+		h = &inventoryHost{name: name}
+		w.hosts[name.Value] = h
+		w.names = append(w.names, name.Value)
+	}
+	h.values = append(h.values, host)
+
+	// A host listed without values gets them from another group or from its groups.
+	if host.Kind == yaml.ScalarNode && host.ShortTag() == "!!null" {
+		return
+	}
+	if !c.checkMapping(host, name.Value) {
+		return
+	}
+
+	for i := 0; i+1 < len(host.Content); i += 2 {
+		c.checkUnknownKey(host.Content[i], name.Value+"."+host.Content[i].Value, vars.HostKeys)
+	}
+	c.checkHostVars(host, name.Value)
+}
+
+// checkHostVars checks the values of the host keys set on a host or in the variables of a group.
+func (c *problemCollector) checkHostVars(node *yaml.Node, prefix string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		key := prefix + "." + k.Value
+
+		switch k.Value {
+		case "ansible_host":
+			c.checkHost(v, key)
+		case "ansible_port":
+			c.checkPort(v, key)
+		case "webitel_services":
+			c.checkStringList(v, key)
+		}
+	}
+}
+
+// servicesNode returns the webitel_services node Ansible uses for the host: the last one set on the host,
+// else the one of its most specific group. groups are the groups of the host, the most specific last.
+func (w *inventoryWalk) servicesNode(name string, groups []string) *yaml.Node {
+	values := w.hosts[name].values
+	for i := len(values) - 1; i >= 0; i-- {
+		if s := yamldoc.MappingValue(values[i], "webitel_services"); s != nil {
+			return s
+		}
+	}
+
+	for i := len(groups) - 1; i >= -1; i-- {
+		group := "all"
+		if i >= 0 {
+			group = groups[i]
+		}
+
+		nodes := w.vars[group]
+		for j := len(nodes) - 1; j >= 0; j-- {
+			if s := yamldoc.MappingValue(nodes[j], "webitel_services"); s != nil {
+				return s
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package validator
+
+import (
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// placement is a service listed in webitel_services of a host.
+type placement struct {
+	host string
+	node *yaml.Node
+}
+
+// hostServices is the webitel_services node of a host, nil when the host has no services.
+type hostServices struct {
+	name string
+	node *yaml.Node
+}
+
+// checkPlacement checks the services of all hosts as a whole: every required service is placed,
+// singletons are placed once, names are known, and services on one host go together and don't conflict.
+func (c *problemCollector) checkPlacement(hosts *yaml.Node, services []hostServices) {
+	placements := make(map[string][]placement)
+	// Services set in the variables of a group are checked once, every host of the group gets them.
+	checked := make(map[*yaml.Node]bool)
+	for _, h := range services {
+		if h.node == nil || h.node.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		key := h.name + ".webitel_services"
+		onHost := make(map[string]*yaml.Node, len(h.node.Content))
+		for _, item := range h.node.Content {
+			if item.Kind != yaml.ScalarNode {
+				continue
+			}
+
+			if onHost[item.Value] != nil {
+				if !checked[h.node] {
+					c.warnf(item, key, "%s is listed twice", item.Value)
+				}
+				continue
+			}
+
+			onHost[item.Value] = item
+			if !checked[h.node] {
+				c.checkService(item, key)
+			}
+			if oneOf(item.Value, vars.WebitelServices) == nil {
+				placements[item.Value] = append(placements[item.Value], placement{host: h.name, node: item})
+			}
+		}
+
+		if !checked[h.node] {
+			c.checkHostServices(h.node, onHost, key)
+			if len(services) > 1 {
+				c.checkConflicts(onHost, key)
+			}
+		}
+		checked[h.node] = true
+	}
+
+	for _, service := range vars.RequiredServices {
+		if len(placements[service]) == 0 {
+			c.errorf(hosts, "all.hosts", "%s is required but not placed on any host", service)
+		}
+	}
+
+	for _, service := range vars.SingletonServices {
+		if p := placements[service]; len(p) > 1 {
+			// Report the extra placements, the first one is where the service is expected.
+			for _, extra := range p[1:] {
+				c.errorf(extra.node, extra.host+".webitel_services", "%s can be placed on one host only, it is already on %s",
+					service, p[0].host)
+			}
+		}
+	}
+}
+
+// checkService reports a service that the playbook doesn't install. A name close to a known one is reported
+// as an error because it is most likely a typo and the intended service is not deployed, any other name is a warning.
+func (c *problemCollector) checkService(node *yaml.Node, key string) bool {
+	for _, s := range vars.WebitelServices {
+		if node.Value == s {
+			return true
+		}
+	}
+
+	if suggestion := closest(node.Value, vars.WebitelServices); suggestion != "" {
+		c.errorf(node, key, "unknown service %q, did you mean %q?", node.Value, suggestion)
+		return false
+	}
+
+	c.warnf(node, key, "unknown service %q, the playbook skips it", node.Value)
+
+	return false
+}
+
+// checkHostServices reports services of one host that are missing their dependencies.
+func (c *problemCollector) checkHostServices(services *yaml.Node, onHost map[string]*yaml.Node, key string) {
+	for _, node := range services.Content {
+		service := node.Value
+		if onHost[service] != node {
+			continue
+		}
+
+		missing := make([]string, 0)
+		for _, dependency := range vars.ServiceDependencies[service] {
+			if onHost[dependency] == nil {
+				missing = append(missing, dependency)
+			}
+		}
+
+		if len(missing) > 0 {
+			c.errorf(node, key, "%s requires %s on the same host", service, strings.Join(missing, ", "))
+		}
+	}
+}
+
+// checkConflicts reports the pairs of vars.ServiceConflicts placed on one host, at the second service of the pair.
+func (c *problemCollector) checkConflicts(onHost map[string]*yaml.Node, key string) {
+	for _, pair := range vars.ServiceConflicts {
+		if onHost[pair[0]] != nil && onHost[pair[1]] != nil {
+			c.errorf(onHost[pair[1]], key, "%s conflicts with %s on the same host", pair[1], pair[0])
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package validator
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"gopkg.in/yaml.v3"
+	"net"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a Problem.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	return []string{
+		"error",
+		"warning",
+	}[s]
+}
+
+// Problem is a single validation failure found in a config file.
+type Problem struct {
+	File     string
+	Line     int
+	Column   int
+	Key      string
+	Message  string
+	Severity Severity
+}
+
+// String returns the problem in the file:line:column format.
+func (p Problem) String() string {
+	msg := p.Message
+	if p.Key != "" {
+		msg = fmt.Sprintf("%s: %s", p.Key, p.Message)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.File, p.Line, p.Column, p.Severity, msg)
+}
+
+// Problems is a list of validation failures.
+type Problems []Problem
+
+// Errors returns the number of problems with SeverityError.
+func (p Problems) Errors() int {
+	n := 0
+	for _, problem := range p {
+		if problem.Severity == SeverityError {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Warnings returns the number of problems with SeverityWarning.
+func (p Problems) Warnings() int {
+	return len(p) - p.Errors()
+}
+
+// HasErrors reports whether at least one problem is an error.
+func (p Problems) HasErrors() bool {
+	return p.Errors() > 0
+}
+
+var (
+	hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	yamlLineRegexp = regexp.MustCompile(`line (\d+)`)
+)
+
+// Validate checks both the variables and the inventory files of the config.
+func Validate(cfg config.Config) (Problems, error) {
+	problems, err := ValidateConfigFile(cfg, config.VarsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	inventoryProblems, err := ValidateConfigFile(cfg, config.InventoryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(problems, inventoryProblems...), nil
+}
+
+// ValidateConfigFile checks one of the config files, configFileType is config.VarsConfig or config.InventoryConfig.
+func ValidateConfigFile(cfg config.Config, configFileType int) (Problems, error) {
+	path := cfg.ConfigFiles[configFileType]
+	switch configFileType {
+	case config.VarsConfig:
+		return ValidateVars(path)
+	case config.InventoryConfig:
+		return ValidateInventory(path)
+	}
+
+	return nil, fmt.Errorf("unknown config file type: %d", configFileType)
+}
+
+// parse reads a YAML file into a document node. A syntax error is returned as a Problem.
+func parse(path string) (*yaml.Node, Problems, error) {
+	content, err := file.ReadFileContent(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal([]byte(content), &doc); err != nil {
+		line := 0
+		if m := yamlLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+
+		return nil, Problems{{
+			File:     path,
+			Line:     line,
+			Column:   1,
+			Message:  strings.TrimPrefix(err.Error(), "yaml: "),
+			Severity: SeverityError,
+		}}, nil
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, Problems{{
+			File:     path,
+			Line:     1,
+			Column:   1,
+			Message:  "file is empty",
+			Severity: SeverityError,
+		}}, nil
+	}
+
+	return doc.Content[0], nil, nil
+}
+
+// problemCollector accumulates problems found in one file.
+type problemCollector struct {
+	path     string
+	problems Problems
+}
+
+func (c *problemCollector) add(node *yaml.Node, key string, severity Severity, format string, args ...any) {
+	c.problems = append(c.problems, Problem{
+		File:     c.path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Key:      key,
+		Message:  fmt.Sprintf(format, args...),
+		Severity: severity,
+	})
+}
+
+func (c *problemCollector) errorf(node *yaml.Node, key string, format string, args ...any) {
+	c.add(node, key, SeverityError, format, args...)
+}
+
+func (c *problemCollector) warnf(node *yaml.Node, key string, format string, args ...any) {
+	c.add(node, key, SeverityWarning, format, args...)
+}
+
+// checkMapping reports a problem when the node is not a mapping.
+func (c *problemCollector) checkMapping(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.MappingNode {
+		c.errorf(node, key, "expected a mapping")
+		return false
+	}
+
+	return true
+}
+
+// checkUnknownKey reports a key that is not in known. A key close to a known one is
+// reported as an error because it is most likely a typo, any other key is a warning.
+func (c *problemCollector) checkUnknownKey(node *yaml.Node, key string, known []string) {
+	for _, k := range known {
+		if node.Value == k {
+			return
+		}
+	}
+
+	if suggestion := closest(node.Value, known); suggestion != "" {
+		c.errorf(node, key, "unknown key, did you mean %q?", suggestion)
+		return
+	}
+
+	c.warnf(node, key, "unknown key")
+}
+
+func (c *problemCollector) checkBool(node *yaml.Node, key string) {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!bool" {
+		c.errorf(node, key, "expected true or false, got %q", node.Value)
+	}
+}
+
+func (c *problemCollector) checkPort(node *yaml.Node, key string) {
+	port, err := strconv.Atoi(node.Value)
+	if node.Kind != yaml.ScalarNode || err != nil || port < 1 || port > 65535 {
+		c.errorf(node, key, "expected a port number between 1 and 65535, got %q", node.Value)
+	}
+}
+
+func (c *problemCollector) checkString(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() == "!!null" {
+		c.errorf(node, key, "expected a string")
+		return false
+	}
+
+	return true
+}
+
+func (c *problemCollector) checkStringList(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.SequenceNode {
+		c.errorf(node, key, "expected a list of strings")
+		return false
+	}
+
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			c.errorf(item, key, "expected a string")
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *problemCollector) checkHost(node *yaml.Node, key string) {
+	if !c.checkString(node, key) {
+		return
+	}
+
+	if !IsHost(node.Value) {
+		c.errorf(node, key, "%q is neither an IP address nor a hostname", node.Value)
+	}
+}
+
+// oneOf returns an error when value is not one of allowed.
+func oneOf(value string, allowed []string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q is not allowed, use one of: %s", value, strings.Join(allowed, ", "))
+}
+
+// IsHost reports whether s is an IP address or an RFC 1123 hostname.
+func IsHost(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+
+	return len(s) <= 253 && hostnameRegexp.MatchString(s)
+}
+
+// IsEmail reports whether s is a bare email address.
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return false
+	}
+
+	return addr.Address == s
+}
+
+// closest returns the known key within an edit distance of two from s, if any.
+func closest(s string, known []string) string {
+	best, bestDistance := "", 3
+	for _, k := range known {
+		if d := distance(s, k); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+
+	return best
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
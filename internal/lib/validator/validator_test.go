@@ -0,0 +1,165 @@
+package validator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// check runs validate on the file in testdata and compares the problems in the file:line:column format.
+func check(t *testing.T, validate func(path string) (Problems, error), name string, want []string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	problems, err := validate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(problems))
+	for _, p := range problems {
+		got = append(got, strings.TrimPrefix(p.String(), path+":"))
+	}
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems of %s:\n%s\nwant:\n%s", name, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateVars(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want []string
+	}{
+		{
+			name: "valid",
+			file: "vars_valid.yml",
+		},
+		{
+			name: "invalid values",
+			file: "vars.yml",
+			want: []string{
+				`3:15: error: ansible_port: expected a port number between 1 and 65535, got "70000"`,
+				`4:18: error: webitel_version: expected a version in the YY.MM format, got "23.7"`,
+				`6:17: error: rtpengine_mode: "cluster" is not allowed, use one of: global, local`,
+				`7:20: error: opensips_fail2ban: expected true or false, got "maybe"`,
+				`8:18: error: nginx_site_name: "-bad-" is neither an IP address nor a hostname`,
+				`9:21: error: nginx_mail_address: "admin" is not a valid email address`,
+				`11:1: error: grafana_basic_dashboards_languag: unknown key, did you mean "grafana_basic_dashboards_language"?`,
+				`12:14: error: locales_gen: expected a list of strings`,
+				`13:1: warning: custom_var: unknown key`,
+				`14:1: error: rtp_engine_mode: the key is renamed to "rtpengine_mode"`,
+			},
+		},
+		{
+			name: "syntax error",
+			file: "syntax.yml",
+			want: []string{`3:1: error: line 3: mapping values are not allowed in this context`},
+		},
+		{
+			name: "empty",
+			file: "empty.yml",
+			want: []string{`1:1: error: file is empty`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, ValidateVars, tt.file, tt.want)
+		})
+	}
+}
+
+func TestValidateInventory(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want []string
+	}{
+		{
+			name: "valid",
+			file: "inventory_valid.yml",
+		},
+		{
+			name: "invalid hosts",
+			file: "inventory.yml",
+			want: []string{
+				`5:7: error: node1.ansible_prot: unknown key, did you mean "ansible_port"?`,
+				`8:21: error: node2.ansible_host: "not a host" is neither an IP address nor a hostname`,
+				`9:21: error: node2.ansible_port: expected a port number between 1 and 65535, got "0"`,
+				`21:3: warning: all.colour: unknown key`,
+				`13:5: error: node3: ansible_host is not set`,
+				`6:87: error: node1.webitel_services: freeswitch conflicts with opensips on the same host`,
+				`12:11: error: node2.webitel_services: unknown service "nginxx", did you mean "nginx"?`,
+				`14:35: warning: node3.webitel_services: grafana is listed twice`,
+				`11:11: error: node2.webitel_services: rabbitmq can be placed on one host only, it is already on node1`,
+			},
+		},
+		{
+			name: "conflicts",
+			file: "inventory_conflicts.yml",
+			want: []string{
+				`11:11: error: node2.webitel_services: freeswitch conflicts with opensips on the same host`,
+				`11:11: error: node2.webitel_services: freeswitch conflicts with rtpengine on the same host`,
+				`19:28: error: node3.webitel_services: freeswitch conflicts with opensips on the same host`,
+			},
+		},
+		{
+			name: "all in one",
+			file: "inventory_all_in_one.yml",
+		},
+		{
+			name: "groups",
+			file: "inventory_groups.yml",
+			want: []string{
+				`9:11: error: all.children.web.children.web: group web is its own ancestor`,
+				`2:3: error: all.hosts: postgresql is required but not placed on any host`,
+				`2:3: error: all.hosts: rabbitmq is required but not placed on any host`,
+				`2:3: error: all.hosts: consul is required but not placed on any host`,
+				`2:3: error: all.hosts: webitel_core is required but not placed on any host`,
+				`2:3: error: all.hosts: freeswitch is required but not placed on any host`,
+				`2:3: error: all.hosts: opensips is required but not placed on any host`,
+				`2:3: error: all.hosts: nginx is required but not placed on any host`,
+			},
+		},
+		{
+			name: "no all group",
+			file: "inventory_no_all.yml",
+			want: []string{`1:1: error: all: the inventory must define the all group`},
+		},
+		{
+			name: "empty",
+			file: "empty.yml",
+			want: []string{`1:1: error: file is empty`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, ValidateInventory, tt.file, tt.want)
+		})
+	}
+}
+
+func TestIsHost(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"10.0.0.1", true},
+		{"::1", true},
+		{"node1", true},
+		{"node1.example.com", true},
+		{"-node1", false},
+		{"node_1", false},
+		{"not a host", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsHost(tt.s); got != tt.want {
+			t.Errorf("IsHost(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
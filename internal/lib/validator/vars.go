@@ -0,0 +1,111 @@
+package validator
+
+import (
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var webitelVersionRegexp = regexp.MustCompile(`^\d{2}\.\d{2}$`)
+
+// ValidateVars checks the Ansible variables file.
+func ValidateVars(path string) (Problems, error) {
+	root, problems, err := parse(path)
+	if err != nil || problems != nil {
+		return problems, err
+	}
+
+	c := &problemCollector{path: path}
+	if !c.checkMapping(root, "") {
+		return c.problems, nil
+	}
+
+	kinds := yamlKinds(reflect.TypeOf(config.Variables{}))
+	known := append(keys(kinds), vars.ExtraVarsKeys...)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		if name, ok := vars.RenamedKeys[k.Value]; ok {
+			c.errorf(k, k.Value, "the key is renamed to %q", name)
+			continue
+		}
+		c.checkUnknownKey(k, k.Value, known)
+
+		switch kinds[k.Value] {
+		case reflect.Bool:
+			c.checkBool(v, k.Value)
+		case reflect.Int:
+			c.checkPort(v, k.Value)
+		case reflect.Slice:
+			c.checkStringList(v, k.Value)
+		case reflect.String:
+			c.checkVarsString(v, k.Value)
+		}
+	}
+
+	return c.problems, nil
+}
+
+func (c *problemCollector) checkVarsString(node *yaml.Node, key string) {
+	if !c.checkString(node, key) {
+		return
+	}
+
+	if err := ValidateVar(key, node.Value); err != nil {
+		c.errorf(node, key, "%s", err)
+	}
+}
+
+// ValidateVar checks the string value of the variable key, e.g. a value typed in a form.
+func ValidateVar(key, value string) error {
+	// Empty values leave the defaults of the playbook in place.
+	if value == "" {
+		return nil
+	}
+
+	switch key {
+	case "nginx_mail_address":
+		if !IsEmail(value) {
+			return fmt.Errorf("%q is not a valid email address", value)
+		}
+	case "nginx_site_name":
+		if !IsHost(value) {
+			return fmt.Errorf("%q is neither an IP address nor a hostname", value)
+		}
+	case "rtpengine_mode":
+		return oneOf(value, vars.RTPEngineModes)
+	case "webitel_version":
+		if !webitelVersionRegexp.MatchString(value) {
+			return fmt.Errorf("expected a version in the YY.MM format, got %q", value)
+		}
+	}
+
+	return nil
+}
+
+// yamlKinds maps yaml tags of the struct fields to their kinds.
+func yamlKinds(t reflect.Type) map[string]reflect.Kind {
+	kinds := make(map[string]reflect.Kind, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		kinds[name] = field.Type.Kind()
+	}
+
+	return kinds
+}
+
+func keys(kinds map[string]reflect.Kind) []string {
+	k := make([]string, 0, len(kinds))
+	for name := range kinds {
+		k = append(k, name)
+	}
+
+	return k
+}
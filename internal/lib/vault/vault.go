@@ -0,0 +1,169 @@
+// Package vault encrypts and decrypts values in the Ansible Vault 1.1 AES256 format,
+// the one produced by ansible-vault encrypt_string.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/pbkdf2"
+	"strings"
+)
+
+const (
+	// Header is the first line of an encrypted value.
+	Header = "$ANSIBLE_VAULT;1.1;AES256"
+	// Tag marks an encrypted scalar in YAML files.
+	Tag = "!vault"
+
+	saltLength = 32
+	keyLength  = 32
+	ivLength   = 16
+	iterations = 10000
+	lineLength = 80
+)
+
+var (
+	ErrInvalidFormat = errors.New("vault: invalid format")
+	ErrWrongPassword = errors.New("vault: wrong password or corrupted value")
+	// ErrPasswordRequired is returned when a config file contains encrypted values but no vault password is given.
+	ErrPasswordRequired = errors.New("vault: config contains encrypted values, " +
+		"specify --vault-password-file or --ask-vault-pass")
+)
+
+// IsEncrypted reports whether value is an Ansible Vault encrypted value.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "$ANSIBLE_VAULT;")
+}
+
+// Encrypt encrypts plaintext with password. The result starts with Header and is wrapped
+// at 80 characters as ansible-vault does.
+func Encrypt(plaintext, password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	cipherKey, hmacKey, iv := deriveKeys(password, salt)
+	block, err := aes.NewCipher(cipherKey)
+	if err != nil {
+		return "", err
+	}
+
+	padded := pad([]byte(plaintext), aes.BlockSize)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, padded)
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(ciphertext)
+
+	body := strings.Join([]string{
+		hex.EncodeToString(salt),
+		hex.EncodeToString(mac.Sum(nil)),
+		hex.EncodeToString(ciphertext),
+	}, "\n")
+	encoded := hex.EncodeToString([]byte(body))
+
+	lines := []string{Header}
+	for len(encoded) > lineLength {
+		lines = append(lines, encoded[:lineLength])
+		encoded = encoded[lineLength:]
+	}
+	lines = append(lines, encoded)
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt or by ansible-vault.
+func Decrypt(value, password string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(value), "\n")
+	header := strings.Split(strings.TrimSpace(lines[0]), ";")
+	if len(header) < 3 || header[0] != "$ANSIBLE_VAULT" {
+		return "", ErrInvalidFormat
+	}
+	if strings.TrimSpace(header[2]) != "AES256" {
+		return "", fmt.Errorf("vault: unsupported cipher %q", header[2])
+	}
+
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	body, err := hex.DecodeString(strings.Join(lines[1:], ""))
+	if err != nil {
+		return "", ErrInvalidFormat
+	}
+
+	parts := strings.Split(string(body), "\n")
+	if len(parts) != 3 {
+		return "", ErrInvalidFormat
+	}
+
+	salt, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidFormat
+	}
+	expectedMAC, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidFormat
+	}
+	ciphertext, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidFormat
+	}
+
+	cipherKey, hmacKey, iv := deriveKeys(password, salt)
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(ciphertext)
+	if !hmac.Equal(mac.Sum(nil), expectedMAC) {
+		return "", ErrWrongPassword
+	}
+
+	block, err := aes.NewCipher(cipherKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, iv).XORKeyStream(plaintext, ciphertext)
+
+	plaintext, err = unpad(plaintext, aes.BlockSize)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// deriveKeys splits the PBKDF2 output into the AES key, the HMAC key and the CTR initial counter.
+func deriveKeys(password string, salt []byte) ([]byte, []byte, []byte) {
+	key := pbkdf2.Key([]byte(password), salt, iterations, 2*keyLength+ivLength, sha256.New)
+
+	return key[:keyLength], key[keyLength : 2*keyLength], key[2*keyLength:]
+}
+
+// pad applies PKCS#7 padding, ansible-vault pads the plaintext although CTR mode does not need it.
+func pad(data []byte, size int) []byte {
+	n := size - len(data)%size
+
+	return append(data, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte, size int) ([]byte, error) {
+	if len(data) == 0 || len(data)%size != 0 {
+		return nil, ErrInvalidFormat
+	}
+
+	n := int(data[len(data)-1])
+	if n == 0 || n > size || n > len(data) || !bytes.Equal(data[len(data)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, ErrInvalidFormat
+	}
+
+	return data[:len(data)-n], nil
+}
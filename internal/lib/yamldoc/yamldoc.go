@@ -0,0 +1,396 @@
+// Package yamldoc edits YAML documents through their nodes. The comments and the order of the keys are kept,
+// unlike decoding into a struct and encoding it back. A changed document is encoded again, which drops its blank
+// lines and indents it with two spaces, a document that is not changed is not written.
+package yamldoc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/kirychukyurii/wdeploy/internal/lib/file"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned when nothing is set at a path.
+var ErrNotFound = errors.New("yamldoc: not found")
+
+// Document is a parsed YAML document.
+type Document struct {
+	node *yaml.Node
+	// parsed is the encoding of the document as it was parsed, see Changed.
+	parsed []byte
+	// start tells whether the content starts with the --- marker, the encoder leaves it out.
+	start bool
+}
+
+// Parse parses the content into a document, the content must not be empty.
+func Parse(content []byte) (*Document, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, err
+	}
+
+	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
+		return nil, errors.New("document is empty")
+	}
+
+	d := &Document{node: &node, start: bytes.HasPrefix(bytes.TrimLeft(content, " \t\r\n"), []byte("---"))}
+	parsed, err := d.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	d.parsed = parsed
+
+	return d, nil
+}
+
+// Read parses the YAML file at path.
+func Read(path string) (*Document, error) {
+	content, err := file.ReadFileContent(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := Parse([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return d, nil
+}
+
+// Node returns the document node.
+func (d *Document) Node() *yaml.Node {
+	return d.node
+}
+
+// Root returns the top-level node of the document.
+func (d *Document) Root() *yaml.Node {
+	return d.node.Content[0]
+}
+
+// RootMapping returns the top-level mapping, an empty document becomes an empty mapping.
+func (d *Document) RootMapping() (*yaml.Node, error) {
+	root := d.Root()
+	if isNull(root) {
+		root.Kind, root.Tag, root.Value = yaml.MappingNode, "!!map", ""
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping")
+	}
+
+	return root, nil
+}
+
+// Decode decodes the document into v.
+func (d *Document) Decode(v any) error {
+	return d.node.Decode(v)
+}
+
+// Bytes encodes the document with an indent of two spaces.
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if d.start {
+		buf.WriteString("---\n")
+	}
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(d.node); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Changed reports whether the document encodes differently than it did when it was parsed.
+func (d *Document) Changed() bool {
+	content, err := d.Bytes()
+
+	return err != nil || !bytes.Equal(content, d.parsed)
+}
+
+// Write writes the document to the file at path. The file is left as it is when the document is not changed.
+func (d *Document) Write(path string) error {
+	content, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(content, d.parsed) {
+		return nil
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+// Get returns the node at the path, see SplitPath.
+func (d *Document) Get(path string) (*yaml.Node, error) {
+	keys, err := SplitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := d.Root()
+	for i, key := range keys {
+		if node = child(node, key); node == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, JoinPath(keys[:i+1]))
+		}
+	}
+
+	return node, nil
+}
+
+// Set sets the value at the path, see SetKey. Missing and null mappings on the way are created, an item
+// of a sequence is set by its index and the index after the last item appends one.
+func (d *Document) Set(path string, value any) error {
+	keys, err := SplitPath(path)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("the path is empty")
+	}
+
+	node := d.Root()
+	for i, key := range keys[:len(keys)-1] {
+		next := child(node, key)
+		if next == nil || isNull(next) {
+			if isNull(node) {
+				node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+			}
+			if node.Kind != yaml.MappingNode {
+				return fmt.Errorf("%s: expected a mapping", JoinPath(keys[:i]))
+			}
+
+			next = EnsureMapping(node, key)
+		}
+
+		node = next
+	}
+
+	key := keys[len(keys)-1]
+	if isNull(node) {
+		node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		return SetKey(node, key, value)
+	case yaml.SequenceNode:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index > len(node.Content) {
+			return fmt.Errorf("%s: expected an index from 0 to %d", JoinPath(keys), len(node.Content))
+		}
+
+		n, err := encode(value)
+		if err != nil {
+			return err
+		}
+		if index == len(node.Content) {
+			node.Content = append(node.Content, n)
+			return nil
+		}
+
+		replace(node.Content[index], n)
+
+		return nil
+	}
+
+	return fmt.Errorf("%s: expected a mapping or a sequence", JoinPath(keys[:len(keys)-1]))
+}
+
+// Delete removes the key or the item of a sequence at the path, it reports whether anything was removed.
+func (d *Document) Delete(path string) (bool, error) {
+	keys, err := SplitPath(path)
+	if err != nil {
+		return false, err
+	}
+	if len(keys) == 0 {
+		return false, errors.New("the path is empty")
+	}
+
+	parent := d.Root()
+	for _, key := range keys[:len(keys)-1] {
+		if parent = child(parent, key); parent == nil {
+			return false, nil
+		}
+	}
+
+	key := keys[len(keys)-1]
+	if parent.Kind == yaml.SequenceNode {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(parent.Content) {
+			return false, nil
+		}
+
+		parent.Content = append(parent.Content[:index], parent.Content[index+1:]...)
+
+		return true, nil
+	}
+
+	return DeleteKey(parent, key), nil
+}
+
+// SplitPath splits a path into keys. Keys are separated by dots, a key with dots is quoted:
+// all.hosts."node1.example.com".ansible_host. An item of a sequence is its index: webitel_services.0.
+func SplitPath(path string) ([]string, error) {
+	keys := make([]string, 0)
+	for i := 0; i < len(path); {
+		var key string
+		if path[i] == '"' {
+			end := strings.IndexByte(path[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("path %s: the quote is not closed", path)
+			}
+
+			key, i = path[i+1:i+1+end], i+end+2
+		} else {
+			end := strings.IndexByte(path[i:], '.')
+			if end < 0 {
+				end = len(path) - i
+			}
+
+			key, i = path[i:i+end], i+end
+		}
+
+		if key == "" {
+			return nil, fmt.Errorf("path %s: empty key", path)
+		}
+		keys = append(keys, key)
+
+		if i < len(path) {
+			if path[i] != '.' {
+				return nil, fmt.Errorf("path %s: expected a dot after the quoted key", path)
+			}
+			if i++; i == len(path) {
+				return nil, fmt.Errorf("path %s: empty key", path)
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// JoinPath joins keys into a path, quoting the keys with dots.
+func JoinPath(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = k
+		if strings.Contains(k, ".") {
+			quoted[i] = `"` + k + `"`
+		}
+	}
+
+	return strings.Join(quoted, ".")
+}
+
+// MappingValue returns the value of key in the mapping node or nil if it is not set.
+func MappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// SetKey sets key of the mapping to value, a missing key is appended. The comments of the replaced value and
+// the style of a replaced value of the same type are kept. A *yaml.Node value is used as is.
+func SetKey(mapping *yaml.Node, key string, value any) error {
+	n, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	old := MappingValue(mapping, key)
+	if old == nil {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, n)
+		return nil
+	}
+
+	replace(old, n)
+
+	return nil
+}
+
+// DeleteKey removes key from the mapping, it reports whether the key was set.
+func DeleteKey(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnsureMapping returns the mapping value of key, a missing or null value is replaced with an empty mapping.
+func EnsureMapping(mapping *yaml.Node, key string) *yaml.Node {
+	v := MappingValue(mapping, key)
+	if v == nil {
+		v = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+	}
+
+	if isNull(v) {
+		v.Kind, v.Tag, v.Value = yaml.MappingNode, "!!map", ""
+	}
+
+	return v
+}
+
+// child returns the value of key in a mapping or the item with the index key in a sequence.
+func child(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return MappingValue(node, key)
+	case yaml.SequenceNode:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(node.Content) {
+			return nil
+		}
+
+		return node.Content[index]
+	case yaml.AliasNode:
+		return child(node.Alias, key)
+	}
+
+	return nil
+}
+
+func encode(value any) (*yaml.Node, error) {
+	if n, ok := value.(*yaml.Node); ok {
+		return n, nil
+	}
+
+	var n yaml.Node
+	if err := n.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// replace replaces the old node with n in place, keeping the comments of the old one and its style
+// when the value has the same type.
+func replace(old, n *yaml.Node) {
+	c := *n
+	c.HeadComment, c.LineComment, c.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	if old.Kind == c.Kind && old.ShortTag() == c.ShortTag() && (c.Kind == yaml.ScalarNode || len(c.Content) > 0) {
+		c.Style = old.Style
+	}
+	*old = c
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
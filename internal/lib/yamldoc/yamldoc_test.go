@@ -0,0 +1,270 @@
+package yamldoc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+		err  string
+	}{
+		{path: "", want: []string{}},
+		{path: "nginx_site_name", want: []string{"nginx_site_name"}},
+		{path: "all.hosts.node1.ansible_host", want: []string{"all", "hosts", "node1", "ansible_host"}},
+		{path: `all.hosts."node1.example.com".ansible_host`, want: []string{"all", "hosts", "node1.example.com", "ansible_host"}},
+		{path: `"node1.example.com"`, want: []string{"node1.example.com"}},
+		{path: "webitel_services.0", want: []string{"webitel_services", "0"}},
+		{path: "all.", err: "path all.: empty key"},
+		{path: "all..hosts", err: "path all..hosts: empty key"},
+		{path: `all.""`, err: `path all."": empty key`},
+		{path: `all."node1.example.com`, err: `path all."node1.example.com: the quote is not closed`},
+		{path: `all."node1"hosts`, err: `path all."node1"hosts: expected a dot after the quoted key`},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitPath(tt.path)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("SplitPath(%q) error = %v, want %s", tt.path, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitPath(%q) error = %v", tt.path, err)
+			continue
+		}
+
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("SplitPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if tt.path != "" && JoinPath(got) != tt.path {
+			t.Errorf("JoinPath(%q) = %s, want %s", got, JoinPath(got), tt.path)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		value   any
+		want    string
+		err     string
+	}{
+		{
+			name:    "replace keeping comments",
+			content: "# The site.\nnginx_site_name: old.example.com # public name\nnginx_letsencrypt: false\n",
+			path:    "nginx_site_name",
+			value:   "new.example.com",
+			want:    "# The site.\nnginx_site_name: new.example.com # public name\nnginx_letsencrypt: false\n",
+		},
+		{
+			name:    "keep the quotes",
+			content: "webitel_version: \"23.12\"\n",
+			path:    "webitel_version",
+			value:   "24.02",
+			want:    "webitel_version: \"24.02\"\n",
+		},
+		{
+			name:    "append a key",
+			content: "a: 1\n",
+			path:    "b",
+			value:   true,
+			want:    "a: 1\nb: true\n",
+		},
+		{
+			name:    "create the mappings",
+			content: "all:\n  hosts:\n",
+			path:    `all.hosts."node1.example.com".ansible_port`,
+			value:   2222,
+			want:    "all:\n  hosts:\n    node1.example.com:\n      ansible_port: 2222\n",
+		},
+		{
+			name:    "null document",
+			content: "# Variables.\n~\n",
+			path:    "nginx.site",
+			value:   "example.com",
+			want:    "# Variables.\nnginx:\n  site: example.com\n",
+		},
+		{
+			name:    "replace an item",
+			content: "locales_gen: [en_US.UTF-8, uk_UA.UTF-8]\n",
+			path:    "locales_gen.1",
+			value:   "de_DE.UTF-8",
+			want:    "locales_gen: [en_US.UTF-8, de_DE.UTF-8]\n",
+		},
+		{
+			name:    "append an item",
+			content: "webitel_services:\n  - nginx # the proxy\n",
+			path:    "webitel_services.1",
+			value:   "opensips",
+			want:    "webitel_services:\n  - nginx # the proxy\n  - opensips\n",
+		},
+		{
+			name:    "index out of the sequence",
+			content: "webitel_services: [nginx]\n",
+			path:    "webitel_services.2",
+			value:   "opensips",
+			err:     "webitel_services.2: expected an index from 0 to 1",
+		},
+		{
+			name:    "scalar on the way",
+			content: "nginx: proxy\n",
+			path:    "nginx.site.name",
+			value:   "example.com",
+			err:     "nginx: expected a mapping",
+		},
+		{
+			name:    "empty path",
+			content: "a: 1\n",
+			path:    "",
+			value:   1,
+			err:     "the path is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.Set(tt.path, tt.value)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("Set() error = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := d.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Set():\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		deleted bool
+		want    string
+	}{
+		{
+			name:    "key",
+			content: "# Variables.\na: 1\nb: 2 # second\nc: 3\n",
+			path:    "b",
+			deleted: true,
+			want:    "# Variables.\na: 1\nc: 3\n",
+		},
+		{
+			name:    "quoted key",
+			content: "all:\n  hosts:\n    node1.example.com:\n    node2:\n",
+			path:    `all.hosts."node1.example.com"`,
+			deleted: true,
+			want:    "all:\n  hosts:\n    node2:\n",
+		},
+		{
+			name:    "item",
+			content: "webitel_services: [nginx, opensips, rtpengine]\n",
+			path:    "webitel_services.1",
+			deleted: true,
+			want:    "webitel_services: [nginx, rtpengine]\n",
+		},
+		{
+			name:    "missing key",
+			content: "a: 1\n",
+			path:    "b.c",
+			want:    "a: 1\n",
+		},
+		{
+			name:    "missing item",
+			content: "webitel_services: [nginx]\n",
+			path:    "webitel_services.1",
+			want:    "webitel_services: [nginx]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deleted, err := d.Delete(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != tt.deleted {
+				t.Errorf("Delete() = %t, want %t", deleted, tt.deleted)
+			}
+			if d.Changed() != tt.deleted {
+				t.Errorf("Changed() = %t, want %t", d.Changed(), tt.deleted)
+			}
+
+			got, err := d.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Delete():\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	content := "---\n# Variables.\n\nnginx_site_name:    example.com\n\nlocales_gen:\n    - en_US.UTF-8\n"
+	path := filepath.Join(t.TempDir(), "vars.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Setting the value the key has changes nothing, the file keeps its blank lines and indentation.
+	if err = d.Set("nginx_site_name", "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if d.Changed() {
+		t.Error("Changed() = true after setting the same value")
+	}
+	if err = d.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != content {
+		t.Errorf("the file is rewritten:\n%s", got)
+	}
+
+	if err = d.Set("nginx_site_name", "webitel.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "---\n# Variables.\nnginx_site_name: webitel.example.com\nlocales_gen:\n  - en_US.UTF-8\n"
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("Write():\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package summary
+
+// Markdown is the summary.Summary template for handover tickets.
+var Markdown = `# Webitel v{{ .WebitelVersion }}
+
+Profile: {{ .Profile }}, generated {{ date .Generated }}
+
+Playbook: {{ .Playbook }}{{ with .PlaybookRef }} ({{ . }}){{ end }}{{ with .PlaybookCommit }}, commit {{ . }}{{ end }}
+
+## Hosts
+
+| Host | Address | User | Port | Services |
+|------|---------|------|------|----------|
+{{ range .Hosts -}}
+| {{ .Name }} | {{ .Address }} | {{ or .User "-" }} | {{ if .Port }}{{ .Port }}{{ else }}22{{ end }} | {{ join .Services ", " }} |
+{{ end }}
+## Settings
+
+| Setting | Value |
+|---------|-------|
+| Site | {{ or .Settings.NginxSiteName "-" }} |
+| Let's Encrypt | {{ yesno .Settings.NginxLetsencrypt }}{{ with .Settings.NginxMailAddress }} ({{ . }}){{ end }} |
+| Grafana | {{ yesno .Settings.GrafanaEnable }} |
+| Grafana basic dashboards | {{ yesno .Settings.GrafanaBasicDashboards }}{{ with .Settings.GrafanaBasicDashboardsLanguage }} ({{ . }}){{ end }} |
+| RTPEngine mode | {{ .Settings.RTPEngineMode }} |
+| OpenSIPS | {{ .Settings.OpensipsVersion }} |
+| OpenSIPS fail2ban | {{ yesno .Settings.OpensipsFail2ban }} |
+| Locales | {{ or (join .Settings.LocalesGen ", ") "-" }} |
+{{ with .LastRun }}
+## Last run
+
+| Run | Mode | Status | Started | Duration |
+|-----|------|--------|---------|----------|
+| {{ .ID }} | {{ .Mode }} | {{ .Status }} | {{ date .Start }} | {{ duration .Duration }} |
+{{ with .Limit }}
+Hosts: ` + "`{{ . }}`" + `
+{{ end }}{{ with .Tags }}
+Services: ` + "`{{ . }}`" + `
+{{ end }}{{ with .Error }}
+Error: ` + "`{{ . }}`" + `
+{{ end }}{{ with .Stats }}
+| Host | Ok | Changed | Failed | Unreachable | Skipped |
+|------|----|---------|--------|-------------|---------|
+{{ range $host, $st := . -}}
+| {{ $host }} | {{ $st.Ok }} | {{ $st.Changed }} | {{ $st.Failures }} | {{ $st.Unreachable }} | {{ $st.Skipped }} |
+{{ end }}{{ end }}{{ end }}`
+
+// HTML is the summary.Summary template as a standalone page.
+var HTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Webitel v{{ .WebitelVersion }} · {{ .Profile }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+th { background: #f3f3f3; }
+.succeeded { color: #2a7a2a; }
+.failed, .aborted { color: #b22; }
+</style>
+</head>
+<body>
+<h1>Webitel v{{ .WebitelVersion }}</h1>
+<p>Profile: {{ .Profile }}, generated {{ date .Generated }}</p>
+<p>Playbook: {{ .Playbook }}{{ with .PlaybookRef }} ({{ . }}){{ end }}{{ with .PlaybookCommit }}, commit {{ . }}{{ end }}</p>
+
+<h2>Hosts</h2>
+<table>
+<tr><th>Host</th><th>Address</th><th>User</th><th>Port</th><th>Services</th></tr>
+{{ range .Hosts -}}
+<tr><td>{{ .Name }}</td><td>{{ .Address }}</td><td>{{ or .User "-" }}</td><td>{{ if .Port }}{{ .Port }}{{ else }}22{{ end }}</td><td>{{ join .Services ", " }}</td></tr>
+{{ end -}}
+</table>
+
+<h2>Settings</h2>
+<table>
+<tr><th>Setting</th><th>Value</th></tr>
+<tr><td>Site</td><td>{{ or .Settings.NginxSiteName "-" }}</td></tr>
+<tr><td>Let's Encrypt</td><td>{{ yesno .Settings.NginxLetsencrypt }}{{ with .Settings.NginxMailAddress }} ({{ . }}){{ end }}</td></tr>
+<tr><td>Grafana</td><td>{{ yesno .Settings.GrafanaEnable }}</td></tr>
+<tr><td>Grafana basic dashboards</td><td>{{ yesno .Settings.GrafanaBasicDashboards }}{{ with .Settings.GrafanaBasicDashboardsLanguage }} ({{ . }}){{ end }}</td></tr>
+<tr><td>RTPEngine mode</td><td>{{ .Settings.RTPEngineMode }}</td></tr>
+<tr><td>OpenSIPS</td><td>{{ .Settings.OpensipsVersion }}</td></tr>
+<tr><td>OpenSIPS fail2ban</td><td>{{ yesno .Settings.OpensipsFail2ban }}</td></tr>
+<tr><td>Locales</td><td>{{ or (join .Settings.LocalesGen ", ") "-" }}</td></tr>
+</table>
+{{ with .LastRun }}
+<h2>Last run</h2>
+<table>
+<tr><th>Run</th><th>Mode</th><th>Status</th><th>Started</th><th>Duration</th></tr>
+<tr><td>{{ .ID }}</td><td>{{ .Mode }}</td><td class="{{ .Status }}">{{ .Status }}</td><td>{{ date .Start }}</td><td>{{ duration .Duration }}</td></tr>
+</table>
+{{ with .Limit }}<p>Hosts: <code>{{ . }}</code></p>{{ end }}
+{{ with .Tags }}<p>Services: <code>{{ . }}</code></p>{{ end }}
+{{ with .Error }}<p>Error: <code>{{ . }}</code></p>{{ end }}
+{{ with .Stats -}}
+<table>
+<tr><th>Host</th><th>Ok</th><th>Changed</th><th>Failed</th><th>Unreachable</th><th>Skipped</th></tr>
+{{ range $host, $st := . -}}
+<tr><td>{{ $host }}</td><td>{{ $st.Ok }}</td><td>{{ $st.Changed }}</td><td>{{ $st.Failures }}</td><td>{{ $st.Unreachable }}</td><td>{{ $st.Skipped }}</td></tr>
+{{ end -}}
+</table>
+{{ end -}}
+{{ end -}}
+</body>
+</html>
+`
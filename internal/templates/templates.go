@@ -0,0 +1,183 @@
+// Package templates is the registry of the templates the config files and the plan are generated from. The
+// built-in templates are embedded, templates in Dir replace the built-in ones of the same kind and name or add
+// new ones, e.g. Dir/inventory/cluster.yml is the inventory template cluster. A template may start with a
+// comment describing it: {{- /* Two hosts behind a load balancer */ -}}.
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/adrg/xdg"
+	"github.com/kirychukyurii/wdeploy/internal/constants"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed builtin
+var builtin embed.FS
+
+// Kind is what a template generates, it is the directory of the template.
+type Kind string
+
+const (
+	Vars      Kind = "vars"      // The variables file, executed with the config
+	Inventory Kind = "inventory" // The inventory file, executed with the config, see --deploy-type
+	View      Kind = "view"      // The plan of a deploy, executed with a plan.Plan
+)
+
+// Kinds are the kinds of the templates in the order they are listed.
+var Kinds = []Kind{Vars, Inventory, View}
+
+// ErrNotFound is returned for a template that is neither built in nor in Dir.
+var ErrNotFound = errors.New("templates: not found")
+
+var descriptionRegexp = regexp.MustCompile(`^\{\{-?\s*/\*((?s).*?)\*/\s*-?}}`)
+
+// Template is a template of the registry.
+type Template struct {
+	Kind        Kind
+	Name        string
+	Description string
+	Path        string // File of a user template, empty for a built-in one
+	Overrides   bool   // Whether the user template replaces a built-in one
+	Content     string
+}
+
+// Source describes where the template comes from.
+func (t Template) Source() string {
+	switch {
+	case t.Path == "":
+		return "built-in"
+	case t.Overrides:
+		return fmt.Sprintf("%s (overrides built-in)", t.Path)
+	}
+
+	return t.Path
+}
+
+// Dir returns the directory with the user templates.
+func Dir() string {
+	return filepath.Join(xdg.ConfigHome, constants.AppName, "templates")
+}
+
+// List returns the templates of every kind sorted by name, user templates replace the built-in ones.
+func List() ([]Template, error) {
+	templates := make([]Template, 0)
+	for _, kind := range Kinds {
+		t, err := list(kind)
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, t...)
+	}
+
+	return templates, nil
+}
+
+// Names returns the names of the templates of the kind, a user directory that can't be read is skipped.
+func Names(kind Kind) []string {
+	templates, err := list(kind)
+	if err != nil {
+		templates, _ = read(builtin, "builtin", kind, "")
+	}
+
+	names := make([]string, 0, len(templates))
+	for _, t := range templates {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
+
+// Get returns the template of the kind with the name.
+func Get(kind Kind, name string) (Template, error) {
+	templates, err := list(kind)
+	if err != nil {
+		return Template{}, err
+	}
+
+	names := make([]string, 0, len(templates))
+	for _, t := range templates {
+		if t.Name == name {
+			return t, nil
+		}
+
+		names = append(names, t.Name)
+	}
+
+	return Template{}, fmt.Errorf("%w: %s template %q, expected one of: %s", ErrNotFound, kind, name,
+		strings.Join(names, ", "))
+}
+
+func list(kind Kind) ([]Template, error) {
+	templates, err := read(builtin, "builtin", kind, "")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := read(os.DirFS(Dir()), ".", kind, Dir())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range user {
+		i := sort.Search(len(templates), func(i int) bool { return templates[i].Name >= u.Name })
+		if i < len(templates) && templates[i].Name == u.Name {
+			u.Overrides = true
+			templates[i] = u
+
+			continue
+		}
+
+		templates = append(templates[:i], append([]Template{u}, templates[i:]...)...)
+	}
+
+	return templates, nil
+}
+
+// read returns the templates of the kind in the directory root of fsys sorted by name, dir is the path of
+// fsys on disk and empty for the embedded templates. A missing directory has no templates.
+func read(fsys fs.FS, root string, kind Kind, dir string) ([]Template, error) {
+	entries, err := fs.ReadDir(fsys, path.Join(root, string(kind)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]Template, 0, len(entries))
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), path.Ext(e.Name()))
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		content, err := fs.ReadFile(fsys, path.Join(root, string(kind), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		t := Template{Kind: kind, Name: name, Content: string(content)}
+		if dir != "" {
+			t.Path = filepath.Join(dir, string(kind), e.Name())
+		}
+		if m := descriptionRegexp.FindStringSubmatch(t.Content); m != nil {
+			t.Description = strings.Join(strings.Fields(m[1]), " ")
+		}
+
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+
+	return templates, nil
+}
@@ -1,11 +1,32 @@
 package view
 
-var Tmpl = `# SUMMARY Webitel v{{ .WebitelVersion }}
-
-| Name     | Server      | Services  |
-|----------|-------------|-----------|
-{{ range $k, $v := .Inventory.Inventory.Hosts -}}
-| {{ $k }} | {{ $v.AnsibleHost }} | {{ range $v.WebitelServices }} {{ . }} |
-|          |             | {{ end }} |
-{{- end }}
-`
+import (
+	"bytes"
+	"github.com/kirychukyurii/wdeploy/internal/templates"
+	"strings"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Render executes the plan view template with data, usually a plan.Plan.
+func Render(data any) (string, error) {
+	t, err := templates.Get(templates.View, "plan")
+	if err != nil {
+		return "", err
+	}
+
+	tpl, err := template.New(t.Name).Funcs(funcs).Parse(t.Content)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
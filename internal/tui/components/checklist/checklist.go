@@ -0,0 +1,199 @@
+package checklist
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"strings"
+)
+
+// KeyMap is the key bindings of a checklist.
+type KeyMap struct {
+	Toggle    key.Binding
+	ToggleAll key.Binding
+}
+
+// DefaultKeyMap returns the default checklist key bindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Toggle: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "toggle"),
+		),
+		ToggleAll: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "toggle all"),
+		),
+	}
+}
+
+// Checklist is a list of items that can be checked and unchecked.
+type Checklist struct {
+	common  common.Common
+	title   string
+	items   []string
+	checked map[string]bool
+	cursor  int
+	offset  int
+	focused bool
+
+	KeyMap KeyMap
+}
+
+// New returns a new Checklist with all items unchecked.
+func New(c common.Common, title string, items []string) *Checklist {
+	return &Checklist{
+		common:  c,
+		title:   title,
+		items:   items,
+		checked: make(map[string]bool, len(items)),
+		KeyMap:  DefaultKeyMap(),
+	}
+}
+
+// SetSize implements common.Component.
+func (c *Checklist) SetSize(width, height int) {
+	c.common.SetSize(width, height)
+}
+
+// SetItems replaces the items, the items that are still present stay checked.
+func (c *Checklist) SetItems(items []string) {
+	c.items = items
+	if c.cursor >= len(items) {
+		c.cursor = 0
+	}
+}
+
+// Cursor returns the index of the item under the cursor.
+func (c *Checklist) Cursor() int {
+	return c.cursor
+}
+
+// SetCursor moves the cursor to the item with the index.
+func (c *Checklist) SetCursor(i int) {
+	if i >= 0 && i < len(c.items) {
+		c.cursor = i
+	}
+}
+
+// Items returns all items.
+func (c *Checklist) Items() []string {
+	return c.items
+}
+
+// SetChecked checks exactly the given items.
+func (c *Checklist) SetChecked(items []string) {
+	c.checked = make(map[string]bool, len(items))
+	for _, item := range items {
+		c.checked[item] = true
+	}
+}
+
+// CheckAll checks every item.
+func (c *Checklist) CheckAll() {
+	c.SetChecked(c.items)
+}
+
+// Checked returns the checked items in the order of the list.
+func (c *Checklist) Checked() []string {
+	checked := make([]string, 0, len(c.items))
+	for _, item := range c.items {
+		if c.checked[item] {
+			checked = append(checked, item)
+		}
+	}
+
+	return checked
+}
+
+// AllChecked reports whether every item is checked.
+func (c *Checklist) AllChecked() bool {
+	return len(c.Checked()) == len(c.items)
+}
+
+// Focus makes the checklist handle key presses.
+func (c *Checklist) Focus() {
+	c.focused = true
+}
+
+// Blur stops the checklist from handling key presses.
+func (c *Checklist) Blur() {
+	c.focused = false
+}
+
+// Focused reports whether the checklist handles key presses.
+func (c *Checklist) Focused() bool {
+	return c.focused
+}
+
+// Init implements tea.Model.
+func (c *Checklist) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (c *Checklist) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !c.focused || len(c.items) == 0 {
+		return c, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, c.common.KeyMap.Up):
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case key.Matches(keyMsg, c.common.KeyMap.Down):
+		if c.cursor < len(c.items)-1 {
+			c.cursor++
+		}
+	case key.Matches(keyMsg, c.KeyMap.Toggle):
+		item := c.items[c.cursor]
+		c.checked[item] = !c.checked[item]
+	case key.Matches(keyMsg, c.KeyMap.ToggleAll):
+		if c.AllChecked() {
+			c.SetChecked(nil)
+		} else {
+			c.CheckAll()
+		}
+	}
+
+	return c, nil
+}
+
+// View implements tea.Model.
+func (c *Checklist) View() string {
+	st := c.common.Styles.Checklist
+	s := strings.Builder{}
+	s.WriteString(st.Title.Render(fmt.Sprintf("%s (%d/%d)", c.title, len(c.Checked()), len(c.items))))
+
+	// Keep the cursor visible below the title.
+	height := c.common.Height - 1
+	if height < 1 {
+		height = 1
+	}
+	if c.cursor < c.offset {
+		c.offset = c.cursor
+	}
+	if c.cursor >= c.offset+height {
+		c.offset = c.cursor - height + 1
+	}
+
+	for i := c.offset; i < len(c.items) && i < c.offset+height; i++ {
+		box := st.Unchecked.Render("[ ]")
+		if c.checked[c.items[i]] {
+			box = st.Checked.Render("[x]")
+		}
+
+		item := st.Item.Render(c.items[i])
+		if c.focused && i == c.cursor {
+			item = st.Cursor.Render(c.items[i])
+		}
+
+		s.WriteString("\n")
+		s.WriteString(fmt.Sprintf("%s %s", box, item))
+	}
+
+	return s.String()
+}
@@ -31,6 +31,11 @@ func New(c common.Common, question string, buttons []string) *Dialog {
 	return d
 }
 
+// SetQuestion replaces the question above the buttons.
+func (d *Dialog) SetQuestion(question string) {
+	d.question = question
+}
+
 // SetSize implements common.Component.
 func (d *Dialog) SetSize(width, height int) {
 	d.common.Width = width
@@ -0,0 +1,150 @@
+package problems
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/validator"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/code"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
+	"strings"
+)
+
+// Problems is a pane listing validation problems of a config file.
+type Problems struct {
+	common         common.Common
+	code           *code.Code
+	problems       validator.Problems
+	configFileType int
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// New creates a new problems pane for the config file of configFileType.
+func New(common common.Common, cfg config.Config, configFileType int, logger logger.Logger) *Problems {
+	p := &Problems{
+		common:         common,
+		code:           code.New(common, "", ""),
+		configFileType: configFileType,
+
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	p.code.SetShowLineNumber(false)
+	return p
+}
+
+// SetSize implements common.Component.
+func (p *Problems) SetSize(width, height int) {
+	p.common.SetSize(width, height)
+	p.code.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (p *Problems) ShortHelp() []key.Binding {
+	return []key.Binding{
+		p.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (p *Problems) FullHelp() [][]key.Binding {
+	k := p.code.KeyMap
+	return [][]key.Binding{
+		{
+			k.Down,
+			k.Up,
+			k.PageDown,
+			k.PageUp,
+			k.HalfPageDown,
+			k.HalfPageUp,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (p *Problems) Init() tea.Cmd {
+	problems, err := validator.ValidateConfigFile(p.cfg, p.configFileType)
+	if err != nil {
+		p.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+	p.problems = problems
+
+	p.code.GotoTop()
+	return p.code.SetContent(p.render(), code.PlainTextExt)
+}
+
+// Update implements tea.Model.
+func (p *Problems) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg.(type) {
+	case tabs.ActiveTabMsg, action.Action:
+		// Validate again when the pane is shown, the file could be edited in the meantime.
+		cmds = append(cmds, p.Init())
+	}
+
+	c, cmd := p.code.Update(msg)
+	p.code = c.(*code.Code)
+	if cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+
+	return p, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (p *Problems) View() string {
+	return p.code.View()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (p *Problems) StatusBarValue() string {
+	return p.cfg.ConfigFiles[p.configFileType]
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (p *Problems) StatusBarInfo() string {
+	return fmt.Sprintf("✗ %d ⚠ %d", p.problems.Errors(), p.problems.Warnings())
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (p *Problems) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", p.cfg.WebitelVersion)
+}
+
+// Problems returns the problems found by the last validation.
+func (p *Problems) Problems() validator.Problems {
+	return p.problems
+}
+
+func (p *Problems) render() string {
+	st := p.common.Styles.Problems
+	if len(p.problems) == 0 {
+		return st.NoProblems.Render("✓ No problems found")
+	}
+
+	s := strings.Builder{}
+	for _, problem := range p.problems {
+		severity := st.Error.Render("✗ error  ")
+		if problem.Severity == validator.SeverityWarning {
+			severity = st.Warning.Render("⚠ warning")
+		}
+
+		location := st.Location.Render(fmt.Sprintf("%4d:%-3d", problem.Line, problem.Column))
+		msg := problem.Message
+		if problem.Key != "" {
+			msg = fmt.Sprintf("%s: %s", problem.Key, problem.Message)
+		}
+
+		s.WriteString(fmt.Sprintf("%s %s %s\n", severity, location, msg))
+	}
+
+	return s.String()
+}
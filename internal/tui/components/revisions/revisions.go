@@ -0,0 +1,371 @@
+package revisions
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/configdiff"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/revision"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/code"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/dialog"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
+	"os"
+	"strings"
+	"time"
+)
+
+// listHeight is the number of revisions shown above the diff.
+const listHeight = 8
+
+// RestoredMsg is a message sent when a revision of the config file of ConfigFileType has been restored.
+type RestoredMsg struct {
+	ConfigFileType int
+}
+
+// Revisions is a pane listing the previous contents of a config file, with the diff of the selected one
+// against the current file.
+type Revisions struct {
+	common         common.Common
+	code           *code.Code
+	dialog         *dialog.Dialog
+	revisions      []revision.Revision
+	changes        []string // Added and removed lines of every revision against the current file
+	cursor         int
+	offset         int
+	confirm        bool
+	message        string
+	failed         bool
+	configFileType int
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// New creates a new revisions pane for the config file of configFileType.
+func New(common common.Common, cfg config.Config, configFileType int, logger logger.Logger) *Revisions {
+	r := &Revisions{
+		common:         common,
+		code:           code.New(common, "", ""),
+		dialog:         dialog.New(common, "", []string{"Restore", "Cancel"}),
+		configFileType: configFileType,
+
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	r.code.SetShowLineNumber(false)
+	return r
+}
+
+// SetSize implements common.Component.
+func (r *Revisions) SetSize(width, height int) {
+	r.common.SetSize(width, height)
+	r.code.SetSize(width, height-r.listViewHeight()-1)
+	hm := r.common.Styles.Dialog.Box.GetHorizontalFrameSize()
+	r.dialog.SetSize(width, hm)
+}
+
+// ShortHelp implements help.KeyMap.
+func (r *Revisions) ShortHelp() []key.Binding {
+	restore := r.common.KeyMap.Select
+	restore.SetHelp("enter", "restore")
+
+	return []key.Binding{
+		r.common.KeyMap.UpDown,
+		restore,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (r *Revisions) FullHelp() [][]key.Binding {
+	restore := r.common.KeyMap.Select
+	restore.SetHelp("enter", "restore")
+	k := r.code.KeyMap
+
+	return [][]key.Binding{
+		{
+			r.common.KeyMap.Up,
+			r.common.KeyMap.Down,
+			restore,
+		},
+		{
+			k.PageDown,
+			k.PageUp,
+		},
+	}
+}
+
+// IsConfirming reports whether the pane asks to restore a revision, it takes the key presses then.
+func (r *Revisions) IsConfirming() bool {
+	return r.confirm
+}
+
+// Init implements tea.Model.
+func (r *Revisions) Init() tea.Cmd {
+	revisions, err := r.cfg.Revisions(r.configFileType).List()
+	if err != nil {
+		r.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+
+	current, err := r.current()
+	if err != nil {
+		r.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+
+	r.revisions, r.changes, r.confirm = revisions, make([]string, len(revisions)), false
+	for i, rev := range revisions {
+		r.changes[i] = "?"
+		if content, err := rev.Content(); err == nil {
+			r.changes[i] = changes(configdiff.Lines(current, content))
+		}
+	}
+	if r.cursor >= len(r.revisions) {
+		r.cursor, r.offset = 0, 0
+	}
+
+	r.SetSize(r.common.Width, r.common.Height)
+
+	return r.preview()
+}
+
+// Update implements tea.Model.
+func (r *Revisions) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if r.confirm {
+		switch msg := msg.(type) {
+		case dialog.SelectDialogButtonMsg:
+			r.confirm = false
+			if msg == 0 {
+				return r, r.restore()
+			}
+
+			return r, nil
+		case tea.KeyMsg:
+			if key.Matches(msg, r.common.KeyMap.Back) {
+				r.confirm = false
+				return r, nil
+			}
+
+			d, cmd := r.dialog.Update(msg)
+			r.dialog = d.(*dialog.Dialog)
+
+			return r, cmd
+		}
+	}
+
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case tabs.ActiveTabMsg, action.Action:
+		// The file could be changed in the meantime, the revisions are compared with it again.
+		r.message, r.failed = "", false
+		cmds = append(cmds, r.Init())
+	case tea.WindowSizeMsg:
+		d, cmd := r.dialog.Update(msg)
+		r.dialog = d.(*dialog.Dialog)
+		cmds = append(cmds, cmd)
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, r.common.KeyMap.Up):
+			if r.cursor > 0 {
+				r.cursor--
+				cmds = append(cmds, r.preview())
+			}
+		case key.Matches(msg, r.common.KeyMap.Down):
+			if r.cursor < len(r.revisions)-1 {
+				r.cursor++
+				cmds = append(cmds, r.preview())
+			}
+		case key.Matches(msg, r.common.KeyMap.Select):
+			if len(r.revisions) > 0 {
+				rev := r.revisions[r.cursor]
+				r.confirm = true
+				r.dialog.SetQuestion(fmt.Sprintf("Restore revision %s? The current content is kept as a revision.", rev.ID))
+				cmds = append(cmds, r.dialog.Init())
+			}
+		case key.Matches(msg, r.code.KeyMap.PageDown):
+			r.code.ViewDown()
+		case key.Matches(msg, r.code.KeyMap.PageUp):
+			r.code.ViewUp()
+		}
+	}
+
+	return r, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (r *Revisions) View() string {
+	st := r.common.Styles.History
+	if len(r.revisions) == 0 {
+		return st.NoRuns.Render("No revisions yet, the file is kept here every time it is changed")
+	}
+
+	body := r.code.View()
+	if r.confirm {
+		body = r.dialog.View()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top,
+		r.listView(),
+		r.messageView(),
+		body,
+	)
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (r *Revisions) StatusBarValue() string {
+	return r.cfg.Revisions(r.configFileType).Dir()
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (r *Revisions) StatusBarInfo() string {
+	return fmt.Sprintf("%d revision(s)", len(r.revisions))
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (r *Revisions) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", r.cfg.WebitelVersion)
+}
+
+func (r *Revisions) path() string {
+	return r.cfg.ConfigFiles[r.configFileType]
+}
+
+// current returns the content of the file as it is, to be compared with the revisions.
+func (r *Revisions) current() (string, error) {
+	content, err := os.ReadFile(r.path())
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// preview shows what restoring the selected revision changes in the current file.
+func (r *Revisions) preview() tea.Cmd {
+	if len(r.revisions) == 0 {
+		return nil
+	}
+
+	current, err := r.current()
+	if err != nil {
+		r.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+
+	rev := r.revisions[r.cursor]
+	content, err := rev.Content()
+	if err != nil {
+		r.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+
+	lines := configdiff.Lines(r.cfg.MaskSecrets(current, false), r.cfg.MaskSecrets(content, false))
+	view := "The revision is the same as the current file"
+	if len(lines) > 0 {
+		view = fmt.Sprintf("Restoring revision %s changes the current file:\n\n%s", rev.ID, strings.Join(lines, "\n"))
+	}
+
+	r.code.GotoTop()
+	return r.code.SetContent(view, ".diff")
+}
+
+// restore replaces the file with the selected revision and tells the other panes to read it again.
+func (r *Revisions) restore() tea.Cmd {
+	rev := r.revisions[r.cursor]
+	if err := r.cfg.Revisions(r.configFileType).Restore(rev.ID, r.path()); err != nil {
+		r.logger.Zap.Error(err)
+		r.message, r.failed = err.Error(), true
+
+		return nil
+	}
+
+	// The revision may be older than the vault password.
+	if _, err := r.cfg.EncryptSecrets(r.configFileType); err != nil {
+		r.logger.Zap.Error(err)
+	}
+
+	r.cursor, r.offset = 0, 0
+	cmd := r.Init()
+	r.message, r.failed = fmt.Sprintf("Restored revision %s", rev.ID), false
+
+	configFileType := r.configFileType
+	return tea.Batch(cmd, func() tea.Msg {
+		return RestoredMsg{ConfigFileType: configFileType}
+	})
+}
+
+func (r *Revisions) listViewHeight() int {
+	n := len(r.revisions)
+	if n > listHeight {
+		n = listHeight
+	}
+
+	// The header and the message line.
+	return n + 2
+}
+
+func (r *Revisions) listView() string {
+	st := r.common.Styles.History
+	height := r.listViewHeight() - 2
+	if r.cursor < r.offset {
+		r.offset = r.cursor
+	}
+	if r.cursor >= r.offset+height {
+		r.offset = r.cursor - height + 1
+	}
+
+	s := strings.Builder{}
+	s.WriteString(st.Header.Render(fmt.Sprintf(" %-19s %-19s %-9s %s", "ID", "SAVED", "SIZE", "CHANGES")))
+	for i := r.offset; i < len(r.revisions) && i < r.offset+height; i++ {
+		rev := r.revisions[i]
+		row := fmt.Sprintf("%-19s %-19s %-9s %s", rev.ID, rev.Time.Format(time.DateTime),
+			fmt.Sprintf("%d B", rev.Size), r.changes[i])
+		row = common.TruncateString(row, r.common.Width-1)
+
+		s.WriteString("\n")
+		if i == r.cursor {
+			s.WriteString(st.Selected.Render(row))
+		} else {
+			s.WriteString(st.Row.Render(row))
+		}
+	}
+
+	return s.String()
+}
+
+func (r *Revisions) messageView() string {
+	st := r.common.Styles.Problems
+	switch {
+	case r.message == "":
+		return ""
+	case r.failed:
+		return st.Error.Render("✗ " + r.message)
+	}
+
+	return st.NoProblems.Render("✓ " + r.message)
+}
+
+// changes returns the number of added and removed lines of a line diff.
+func changes(lines []string) string {
+	added, removed := 0, 0
+	for _, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+ "):
+			added++
+		case strings.HasPrefix(l, "- "):
+			removed++
+		}
+	}
+
+	if added == 0 && removed == 0 {
+		return "same as current"
+	}
+
+	return fmt.Sprintf("+%d -%d", added, removed)
+}
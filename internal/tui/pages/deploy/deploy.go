@@ -2,6 +2,8 @@ package deploy
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -9,7 +11,12 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kirychukyurii/wdeploy/internal/config"
 	"github.com/kirychukyurii/wdeploy/internal/lib/ansible"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible/events"
+	"github.com/kirychukyurii/wdeploy/internal/lib/deployment"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
 	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/validator"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
 	"github.com/kirychukyurii/wdeploy/internal/tui/common"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/dialog"
@@ -17,13 +24,22 @@ import (
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/statusbar"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
 	"io"
+	"time"
+)
+
+var abortDeploy = key.NewBinding(
+	key.WithKeys("x"),
+	key.WithHelp("x", "abort deploy"),
 )
 
 type tab int
 
 const (
 	viewTab tab = iota
+	targetsTab
+	progressTab
 	logTab
+	dryRunTab
 
 	lastTab
 )
@@ -31,7 +47,10 @@ const (
 func (t tab) String() string {
 	return []string{
 		"Deploy",
+		"Targets",
+		"Progress",
 		"Log",
+		"Dry run",
 	}[t]
 }
 
@@ -47,6 +66,20 @@ type RepoMsg action.Action
 // BackMsg is a message to go back to the previous view.
 type BackMsg struct{}
 
+// ConfigChangedMsg is a message sent when the config file of ConfigFileType has been saved or restored on another page.
+type ConfigChangedMsg struct {
+	ConfigFileType int
+}
+
+// QuitMsg is a message sent when the application can quit after the aborted deploy is over.
+type QuitMsg struct{}
+
+// DeployFinishedMsg is a message sent when the playbook run is over.
+type DeployFinishedMsg struct {
+	run history.Run
+	err error
+}
+
 // Deploy is a view for a git repository.
 type Deploy struct {
 	common       common.Common
@@ -57,29 +90,53 @@ type Deploy struct {
 	tabs      *tabs.Tabs
 	panes     []common.Component
 	sub       chan string
-
+	events    chan events.Event
+
+	// cancel aborts the running deploy, it is nil when nothing runs.
+	cancel       context.CancelFunc
+	checkMode    bool // The last run is a dry run, its output goes to the Dry run tab
+	targets      TargetsMsg
+	runState     string
+	abortDialog  *dialog.Dialog
+	confirmAbort bool
+	// quitDialog asks to abort the running deploy on quit, quitAfterRun quits once it is over.
+	quitDialog   *dialog.Dialog
+	confirmQuit  bool
+	quitAfterRun bool
+	// preflight checks the hosts and asks to start the run, pendingCheckMode is the run it confirms.
+	preflight        *Preflight
+	confirmRun       bool
+	pendingCheckMode bool
+
+	runs   *deployment.Runs
 	cfg    config.Config
 	logger logger.Logger
 }
 
-// New returns a new Repo.
-func New(c common.Common, cfg config.Config, logger logger.Logger) *Deploy {
+// New returns a new Repo, its deploys are started with runs.
+func New(c common.Common, cfg config.Config, logger logger.Logger, runs *deployment.Runs) *Deploy {
 	sb := statusbar.New(c)
 	ts := make([]string, lastTab)
 
 	// Tabs must match the order of tab constants above.
-	for i, t := range []tab{viewTab, logTab} {
+	for i, t := range []tab{viewTab, targetsTab, progressTab, logTab, dryRunTab} {
 		ts[i] = t.String()
 	}
 	tb := tabs.New(c, ts)
 
 	view := NewView(c, cfg, logger)
+	targets := NewTargets(c, cfg, logger)
+	progress := NewProgress(c, cfg, logger)
 	log := NewLog(c, cfg, logger)
+	dryRun := NewDryRun(c, cfg, logger)
 
 	// Make sure the order matches the order of tab constants above.
 	panes := []common.Component{
 		view,
+		targets,
+		progress,
 		log,
+		dryRun,
 	}
 
 	d := &Deploy{
@@ -88,6 +145,13 @@ func New(c common.Common, cfg config.Config, logger logger.Logger) *Deploy {
 		tabs:      tb,
 		panes:     panes,
 		sub:       make(chan string),
+		events:    make(chan events.Event),
+		abortDialog: dialog.New(c, "Abort the running deploy? Hosts may be left half-configured.",
+			[]string{"Abort", "Continue"}),
+		quitDialog: dialog.New(c, "A deploy is running. Abort it and quit? Hosts may be left half-configured.",
+			[]string{"Abort and quit", "Continue"}),
+		preflight: NewPreflight(c, cfg, logger),
+		runs:      runs,
 		cfg:       cfg,
 		logger:    logger,
 	}
@@ -107,6 +171,9 @@ func (d *Deploy) SetSize(width, height int) {
 	for _, p := range d.panes {
 		p.SetSize(width, height-hm)
 	}
+	d.abortDialog.SetSize(width, height-hm)
+	d.quitDialog.SetSize(width, height-hm)
+	d.preflight.SetSize(width, height-hm)
 }
 
 func (d *Deploy) commonHelp() []key.Binding {
@@ -117,6 +184,9 @@ func (d *Deploy) commonHelp() []key.Binding {
 	tab.SetHelp("tab", "switch tab")
 	b = append(b, back)
 	b = append(b, tab)
+	if d.cancel != nil {
+		b = append(b, abortDeploy)
+	}
 
 	return b
 }
@@ -138,12 +208,55 @@ func (d *Deploy) FullHelp() [][]key.Binding {
 	return b
 }
 
+// start runs the playbook, or a dry run when checkMode is set, and shows its progress.
+func (d *Deploy) start(checkMode bool) []tea.Cmd {
+	cmds := make([]tea.Cmd, 0)
+	d.checkMode = checkMode
+	d.activeTab = progressTab
+	d.runState = "deploying"
+	if d.checkMode {
+		d.activeTab = dryRunTab
+		d.runState = "dry run"
+		cmds = append(cmds, d.panes[dryRunTab].(*DryRun).Reset())
+	}
+
+	cmd := tabs.SelectTabCmd(int(d.activeTab))
+	if cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+
+	d.panes[progressTab].(*Progress).Reset()
+
+	return append(cmds, d.deploy(d.sub, d.events), d.updateStatusBarCmd)
+}
+
+// Running reports whether a deploy or a dry run is in progress.
+func (d *Deploy) Running() bool {
+	return d.cancel != nil
+}
+
+// ConfirmQuit asks to abort the running deploy, the page sends QuitMsg once it is over.
+func (d *Deploy) ConfirmQuit() tea.Cmd {
+	if d.runState == "aborting" {
+		d.quitAfterRun = true
+
+		return d.updateStatusBarCmd
+	}
+
+	d.confirmAbort = false
+	d.confirmRun = false
+	d.confirmQuit = true
+
+	return d.quitDialog.Init()
+}
+
 // Init implements tea.Log.
 func (d *Deploy) Init() tea.Cmd {
 	return tea.Batch(
 		d.tabs.Init(),
 		d.statusbar.Init(),
 		waitForActivity(d.sub),
+		waitForEvent(d.events),
 	)
 }
 
@@ -151,19 +264,157 @@ func (d *Deploy) Init() tea.Cmd {
 func (d *Deploy) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds := make([]tea.Cmd, 0)
 
+	if d.confirmAbort {
+		switch msg := msg.(type) {
+		case dialog.SelectDialogButtonMsg:
+			d.confirmAbort = false
+			if msg == 0 && d.cancel != nil {
+				d.logger.Zap.Warn("Aborting the deploy")
+				d.cancel()
+				d.runState = "aborting"
+			}
+
+			return d, d.updateStatusBarCmd
+		case tea.KeyMsg:
+			if key.Matches(msg, d.common.KeyMap.Back) {
+				d.confirmAbort = false
+
+				return d, nil
+			}
+
+			m, cmd := d.abortDialog.Update(msg)
+			d.abortDialog = m.(*dialog.Dialog)
+
+			return d, cmd
+		}
+	}
+
+	if d.confirmQuit {
+		switch msg := msg.(type) {
+		case dialog.SelectDialogButtonMsg:
+			d.confirmQuit = false
+			if msg != 0 {
+				return d, nil
+			}
+
+			// The deploy may be over while the dialog is shown.
+			if d.cancel == nil {
+				return d, quitCmd
+			}
+
+			d.logger.Zap.Warn("Aborting the deploy to quit")
+			d.cancel()
+			d.runState = "aborting"
+			d.quitAfterRun = true
+
+			return d, d.updateStatusBarCmd
+		case tea.KeyMsg:
+			if key.Matches(msg, d.common.KeyMap.Back) {
+				d.confirmQuit = false
+
+				return d, nil
+			}
+
+			m, cmd := d.quitDialog.Update(msg)
+			d.quitDialog = m.(*dialog.Dialog)
+
+			return d, cmd
+		}
+	}
+
+	if d.confirmRun {
+		switch msg := msg.(type) {
+		case dialog.SelectDialogButtonMsg:
+			d.confirmRun = false
+			if msg == 0 && d.cancel == nil {
+				return d, tea.Batch(d.start(d.pendingCheckMode)...)
+			}
+
+			return d, nil
+		case tea.KeyMsg:
+			if key.Matches(msg, d.common.KeyMap.Back) {
+				d.confirmRun = false
+
+				return d, nil
+			}
+
+			m, cmd := d.preflight.Update(msg)
+			d.preflight = m.(*Preflight)
+
+			return d, cmd
+		case HostsCheckedMsg:
+			m, cmd := d.preflight.Update(msg)
+			d.preflight = m.(*Preflight)
+
+			return d, cmd
+		}
+	}
+
 	switch msg := msg.(type) {
 	case dialog.SelectDialogButtonMsg:
-		if msg == 0 {
-			d.activeTab = logTab
-			cmd := tabs.SelectTabCmd(int(logTab))
-			if cmd != nil {
-				cmds = append(cmds, cmd)
+		if (msg == 0 || msg == 1) && d.cancel == nil {
+			if err := d.panes[targetsTab].(*Targets).Validate(); err != nil {
+				return d, common.ErrorCmd(err)
 			}
-			//r.tabs.Update()
 
-			// TODO
-			_ = d.deploy(d.sub)
+			problems, err := validator.ValidateConfigFile(d.cfg, config.InventoryConfig)
+			if err != nil {
+				return d, common.ErrorCmd(err)
+			}
+
+			if problems.HasErrors() {
+				return d, common.ErrorCmd(fmt.Errorf("the inventory has %d error(s), fix them on the Problems tab of the Hosts page",
+					problems.Errors()))
+			}
+
+			// The run starts once the hosts are checked and the results are confirmed.
+			d.confirmRun, d.pendingCheckMode = true, msg == 1
+
+			return d, d.preflight.Start(d.targets.Limit, problems.Warnings())
+		}
+	case DeployFinishedMsg:
+		d.cancel = nil
+		if d.checkMode {
+			cmds = append(cmds, d.updateModel(dryRunTab, msg))
+		}
+		// A successful deploy is the new base of the changes on the Deploy tab.
+		cmds = append(cmds, d.updateModel(viewTab, msg))
+		d.runState = string(history.StatusSucceeded)
+		switch {
+		case errors.Is(msg.err, ansible.ErrAborted):
+			d.runState = string(history.StatusAborted)
+		case msg.err != nil:
+			d.runState = string(history.StatusFailed)
+		}
+		if d.quitAfterRun {
+			cmds = append(cmds, quitCmd)
+		}
+
+		return d, tea.Batch(append(cmds, d.updateStatusBarCmd)...)
+	// Log lines and playbook events keep coming while another tab is active,
+	// so they go straight to their panes instead of the active one.
+	case LogMsg:
+		if d.checkMode {
+			return d, tea.Batch(d.updateModel(dryRunTab, msg), d.updateStatusBarCmd)
+		}
+
+		return d, tea.Batch(d.updateModel(logTab, msg), d.updateStatusBarCmd)
+	case EventMsg:
+		return d, tea.Batch(d.updateModel(progressTab, msg), d.updateStatusBarCmd)
+	case ConfigChangedMsg:
+		// The next run is recorded with the saved config.
+		if msg.ConfigFileType == config.VarsConfig {
+			d.cfg.Variables = config.Variables{}
+		}
+		if err := d.cfg.ReadToStruct(msg.ConfigFileType); err != nil && !errors.Is(err, vault.ErrWrongPassword) {
+			d.logger.Zap.Debug(err)
 		}
+
+		return d, tea.Batch(d.updateModels(msg), d.updateStatusBarCmd)
+	case TargetsMsg:
+		d.targets = msg
+
+		return d, d.updateModel(viewTab, msg)
 	case RepoMsg:
 		d.activeTab = 0
 		d.selectedRepo = action.Action(msg) //git.GitRepo(msg)
@@ -186,6 +437,12 @@ func (d *Deploy) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			d.updateStatusBarCmd,
 		)
 	case tea.KeyMsg, tea.MouseMsg:
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, abortDeploy) && d.cancel != nil {
+			d.confirmAbort = true
+
+			return d, d.abortDialog.Init()
+		}
+
 		t, cmd := d.tabs.Update(msg)
 		d.tabs = t.(*tabs.Tabs)
 		if cmd != nil {
@@ -243,9 +500,18 @@ func (d *Deploy) View() string {
 		d.common.Styles.Tabs.GetVerticalFrameSize()
 	mainStyle := repoBodyStyle.
 		Height(d.common.Height - hm)
+	pane := d.panes[d.activeTab].View()
+	switch {
+	case d.confirmQuit:
+		pane = d.quitDialog.View()
+	case d.confirmAbort:
+		pane = d.abortDialog.View()
+	case d.confirmRun:
+		pane = d.preflight.View()
+	}
 	main := d.common.Zone.Mark(
 		"repo-main",
-		mainStyle.Render(d.panes[d.activeTab].View()),
+		mainStyle.Render(pane),
 	)
 	view := lipgloss.JoinVertical(lipgloss.Top,
 		d.headerView(),
@@ -298,6 +564,10 @@ func (d *Deploy) updateStatusBarCmd() tea.Msg {
 	info := d.panes[d.activeTab].(statusbar.Model).StatusBarInfo()
 	branch := d.panes[d.activeTab].(statusbar.Model).StatusBarBranch()
 
+	if d.runState != "" {
+		info = fmt.Sprintf("%s · %s", d.runState, info)
+	}
+
 	return statusbar.StatusBarMsg{
 		Key:    d.selectedRepo.ID(),
 		Value:  value,
@@ -319,6 +589,13 @@ func (d *Deploy) updateModels(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+func (d *Deploy) updateModel(t tab, msg tea.Msg) tea.Cmd {
+	m, cmd := d.panes[t].Update(msg)
+	d.panes[t] = m.(common.Component)
+
+	return cmd
+}
+
 func updateStatusBarCmd() tea.Msg {
 	return UpdateStatusBarMsg{}
 }
@@ -327,8 +604,22 @@ func backCmd() tea.Msg {
 	return BackMsg{}
 }
 
-func (d *Deploy) deploy(sub chan string) error {
+func quitCmd() tea.Msg {
+	return QuitMsg{}
+}
+
+func (d *Deploy) deploy(sub chan string, eventsSub chan events.Event) tea.Cmd {
+	// The application stops the run on exit and waits for it, nobody reads the channels then.
+	ctx, cancel, done := d.runs.Start()
+	d.cancel = cancel
+
+	cfg := d.cfg
+	cfg.CheckMode = d.checkMode
+	cfg.Limit = d.targets.Limit
+	cfg.Tags = d.targets.Tags
+
 	reader, writer := io.Pipe()
+	finished := make(chan DeployFinishedMsg, 1)
 
 	go func() {
 		r := bufio.NewReader(reader)
@@ -344,17 +635,41 @@ func (d *Deploy) deploy(sub chan string) error {
 			}
 
 			d.logger.Zap.Info(line)
-			sub <- line
+			select {
+			case sub <- line:
+			case <-d.runs.Done():
+			}
 		}
 	}()
 
 	go func() {
-		executor := ansible.NewExecutor(d.cfg, d.logger, writer)
+		defer done()
+		defer cancel()
+
+		run, err := deployment.Run(cfg, d.logger, deployment.Options{
+			Context: ctx,
+			Output:  writer,
+			OnEvent: func(e events.Event) {
+				select {
+				case eventsSub <- e:
+				case <-d.runs.Done():
+				}
+			},
+		})
+		if err != nil {
+			fmt.Fprintln(writer, err)
+		}
+		if run.ID != "" {
+			fmt.Fprintf(writer, "Run %s %s in %s\n", run.ID, run.Status, run.Duration.Round(time.Second))
+		}
 
-		_ = executor.RunPlaybook()
+		_ = writer.Close()
+		finished <- DeployFinishedMsg{run: run, err: err}
 	}()
 
-	return nil
+	return func() tea.Msg {
+		return <-finished
+	}
 }
 
 func readLine(r *bufio.Reader) (string, error) {
@@ -0,0 +1,148 @@
+package deploy
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/deployment"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/code"
+	"strings"
+)
+
+// DryRun shows the output of a check mode run with the diffs ansible-playbook reports,
+// preceded by a summary of the changes per host once the run is over.
+type DryRun struct {
+	common  common.Common
+	code    *code.Code
+	repo    action.Action
+	output  strings.Builder
+	summary string
+	started bool
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewDryRun creates a new dry run model.
+func NewDryRun(common common.Common, cfg config.Config, logger logger.Logger) *DryRun {
+	d := &DryRun{
+		common: common,
+		code:   code.New(common, "", ""),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	d.code.SetShowLineNumber(false)
+	return d
+}
+
+// Reset clears the output before a new dry run.
+func (d *DryRun) Reset() tea.Cmd {
+	d.output.Reset()
+	d.summary = ""
+	d.started = true
+
+	return d.setContent()
+}
+
+// SetSize implements common.Component.
+func (d *DryRun) SetSize(width, height int) {
+	d.common.SetSize(width, height)
+	d.code.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (d *DryRun) ShortHelp() []key.Binding {
+	return []key.Binding{
+		d.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (d *DryRun) FullHelp() [][]key.Binding {
+	k := d.code.KeyMap
+	return [][]key.Binding{
+		{
+			k.Down,
+			k.Up,
+			k.PageDown,
+			k.PageUp,
+			k.HalfPageDown,
+			k.HalfPageUp,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (d *DryRun) Init() tea.Cmd {
+	return d.setContent()
+}
+
+// Update implements tea.Model.
+func (d *DryRun) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case LogMsg:
+		d.output.WriteString(fmt.Sprintln(msg.message))
+		if d.summary == "" {
+			d.code.GotoBottom()
+		}
+		cmds = append(cmds, d.setContent(), waitForActivity(msg.sub))
+	case DeployFinishedMsg:
+		d.summary = deployment.DryRunSummary(msg.run.Stats)
+		d.code.GotoTop()
+		cmds = append(cmds, d.setContent())
+	case RepoMsg:
+		d.repo = action.Action(msg)
+		cmds = append(cmds, d.Init())
+	}
+
+	c, cmd := d.code.Update(msg)
+	d.code = c.(*code.Code)
+	if cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+
+	return d, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (d *DryRun) View() string {
+	return d.code.View()
+}
+
+func (d *DryRun) setContent() tea.Cmd {
+	if !d.started {
+		return d.code.SetContent("Choose \"Dry run\" on the Deploy tab to see what a deploy would change.", code.PlainTextExt)
+	}
+
+	content := d.output.String()
+	if content == "" {
+		content = "Waiting for ansible-playbook…"
+	}
+	if d.summary != "" {
+		content = fmt.Sprintf("Changes per host:\n%s\n%s", d.summary, content)
+	}
+
+	return d.code.SetContent(content, code.PlainTextExt)
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (d *DryRun) StatusBarValue() string {
+	return "ansible-playbook --check --diff"
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (d *DryRun) StatusBarInfo() string {
+	return fmt.Sprintf("☰ %.f%%", d.code.ScrollPercent()*100)
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (d *DryRun) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", d.cfg.WebitelVersion)
+}
@@ -0,0 +1,154 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/hostcheck"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/dialog"
+	"strings"
+)
+
+// HostsCheckedMsg is a message that contains the SSH check of the hosts to deploy to.
+type HostsCheckedMsg struct {
+	results hostcheck.Results
+	err     error
+}
+
+// Preflight checks that the hosts to deploy to are reachable over SSH and asks to start the run.
+type Preflight struct {
+	common   common.Common
+	dialog   *dialog.Dialog
+	checking bool
+	results  hostcheck.Results
+	err      error
+	warnings int // Warnings of the inventory validation
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewPreflight creates a new preflight model.
+func NewPreflight(common common.Common, cfg config.Config, logger logger.Logger) *Preflight {
+	return &Preflight{
+		common: common,
+		dialog: dialog.New(common, "", []string{"Continue", "Cancel"}),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// SetSize implements common.Component.
+func (p *Preflight) SetSize(width, height int) {
+	p.common.SetSize(width, height)
+	hm := p.common.Styles.Dialog.Box.GetHorizontalFrameSize()
+	p.dialog.SetSize(width, hm)
+}
+
+// Start checks the hosts of limit, all hosts when it is empty. warnings is the number of warnings
+// of the inventory, they are mentioned in the question.
+func (p *Preflight) Start(limit string, warnings int) tea.Cmd {
+	p.checking, p.results, p.err, p.warnings = true, nil, nil, warnings
+
+	cfg, log := p.cfg, p.logger
+	return tea.Batch(p.dialog.Init(), func() tea.Msg {
+		targets, err := hostcheck.Targets(cfg, limit)
+		if err != nil {
+			log.Zap.Error(err)
+			return HostsCheckedMsg{err: err}
+		}
+
+		results := hostcheck.New().Check(context.Background(), targets)
+		for _, r := range results {
+			log.Zap.Infof("SSH check of %s (%s as %s): %s %s", r.Name, r.Addr(), r.User, r.Status, r.Detail)
+		}
+
+		return HostsCheckedMsg{results: results}
+	})
+}
+
+// Init implements tea.Model.
+func (p *Preflight) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (p *Preflight) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case HostsCheckedMsg:
+		p.checking, p.results, p.err = false, msg.results, msg.err
+		p.dialog.SetQuestion(p.question())
+	case tea.KeyMsg:
+		// Nothing to confirm until the check is over.
+		if p.checking {
+			return p, nil
+		}
+	}
+
+	d, cmd := p.dialog.Update(msg)
+	p.dialog = d.(*dialog.Dialog)
+
+	return p, cmd
+}
+
+// question asks to start the run, with the reasons to think twice.
+func (p *Preflight) question() string {
+	notes := make([]string, 0, 2)
+	switch {
+	case p.err != nil:
+		notes = append(notes, "The hosts could not be checked.")
+	case p.results.Failed() > 0:
+		notes = append(notes, fmt.Sprintf("%d of %d host(s) are not reachable.", p.results.Failed(), len(p.results)))
+	}
+	if p.warnings > 0 {
+		notes = append(notes, fmt.Sprintf("The inventory has %d warning(s).", p.warnings))
+	}
+
+	if len(notes) == 0 {
+		return "All hosts are reachable, start the run?"
+	}
+
+	return strings.Join(append(notes, "Start the run anyway?"), " ")
+}
+
+// View implements tea.Model.
+func (p *Preflight) View() string {
+	st := p.common.Styles.History
+	if p.checking {
+		return st.NoRuns.Render("Checking SSH connections to the hosts…")
+	}
+
+	if p.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Top,
+			p.common.Styles.Problems.Error.Render("✗ "+p.err.Error()),
+			p.dialog.View(),
+		)
+	}
+
+	s := strings.Builder{}
+	s.WriteString(st.Header.Render(fmt.Sprintf(" %-16s %-24s %-12s %-18s %s", "HOST", "ADDRESS", "USER", "STATUS", "DETAIL")))
+	for _, r := range p.results {
+		status := p.common.Styles.Problems.NoProblems.Render(fmt.Sprintf("%-18s", r.Status))
+		if !r.Status.OK() {
+			status = p.common.Styles.Problems.Error.Render(fmt.Sprintf("%-18s", r.Status))
+		}
+
+		row := fmt.Sprintf(" %-16s %-24s %-12s %s %s", r.Name, r.Addr(), r.User, status, r.Detail)
+		if r.Status == hostcheck.StatusLocal {
+			row = fmt.Sprintf(" %-16s %-24s %-12s %s %s", r.Name, "-", "-", status, r.Detail)
+		}
+
+		s.WriteString("\n")
+		s.WriteString(common.TruncateString(row, p.common.Width-1))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top,
+		s.String(),
+		p.dialog.View(),
+	)
+}
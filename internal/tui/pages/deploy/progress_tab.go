@@ -0,0 +1,263 @@
+package deploy
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/ansible/events"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"sort"
+	"strings"
+)
+
+// EventMsg is a message that contains a playbook event.
+type EventMsg struct {
+	event events.Event
+	sub   chan events.Event
+}
+
+// Progress is a host by task grid of the running playbook.
+type Progress struct {
+	common   common.Common
+	repo     action.Action
+	progress *events.Progress
+	offset   int
+	follow   bool
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewProgress creates a new progress model.
+func NewProgress(common common.Common, cfg config.Config, logger logger.Logger) *Progress {
+	p := &Progress{
+		common: common,
+		follow: true,
+
+		cfg:    cfg,
+		logger: logger,
+	}
+	p.Reset()
+
+	return p
+}
+
+// Reset clears the grid before a new run.
+func (p *Progress) Reset() {
+	inventory := p.cfg.Inventory.Hosts()
+	hosts := make([]string, 0, len(inventory))
+	for h := range inventory {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	p.progress = events.NewProgress(hosts)
+	p.offset, p.follow = 0, true
+}
+
+// SetSize implements common.Component.
+func (p *Progress) SetSize(width, height int) {
+	p.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (p *Progress) ShortHelp() []key.Binding {
+	return []key.Binding{
+		p.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (p *Progress) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			p.common.KeyMap.Up,
+			p.common.KeyMap.Down,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (p *Progress) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (p *Progress) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case EventMsg:
+		p.progress.Apply(msg.event)
+		cmds = append(cmds, waitForEvent(msg.sub))
+	case RepoMsg:
+		p.repo = action.Action(msg)
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, p.common.KeyMap.Up):
+			if p.offset > 0 {
+				p.offset--
+			}
+			p.follow = false
+		case key.Matches(msg, p.common.KeyMap.Down):
+			p.offset++
+		}
+	}
+
+	return p, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (p *Progress) View() string {
+	st := p.common.Styles.Progress
+	if len(p.progress.Tasks) == 0 {
+		return st.NoEvents.Render("Waiting for the playbook to start…")
+	}
+
+	hosts := p.progress.Hosts
+	widths := make([]int, len(hosts))
+	hostsWidth := 0
+	for i, h := range hosts {
+		widths[i] = lipgloss.Width(h) + 2
+		if widths[i] < 5 {
+			widths[i] = 5
+		}
+		hostsWidth += widths[i]
+	}
+
+	taskWidth := p.common.Width - hostsWidth - 1
+	if taskWidth < 16 {
+		taskWidth = 16
+	}
+
+	// Rows left for tasks after the header, the separator and one summary line per host.
+	rows := p.common.Height - len(hosts) - 2
+	if rows < 3 {
+		rows = 3
+	}
+	maxOffset := len(p.progress.Tasks) - rows
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	if p.follow || p.offset >= maxOffset {
+		p.offset, p.follow = maxOffset, true
+	}
+
+	s := strings.Builder{}
+	s.WriteString(st.Header.Render(fmt.Sprintf("%-*s", taskWidth, "TASK")))
+	for i, h := range hosts {
+		s.WriteString(st.Header.Render(fmt.Sprintf("%*s", widths[i], h)))
+	}
+
+	current := p.progress.Current()
+	for _, t := range p.progress.Tasks[p.offset:minInt(p.offset+rows, len(p.progress.Tasks))] {
+		name := t.Name
+		if t.Handler {
+			name = "handler: " + name
+		}
+		s.WriteString("\n")
+		s.WriteString(st.Task.Render(fmt.Sprintf("%-*s", taskWidth, common.TruncateString(name, taskWidth-1))))
+
+		for i, h := range hosts {
+			cell := p.cell(t.Results[h], t == current && !p.progress.Done())
+			s.WriteString(strings.Repeat(" ", widths[i]-1))
+			s.WriteString(cell)
+		}
+	}
+
+	s.WriteString("\n")
+	s.WriteString(st.Pending.Render(strings.Repeat("─", taskWidth+hostsWidth)))
+	for _, h := range hosts {
+		s.WriteString("\n")
+		s.WriteString(p.hostSummary(h))
+	}
+
+	return s.String()
+}
+
+func (p *Progress) cell(status events.Status, running bool) string {
+	st := p.common.Styles.Progress
+	switch status {
+	case events.StatusOk:
+		return st.Ok.Render("✓")
+	case events.StatusChanged:
+		return st.Changed.Render("●")
+	case events.StatusFailed:
+		return st.Failed.Render("✗")
+	case events.StatusSkipped:
+		return st.Skipped.Render("–")
+	case events.StatusUnreachable:
+		return st.Unreachable.Render("!")
+	}
+
+	if running {
+		return st.Pending.Render("…")
+	}
+
+	return " "
+}
+
+func (p *Progress) hostSummary(host string) string {
+	st := p.common.Styles.Progress
+	if stats, ok := p.progress.Stats[host]; ok {
+		return fmt.Sprintf("%-20s %s %s %s %s %s", host,
+			st.Ok.Render(fmt.Sprintf("ok=%d", stats.Ok)),
+			st.Changed.Render(fmt.Sprintf("changed=%d", stats.Changed)),
+			st.Failed.Render(fmt.Sprintf("failed=%d", stats.Failures)),
+			st.Unreachable.Render(fmt.Sprintf("unreachable=%d", stats.Unreachable)),
+			st.Skipped.Render(fmt.Sprintf("skipped=%d", stats.Skipped)),
+		)
+	}
+
+	return fmt.Sprintf("%-20s %s %s %s %s %s", host,
+		st.Ok.Render(fmt.Sprintf("ok=%d", p.progress.Count(host, events.StatusOk))),
+		st.Changed.Render(fmt.Sprintf("changed=%d", p.progress.Count(host, events.StatusChanged))),
+		st.Failed.Render(fmt.Sprintf("failed=%d", p.progress.Count(host, events.StatusFailed))),
+		st.Unreachable.Render(fmt.Sprintf("unreachable=%d", p.progress.Count(host, events.StatusUnreachable))),
+		st.Skipped.Render(fmt.Sprintf("skipped=%d", p.progress.Count(host, events.StatusSkipped))),
+	)
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (p *Progress) StatusBarValue() string {
+	if t := p.progress.Current(); t != nil {
+		return fmt.Sprintf("%s: %s", t.Play, t.Name)
+	}
+
+	return ""
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (p *Progress) StatusBarInfo() string {
+	if p.progress.Done() {
+		return fmt.Sprintf("%d tasks, done", len(p.progress.Tasks))
+	}
+
+	return fmt.Sprintf("%d tasks", len(p.progress.Tasks))
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (p *Progress) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", p.cfg.WebitelVersion)
+}
+
+// A command that waits for a playbook event on a channel.
+func waitForEvent(sub chan events.Event) tea.Cmd {
+	return func() tea.Msg {
+		return EventMsg{
+			event: <-sub,
+			sub:   sub,
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
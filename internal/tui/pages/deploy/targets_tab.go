@@ -0,0 +1,269 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/checklist"
+	"sort"
+	"strings"
+)
+
+// TargetsMsg is a message that contains the hosts and services selected for the deploy,
+// in the --limit and --tags format. An empty value means all of them.
+type TargetsMsg struct {
+	Limit string
+	Tags  string
+}
+
+// Targets lets the user pick the hosts and services to deploy.
+type Targets struct {
+	common   common.Common
+	repo     action.Action
+	hosts    *checklist.Checklist
+	services *checklist.Checklist
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewTargets creates a new targets model with every host and service selected.
+func NewTargets(common common.Common, cfg config.Config, logger logger.Logger) *Targets {
+	t := &Targets{
+		common:   common,
+		hosts:    checklist.New(common, "Hosts", inventoryHosts(cfg)),
+		services: checklist.New(common, "Services", inventoryServices(cfg)),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	t.hosts.CheckAll()
+	t.services.CheckAll()
+	t.hosts.Focus()
+
+	return t
+}
+
+// SetSize implements common.Component.
+func (t *Targets) SetSize(width, height int) {
+	t.common.SetSize(width, height)
+	t.hosts.SetSize(width/2, height-2)
+	t.services.SetSize(width-width/2, height-2)
+}
+
+// ShortHelp implements help.KeyMap.
+func (t *Targets) ShortHelp() []key.Binding {
+	return []key.Binding{
+		t.common.KeyMap.UpDown,
+		t.common.KeyMap.LeftRight,
+		t.hosts.KeyMap.Toggle,
+		t.hosts.KeyMap.ToggleAll,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (t *Targets) FullHelp() [][]key.Binding {
+	k := t.common.KeyMap
+	return [][]key.Binding{
+		{
+			k.Up,
+			k.Down,
+			k.Left,
+			k.Right,
+		},
+		{
+			t.hosts.KeyMap.Toggle,
+			t.hosts.KeyMap.ToggleAll,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (t *Targets) Init() tea.Cmd {
+	return t.targetsCmd
+}
+
+// Update implements tea.Model.
+func (t *Targets) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RepoMsg:
+		t.repo = action.Action(msg)
+		cmds = append(cmds, t.Init())
+	case ConfigChangedMsg:
+		if msg.ConfigFileType == config.InventoryConfig {
+			cmds = append(cmds, t.reload())
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, t.common.KeyMap.Left):
+			t.hosts.Focus()
+			t.services.Blur()
+		case key.Matches(msg, t.common.KeyMap.Right):
+			t.services.Focus()
+			t.hosts.Blur()
+		default:
+			limit, tags := t.Selection()
+
+			h, _ := t.hosts.Update(msg)
+			t.hosts = h.(*checklist.Checklist)
+			s, _ := t.services.Update(msg)
+			t.services = s.(*checklist.Checklist)
+
+			if l, g := t.Selection(); l != limit || g != tags {
+				cmds = append(cmds, t.targetsCmd)
+			}
+		}
+	}
+
+	return t, tea.Batch(cmds...)
+}
+
+// reload reads the inventory again and updates the lists. The selected hosts and services that are still in
+// the inventory stay selected, a list that had everything selected gets the new items selected too.
+func (t *Targets) reload() tea.Cmd {
+	if err := t.cfg.ReadToStruct(config.InventoryConfig); err != nil && !errors.Is(err, vault.ErrWrongPassword) {
+		// The page that wrote the file reports it.
+		t.logger.Zap.Error(err)
+
+		return nil
+	}
+
+	setItems(t.hosts, inventoryHosts(t.cfg))
+	setItems(t.services, inventoryServices(t.cfg))
+
+	return t.targetsCmd
+}
+
+// setItems replaces the items of c keeping its selection.
+func setItems(c *checklist.Checklist, items []string) {
+	all := c.AllChecked()
+	c.SetItems(items)
+	if all {
+		c.CheckAll()
+	}
+}
+
+// View implements tea.Model.
+func (t *Targets) View() string {
+	lists := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(t.common.Width/2).Render(t.hosts.View()),
+		t.services.View(),
+	)
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.NewStyle().Height(t.common.Height-2).Render(lists),
+		"",
+		t.common.Styles.Checklist.Title.Render(common.TruncateString(t.command(), t.common.Width-1)),
+	)
+}
+
+// Selection returns the selected hosts and services as --limit and --tags values,
+// empty when all of them are selected.
+func (t *Targets) Selection() (limit string, tags string) {
+	if !t.hosts.AllChecked() {
+		limit = strings.Join(t.hosts.Checked(), ",")
+	}
+	if !t.services.AllChecked() {
+		tags = strings.Join(t.services.Checked(), ",")
+	}
+
+	return limit, tags
+}
+
+// Validate returns an error when nothing would be deployed.
+func (t *Targets) Validate() error {
+	if len(t.hosts.Checked()) == 0 {
+		return errors.New("no hosts selected on the Targets tab")
+	}
+	if len(t.services.Checked()) == 0 {
+		return errors.New("no services selected on the Targets tab")
+	}
+
+	return nil
+}
+
+// command describes the selection as ansible-playbook arguments.
+func (t *Targets) command() string {
+	limit, tags := t.Selection()
+	s := "ansible-playbook"
+	if limit != "" {
+		s += " --limit " + limit
+	}
+	if tags != "" {
+		s += " --tags " + tags
+	}
+	if limit == "" && tags == "" {
+		s += ", all hosts and services"
+	}
+
+	return s
+}
+
+func (t *Targets) targetsCmd() tea.Msg {
+	limit, tags := t.Selection()
+
+	return TargetsMsg{Limit: limit, Tags: tags}
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (t *Targets) StatusBarValue() string {
+	return ""
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (t *Targets) StatusBarInfo() string {
+	return fmt.Sprintf("%d/%d hosts, %d/%d services", len(t.hosts.Checked()), len(t.hosts.Items()),
+		len(t.services.Checked()), len(t.services.Items()))
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (t *Targets) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", t.cfg.WebitelVersion)
+}
+
+func inventoryHosts(cfg config.Config) []string {
+	inventory := cfg.Inventory.Hosts()
+	hosts := make([]string, 0, len(inventory))
+	for h := range inventory {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// inventoryServices returns the services assigned to any host, known services first in deploy order.
+func inventoryServices(cfg config.Config) []string {
+	assigned := make(map[string]bool)
+	for _, h := range cfg.Inventory.Hosts() {
+		for _, s := range h.WebitelServices {
+			assigned[s] = true
+		}
+	}
+
+	services := make([]string, 0, len(assigned))
+	for _, s := range vars.WebitelServices {
+		if assigned[s] {
+			services = append(services, s)
+			delete(assigned, s)
+		}
+	}
+
+	unknown := make([]string, 0, len(assigned))
+	for s := range assigned {
+		unknown = append(unknown, s)
+	}
+	sort.Strings(unknown)
+
+	return append(services, unknown...)
+}
@@ -1,20 +1,32 @@
 package deploy
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/configdiff"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
 	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
-	tview "github.com/kirychukyurii/wdeploy/internal/templates/view"
+	"github.com/kirychukyurii/wdeploy/internal/lib/plan"
+	"github.com/kirychukyurii/wdeploy/internal/lib/summary"
+	"github.com/kirychukyurii/wdeploy/internal/lib/validator"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
 	"github.com/kirychukyurii/wdeploy/internal/tui/common"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/code"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/dialog"
-	"text/template"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
+	"path/filepath"
+	"strings"
+)
+
+var exportSummary = key.NewBinding(
+	key.WithKeys("e"),
+	key.WithHelp("e", "export summary"),
 )
 
 type ReadmeMsg struct{}
@@ -33,6 +45,8 @@ type View struct {
 	spinner        spinner.Model
 	currentContent FileContentMsg
 	lineNumber     bool
+	problems       validator.Problems // Problems of the inventory, shown above the dialog
+	exported       string             // Files the summary was exported to
 
 	cfg    config.Config
 	logger logger.Logger
@@ -46,7 +60,7 @@ func NewView(common common.Common, cfg config.Config, logger logger.Logger) *Vie
 	v := &View{
 		common:     common,
 		code:       code.New(common, "", ""),
-		dialog:     dialog.New(common, "Are you sure want to deploy Webitel?", []string{"Deploy", "Cancel"}),
+		dialog:     dialog.New(common, deployQuestion(TargetsMsg{}), []string{"Deploy", "Dry run", "Cancel"}),
 		spinner:    s,
 		lineNumber: true,
 
@@ -62,7 +76,7 @@ func NewView(common common.Common, cfg config.Config, logger logger.Logger) *Vie
 func (v *View) SetSize(width, height int) {
 	v.common.SetSize(width, height)
 	hm := v.common.Styles.Dialog.Box.GetHorizontalFrameSize()
-	v.code.SetSize(width, height-hm-3)
+	v.code.SetSize(width, height-hm-3-len(v.problemLines()))
 	v.dialog.SetSize(width, hm)
 }
 
@@ -73,6 +87,7 @@ func (v *View) ShortHelp() []key.Binding {
 		v.common.KeyMap.Select,
 		v.common.KeyMap.UpDown,
 		v.common.KeyMap.BackItem,
+		exportSummary,
 	}
 
 	return b
@@ -94,6 +109,7 @@ func (v *View) FullHelp() [][]key.Binding {
 		},
 		{
 			k.Select,
+			exportSummary,
 		},
 	}
 
@@ -102,25 +118,33 @@ func (v *View) FullHelp() [][]key.Binding {
 
 // Init implements tea.Model.
 func (v *View) Init() tea.Cmd {
-	var buf bytes.Buffer
+	v.code.GotoTop()
+	return tea.Batch(
+		v.dialog.Init(),
+		v.render(),
+	)
+}
 
-	tpl, err := template.New("").Parse(tview.Tmpl)
-	if err != nil {
-		v.logger.Zap.Debug(err)
+// render reads the config files again and shows the plan with the changes since the last successful deploy,
+// the scroll position is kept.
+func (v *View) render() tea.Cmd {
+	v.cfg.Variables, v.cfg.Inventory = config.Variables{}, config.Inventory{}
+	for _, configFileType := range []int{config.VarsConfig, config.InventoryConfig} {
+		if err := v.cfg.ReadToStruct(configFileType); err != nil && !errors.Is(err, vault.ErrWrongPassword) {
+			v.logger.Zap.Debug(err)
+		}
 	}
 
-	err = tpl.Execute(&buf, v.cfg)
+	view, err := plan.Render(v.cfg)
 	if err != nil {
 		v.logger.Zap.Debug(err)
 	}
 
-	view := buf.String()
+	// The changes go right after the title, above the hosts.
+	view = strings.Replace(view, "\n## ", "\n"+v.changes()+"## ", 1)
+	v.validate()
 
-	v.code.GotoTop()
-	return tea.Batch(
-		v.dialog.Init(),
-		v.code.SetContent(view, ".md"),
-	)
+	return v.code.SetContent(view, ".md")
 }
 
 // Update implements tea.Model.
@@ -138,6 +162,15 @@ func (v *View) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case RepoMsg:
 		v.repo = action.Action(msg)
 		cmds = append(cmds, v.Init())
+	case TargetsMsg:
+		v.dialog.SetQuestion(deployQuestion(msg))
+	case tabs.ActiveTabMsg, ConfigChangedMsg, DeployFinishedMsg:
+		// The config could be edited on the Variables and Hosts pages in the meantime.
+		cmds = append(cmds, v.render())
+	case tea.KeyMsg:
+		if key.Matches(msg, exportSummary) {
+			cmds = append(cmds, v.export())
+		}
 	}
 	d, cmd := v.dialog.Update(msg)
 	v.dialog = d.(*dialog.Dialog)
@@ -156,17 +189,125 @@ func (v *View) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View implements tea.Model.
 func (v *View) View() string {
+	lines := append([]string{v.code.View()}, v.problemLines()...)
 	view := lipgloss.JoinVertical(lipgloss.Top,
-		v.code.View(),
-		v.dialog.View(),
+		append(lines, v.dialog.View())...,
 	)
 
 	return view
 }
 
+// export writes the summary in every format to the logs directory of the profile.
+func (v *View) export() tea.Cmd {
+	s, err := summary.New(v.cfg)
+	if err != nil {
+		v.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+
+	files := make([]string, 0, len(summary.Formats))
+	for _, format := range summary.Formats {
+		content, err := s.Render(format)
+		if err != nil {
+			v.logger.Zap.Error(err)
+			return common.ErrorCmd(err)
+		}
+
+		name := fmt.Sprintf("webitel-%s-summary.%s", v.cfg.Profile, format)
+		if err = summary.WriteFile(filepath.Join(v.cfg.LogDirectory, name), content); err != nil {
+			v.logger.Zap.Error(err)
+			return common.ErrorCmd(err)
+		}
+
+		files = append(files, name)
+	}
+
+	v.exported = fmt.Sprintf("exported to %s: %s", v.cfg.LogDirectory, strings.Join(files, ", "))
+	v.logger.Zap.Infof("Summary %s", v.exported)
+
+	return nil
+}
+
+// changes returns the changes of the config since the last successful deploy in Markdown,
+// nothing before the first one.
+func (v *View) changes() string {
+	d, err := configdiff.New(v.cfg)
+	if err != nil {
+		if !errors.Is(err, history.ErrNotFound) {
+			v.logger.Zap.Debug(err)
+		}
+
+		return ""
+	}
+
+	return d.Markdown()
+}
+
+// validate checks the inventory again and makes room for its problems above the dialog.
+func (v *View) validate() {
+	problems, err := validator.ValidateConfigFile(v.cfg, config.InventoryConfig)
+	if err != nil {
+		v.logger.Zap.Debug(err)
+	}
+
+	v.problems = problems
+	v.SetSize(v.common.Width, v.common.Height)
+}
+
+// problemLines renders the first problems of the inventory, deploy is blocked on errors
+// and asks for confirmation on warnings.
+func (v *View) problemLines() []string {
+	const maxLines = 3
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	st := v.common.Styles.Problems
+	lines := make([]string, 0, maxLines+1)
+	for i, problem := range v.problems {
+		if i == maxLines {
+			lines = append(lines, st.Location.Render(fmt.Sprintf("  … and %d more on the Problems tab of the Hosts page",
+				len(v.problems)-maxLines)))
+			break
+		}
+
+		severity := st.Error.Render("✗")
+		if problem.Severity == validator.SeverityWarning {
+			severity = st.Warning.Render("⚠")
+		}
+
+		msg := problem.Message
+		if problem.Key != "" {
+			msg = fmt.Sprintf("%s: %s", problem.Key, problem.Message)
+		}
+
+		lines = append(lines, common.TruncateString(fmt.Sprintf("%s %s", severity, msg), v.common.Width))
+	}
+
+	return lines
+}
+
+// deployQuestion asks to confirm the deploy, naming the hosts and services picked on the Targets tab.
+func deployQuestion(targets TargetsMsg) string {
+	if targets.Limit == "" && targets.Tags == "" {
+		return "Are you sure want to deploy Webitel?"
+	}
+
+	hosts, services := "all hosts", "all services"
+	if targets.Limit != "" {
+		hosts = strings.ReplaceAll(targets.Limit, ",", ", ")
+	}
+	if targets.Tags != "" {
+		services = strings.ReplaceAll(targets.Tags, ",", ", ")
+	}
+
+	return fmt.Sprintf("Are you sure want to deploy %s to %s?", services, hosts)
+}
+
 // StatusBarValue implements statusbar.StatusBar.
 func (v *View) StatusBarValue() string {
-	return ""
+	return v.exported
 }
 
 // StatusBarInfo implements statusbar.StatusBar.
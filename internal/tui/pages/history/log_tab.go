@@ -0,0 +1,117 @@
+package history
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/code"
+)
+
+// Log shows the ansible-playbook output of the selected run.
+type Log struct {
+	common common.Common
+	code   *code.Code
+	run    history.Run
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewLog creates a new log model.
+func NewLog(common common.Common, cfg config.Config, logger logger.Logger) *Log {
+	l := &Log{
+		common: common,
+		code:   code.New(common, "", ""),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	l.code.SetShowLineNumber(false)
+	return l
+}
+
+// SetSize implements common.Component.
+func (l *Log) SetSize(width, height int) {
+	l.common.SetSize(width, height)
+	l.code.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (l *Log) ShortHelp() []key.Binding {
+	return []key.Binding{
+		l.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (l *Log) FullHelp() [][]key.Binding {
+	k := l.code.KeyMap
+	return [][]key.Binding{
+		{
+			k.Down,
+			k.Up,
+			k.PageDown,
+			k.PageUp,
+			k.HalfPageDown,
+			k.HalfPageUp,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (l *Log) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (l *Log) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RunMsg:
+		l.run = msg.run
+		content, err := msg.run.Log()
+		if err != nil {
+			l.logger.Zap.Error(err)
+		}
+
+		cmds = append(cmds, l.code.SetContent(content, code.PlainTextExt))
+		l.code.GotoBottom()
+	}
+
+	c, cmd := l.code.Update(msg)
+	l.code = c.(*code.Code)
+	if cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+
+	return l, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (l *Log) View() string {
+	return l.code.View()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (l *Log) StatusBarValue() string {
+	if l.run.ID == "" {
+		return ""
+	}
+
+	return l.run.LogFile()
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (l *Log) StatusBarInfo() string {
+	return fmt.Sprintf("☰ %.f%%", l.code.ScrollPercent()*100)
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (l *Log) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", l.cfg.WebitelVersion)
+}
@@ -0,0 +1,197 @@
+package history
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"strings"
+	"time"
+)
+
+// RunMsg is a message that contains the selected run, open switches to its summary.
+type RunMsg struct {
+	run  history.Run
+	open bool
+}
+
+// Runs is the list of recorded runs.
+type Runs struct {
+	common common.Common
+	repo   action.Action
+	store  history.Store
+	runs   []history.Run
+	cursor int
+	offset int
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewRuns creates a new runs model.
+func NewRuns(common common.Common, cfg config.Config, logger logger.Logger) *Runs {
+	return &Runs{
+		common: common,
+		store:  history.New(cfg),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// SetSize implements common.Component.
+func (r *Runs) SetSize(width, height int) {
+	r.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (r *Runs) ShortHelp() []key.Binding {
+	open := r.common.KeyMap.Select
+	open.SetHelp("enter", "open run")
+
+	return []key.Binding{
+		r.common.KeyMap.UpDown,
+		open,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (r *Runs) FullHelp() [][]key.Binding {
+	open := r.common.KeyMap.Select
+	open.SetHelp("enter", "open run")
+
+	return [][]key.Binding{
+		{
+			r.common.KeyMap.Up,
+			r.common.KeyMap.Down,
+			open,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (r *Runs) Init() tea.Cmd {
+	runs, err := r.store.List()
+	if err != nil {
+		r.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+
+	r.runs, r.cursor, r.offset = runs, 0, 0
+
+	return r.selectCmd(false)
+}
+
+// Update implements tea.Model.
+func (r *Runs) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RepoMsg:
+		r.repo = action.Action(msg)
+		cmds = append(cmds, r.Init())
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, r.common.KeyMap.Up):
+			if r.cursor > 0 {
+				r.cursor--
+				cmds = append(cmds, r.selectCmd(false))
+			}
+		case key.Matches(msg, r.common.KeyMap.Down):
+			if r.cursor < len(r.runs)-1 {
+				r.cursor++
+				cmds = append(cmds, r.selectCmd(false))
+			}
+		case key.Matches(msg, r.common.KeyMap.Select):
+			cmds = append(cmds, r.selectCmd(true))
+		}
+	}
+
+	return r, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (r *Runs) View() string {
+	st := r.common.Styles.History
+	if len(r.runs) == 0 {
+		return st.NoRuns.Render("No deploys yet")
+	}
+
+	// Keep the cursor visible below the header row.
+	height := r.common.Height - 1
+	if height < 1 {
+		height = 1
+	}
+	if r.cursor < r.offset {
+		r.offset = r.cursor
+	}
+	if r.cursor >= r.offset+height {
+		r.offset = r.cursor - height + 1
+	}
+
+	s := strings.Builder{}
+	s.WriteString(st.Header.Render(fmt.Sprintf(" %-20s %-19s %-7s %-10s %-9s %-8s %s",
+		"ID", "STARTED", "MODE", "STATUS", "DURATION", "COMMIT", "HOSTS")))
+	for i := r.offset; i < len(r.runs) && i < r.offset+height; i++ {
+		run := r.runs[i]
+		commit := run.PlaybookCommit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+
+		row := fmt.Sprintf("%-20s %-19s %-7s %s %-9s %-8s %s", run.ID, run.Start.Format(time.DateTime),
+			run.Mode(), r.status(run.Status), run.Duration.Round(time.Second), commit, strings.Join(run.Hosts, ", "))
+		row = common.TruncateString(row, r.common.Width-1)
+
+		s.WriteString("\n")
+		if i == r.cursor {
+			s.WriteString(st.Selected.Render(row))
+		} else {
+			s.WriteString(st.Row.Render(row))
+		}
+	}
+
+	return s.String()
+}
+
+func (r *Runs) status(status history.Status) string {
+	st := r.common.Styles.History
+	s := fmt.Sprintf("%-10s", status)
+	switch status {
+	case history.StatusSucceeded:
+		return st.Succeeded.Render(s)
+	case history.StatusFailed, history.StatusAborted:
+		return st.Failed.Render(s)
+	}
+
+	return st.Running.Render(s)
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (r *Runs) StatusBarValue() string {
+	return r.cfg.HistoryDirectory
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (r *Runs) StatusBarInfo() string {
+	return fmt.Sprintf("%d run(s)", len(r.runs))
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (r *Runs) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", r.cfg.WebitelVersion)
+}
+
+func (r *Runs) selectCmd(open bool) tea.Cmd {
+	if len(r.runs) == 0 {
+		return nil
+	}
+
+	run := r.runs[r.cursor]
+	return func() tea.Msg {
+		return RunMsg{run: run, open: open}
+	}
+}
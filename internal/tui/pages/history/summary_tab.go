@@ -0,0 +1,117 @@
+package history
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/history"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/code"
+)
+
+// Summary shows the summary of the selected run.
+type Summary struct {
+	common common.Common
+	code   *code.Code
+	run    history.Run
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewSummary creates a new summary model.
+func NewSummary(common common.Common, cfg config.Config, logger logger.Logger) *Summary {
+	s := &Summary{
+		common: common,
+		code:   code.New(common, "", ""),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	s.code.SetShowLineNumber(false)
+	return s
+}
+
+// SetSize implements common.Component.
+func (s *Summary) SetSize(width, height int) {
+	s.common.SetSize(width, height)
+	s.code.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (s *Summary) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (s *Summary) FullHelp() [][]key.Binding {
+	k := s.code.KeyMap
+	return [][]key.Binding{
+		{
+			k.Down,
+			k.Up,
+			k.PageDown,
+			k.PageUp,
+			k.HalfPageDown,
+			k.HalfPageUp,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (s *Summary) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (s *Summary) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RunMsg:
+		s.run = msg.run
+		content, err := msg.run.Summary()
+		if err != nil {
+			s.logger.Zap.Error(err)
+		}
+
+		cmds = append(cmds, s.code.SetContent(content, ".md"))
+		s.code.GotoTop()
+	}
+
+	c, cmd := s.code.Update(msg)
+	s.code = c.(*code.Code)
+	if cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+
+	return s, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (s *Summary) View() string {
+	return s.code.View()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (s *Summary) StatusBarValue() string {
+	if s.run.ID == "" {
+		return ""
+	}
+
+	return s.run.Dir()
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (s *Summary) StatusBarInfo() string {
+	return fmt.Sprintf("☰ %.f%%", s.code.ScrollPercent()*100)
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (s *Summary) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", s.cfg.WebitelVersion)
+}
@@ -0,0 +1,735 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/hostimport"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/validator"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/checklist"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/revisions"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	newHost = key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new host"),
+	)
+	deleteHost = key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete host"),
+	)
+	saveHosts = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "save"),
+	)
+	importHosts = key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "import hosts"),
+	)
+	revertHosts = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "discard changes"),
+	)
+	prevField = key.NewBinding(
+		key.WithKeys("up"),
+		key.WithHelp("↑/↓", "move between fields"),
+	)
+	nextField = key.NewBinding(
+		key.WithKeys("down"),
+	)
+	applyHost = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "apply"),
+	)
+	discardHost = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "discard"),
+	)
+
+	hostNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
+)
+
+// Fields of the host form, the text inputs go first.
+const (
+	nameField int = iota
+	addressField
+	userField
+	portField
+	keyField
+	servicesField
+)
+
+var fieldLabels = []string{
+	"Name",
+	"Address",
+	"User",
+	"Port",
+	"Private key",
+}
+
+const hostListWidth = 36
+
+// InventorySavedMsg is a message sent when the editor has written the inventory file.
+type InventorySavedMsg struct{}
+
+// Editor is a form-based editor of the inventory hosts.
+type Editor struct {
+	common   common.Common
+	repo     action.Action
+	hosts    []config.InventoryHost
+	groups   []config.InventoryGroup // Groups imported with the hosts, written on save
+	cursor   int
+	offset   int
+	inputs   []textinput.Model
+	services *checklist.Checklist
+	path     textinput.Model // The file to import hosts from
+
+	editing   bool // The form of the host under the cursor has focus
+	importing bool // The path of the file to import has focus
+	isNew     bool // The host in the form is added and not applied yet
+	focus     int
+	dirty     bool
+	message   string
+	failed    bool // message is an error
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewEditor creates a new inventory editor model.
+func NewEditor(common common.Common, cfg config.Config, logger logger.Logger) *Editor {
+	e := &Editor{
+		common:   common,
+		inputs:   make([]textinput.Model, servicesField),
+		services: checklist.New(common, "Services", vars.WebitelServices),
+		path:     textinput.New(),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	placeholders := []string{"node1", "IP address or hostname", "ansible_user of the variables", "22", "path to the key"}
+	for i := range e.inputs {
+		e.inputs[i] = textinput.New()
+		e.inputs[i].Prompt = ""
+		e.inputs[i].Placeholder = placeholders[i]
+	}
+	e.inputs[portField].CharLimit = 5
+	e.path.Prompt = ""
+	e.path.Placeholder = "hosts.ini, hosts.csv or ~/.ssh/config"
+
+	return e
+}
+
+// SetSize implements common.Component.
+func (e *Editor) SetSize(width, height int) {
+	e.common.SetSize(width, height)
+
+	formWidth := width - hostListWidth - 2
+	labelWidth := e.common.Styles.Form.Label.GetWidth()
+	for i := range e.inputs {
+		e.inputs[i].Width = formWidth - labelWidth - 1
+	}
+	e.path.Width = formWidth - labelWidth - 1
+
+	// The text inputs, the groups and variables, a blank line and the message line are above and below the services.
+	e.services.SetSize(formWidth, height-len(e.inputs)-5)
+}
+
+// IsEditing reports whether key presses go to the host form or the path of the file to import.
+func (e *Editor) IsEditing() bool {
+	return e.editing || e.importing
+}
+
+// ShortHelp implements help.KeyMap.
+func (e *Editor) ShortHelp() []key.Binding {
+	if e.importing {
+		return e.importHelp()
+	}
+
+	if e.editing {
+		return []key.Binding{
+			prevField,
+			e.services.KeyMap.Toggle,
+			applyHost,
+			discardHost,
+		}
+	}
+
+	edit := e.common.KeyMap.Select
+	edit.SetHelp("enter", "edit host")
+
+	return []key.Binding{
+		e.common.KeyMap.UpDown,
+		edit,
+		newHost,
+		deleteHost,
+		importHosts,
+		saveHosts,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (e *Editor) FullHelp() [][]key.Binding {
+	if e.importing {
+		return [][]key.Binding{e.importHelp()}
+	}
+
+	if e.editing {
+		return [][]key.Binding{
+			{
+				prevField,
+				e.services.KeyMap.Toggle,
+				e.services.KeyMap.ToggleAll,
+			},
+			{
+				applyHost,
+				discardHost,
+			},
+		}
+	}
+
+	edit := e.common.KeyMap.Select
+	edit.SetHelp("enter", "edit host")
+
+	return [][]key.Binding{
+		{
+			e.common.KeyMap.Up,
+			e.common.KeyMap.Down,
+			edit,
+		},
+		{
+			newHost,
+			deleteHost,
+			importHosts,
+		},
+		{
+			saveHosts,
+			revertHosts,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (e *Editor) Init() tea.Cmd {
+	hosts, err := e.cfg.InventoryHosts()
+	if err != nil {
+		e.logger.Zap.Error(err)
+		e.hosts = nil
+		e.setMessage(fmt.Sprintf("%s, fix it in the Config tab", err), true)
+
+		return nil
+	}
+
+	e.hosts, e.groups, e.dirty, e.editing, e.importing = hosts, nil, false, false, false
+	if e.cursor >= len(e.hosts) {
+		e.cursor = 0
+	}
+	e.fill()
+
+	return nil
+}
+
+// Update implements tea.Model.
+func (e *Editor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RepoMsg:
+		e.repo = action.Action(msg)
+		e.setMessage("", false)
+		cmds = append(cmds, e.Init())
+	case tabs.ActiveTabMsg:
+		// The file could be edited in the Config tab in the meantime.
+		if !e.dirty && !e.IsEditing() {
+			cmds = append(cmds, e.Init())
+		}
+	case revisions.RestoredMsg:
+		// The restored file replaces the changes that were not saved.
+		e.setMessage("", false)
+		cmds = append(cmds, e.Init())
+	case tea.KeyMsg:
+		if e.importing {
+			return e, e.updateImport(msg)
+		}
+
+		if e.editing {
+			return e, e.updateForm(msg)
+		}
+
+		switch {
+		case key.Matches(msg, e.common.KeyMap.Up):
+			if e.cursor > 0 {
+				e.cursor--
+				e.fill()
+			}
+		case key.Matches(msg, e.common.KeyMap.Down):
+			if e.cursor < len(e.hosts)-1 {
+				e.cursor++
+				e.fill()
+			}
+		case key.Matches(msg, e.common.KeyMap.Select), key.Matches(msg, e.common.KeyMap.EditItem):
+			if len(e.hosts) > 0 {
+				cmds = append(cmds, e.openForm(false))
+			}
+		case key.Matches(msg, newHost):
+			e.hosts = append(e.hosts, config.InventoryHost{Name: e.newHostName()})
+			e.cursor = len(e.hosts) - 1
+			e.fill()
+			cmds = append(cmds, e.openForm(true))
+		case key.Matches(msg, deleteHost):
+			if len(e.hosts) > 0 {
+				name := e.hosts[e.cursor].Name
+				e.hosts = append(e.hosts[:e.cursor], e.hosts[e.cursor+1:]...)
+				if e.cursor >= len(e.hosts) && e.cursor > 0 {
+					e.cursor--
+				}
+				e.dirty = true
+				e.fill()
+				e.setMessage(fmt.Sprintf("Deleted %s, press ctrl+s to save", name), false)
+			}
+		case key.Matches(msg, importHosts):
+			e.importing = true
+			e.setMessage("", false)
+			e.path.CursorEnd()
+			cmds = append(cmds, e.path.Focus())
+		case key.Matches(msg, saveHosts):
+			cmds = append(cmds, e.save())
+		case key.Matches(msg, revertHosts):
+			e.setMessage("", false)
+			cmds = append(cmds, e.Init())
+		}
+	default:
+		// Keep the cursor of the focused text input blinking.
+		if e.importing {
+			var cmd tea.Cmd
+			e.path, cmd = e.path.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+		if e.editing && e.focus < servicesField {
+			var cmd tea.Cmd
+			e.inputs[e.focus], cmd = e.inputs[e.focus].Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	return e, tea.Batch(cmds...)
+}
+
+// updateForm handles a key press while the host form has focus.
+func (e *Editor) updateForm(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, discardHost):
+		if e.isNew {
+			e.hosts = append(e.hosts[:e.cursor], e.hosts[e.cursor+1:]...)
+			if e.cursor >= len(e.hosts) && e.cursor > 0 {
+				e.cursor--
+			}
+		}
+		e.closeForm()
+		e.setMessage("", false)
+
+		return nil
+	case key.Matches(msg, applyHost):
+		h, err := e.formHost()
+		if err != nil {
+			e.setMessage(err.Error(), true)
+			return nil
+		}
+
+		e.hosts[e.cursor] = h
+		e.dirty = true
+		e.closeForm()
+		e.setMessage(fmt.Sprintf("Applied %s, press ctrl+s to save", h.Name), false)
+
+		return nil
+	case key.Matches(msg, prevField):
+		if e.focus == servicesField && e.services.Cursor() > 0 {
+			break
+		}
+		if e.focus > nameField {
+			return e.focusField(e.focus - 1)
+		}
+
+		return nil
+	case key.Matches(msg, nextField):
+		if e.focus < servicesField {
+			return e.focusField(e.focus + 1)
+		}
+	}
+
+	if e.focus == servicesField {
+		_, cmd := e.services.Update(msg)
+		return cmd
+	}
+
+	var cmd tea.Cmd
+	e.inputs[e.focus], cmd = e.inputs[e.focus].Update(msg)
+
+	return cmd
+}
+
+// updateImport handles a key press while the path of the file to import has focus.
+func (e *Editor) updateImport(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, discardHost):
+		e.importing = false
+		e.path.Blur()
+		e.setMessage("", false)
+
+		return nil
+	case key.Matches(msg, applyHost):
+		if err := e.importFile(strings.TrimSpace(e.path.Value())); err != nil {
+			e.logger.Zap.Error(err)
+			e.setMessage(err.Error(), true)
+
+			return nil
+		}
+
+		e.importing = false
+		e.path.Blur()
+
+		return nil
+	}
+
+	var cmd tea.Cmd
+	e.path, cmd = e.path.Update(msg)
+
+	return cmd
+}
+
+// importFile adds the hosts of the file to the hosts of the editor, they are saved with ctrl+s.
+func (e *Editor) importFile(path string) error {
+	if path == "" {
+		return errors.New("enter the path of an INI inventory, a CSV host list or ssh_config")
+	}
+
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	format, err := hostimport.Detect(path, content)
+	if err != nil {
+		return err
+	}
+
+	result, err := hostimport.Parse(format, strings.NewReader(string(content)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(result.Hosts) == 0 {
+		return fmt.Errorf("%s: no hosts found in the %s file", path, format)
+	}
+	for _, w := range result.Warnings {
+		e.logger.Zap.Warnf("%s: %s", path, w)
+	}
+
+	hosts, added, updated := hostimport.Merge(e.hosts, result.Hosts)
+	e.hosts, e.groups, e.dirty = hosts, append(e.groups, result.Groups...), true
+	if len(added) > 0 {
+		e.cursor = len(e.hosts) - len(added)
+	}
+	e.fill()
+
+	message := fmt.Sprintf("Imported %d host(s): %d added, %d updated", len(result.Hosts), len(added), len(updated))
+	if len(result.Groups) > 0 {
+		message += fmt.Sprintf(", %d group(s)", len(result.Groups))
+	}
+	if len(result.Warnings) > 0 {
+		message += fmt.Sprintf(", %d warning(s) in the log", len(result.Warnings))
+	}
+	e.setMessage(message+", assign services and press ctrl+s to save", false)
+
+	return nil
+}
+
+func (e *Editor) importHelp() []key.Binding {
+	apply := applyHost
+	apply.SetHelp("enter", "import")
+	cancel := discardHost
+	cancel.SetHelp("esc", "cancel")
+
+	return []key.Binding{apply, cancel}
+}
+
+// View implements tea.Model.
+func (e *Editor) View() string {
+	form := lipgloss.NewStyle().
+		Width(e.common.Width - hostListWidth - 2).
+		MarginLeft(2).
+		Render(e.formView())
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, e.listView(), form)
+}
+
+func (e *Editor) listView() string {
+	st := e.common.Styles.History
+	style := lipgloss.NewStyle().Width(hostListWidth)
+	if len(e.hosts) == 0 {
+		return style.Render(st.NoRuns.Render("No hosts, press n to add one"))
+	}
+
+	// Keep the cursor visible below the header row.
+	height := e.common.Height - 1
+	if height < 1 {
+		height = 1
+	}
+	if e.cursor < e.offset {
+		e.offset = e.cursor
+	}
+	if e.cursor >= e.offset+height {
+		e.offset = e.cursor - height + 1
+	}
+
+	s := strings.Builder{}
+	s.WriteString(st.Header.Render(fmt.Sprintf(" %-16s %s", "HOST", "ADDRESS")))
+	for i := e.offset; i < len(e.hosts) && i < e.offset+height; i++ {
+		h := e.hosts[i]
+		row := common.TruncateString(fmt.Sprintf("%-16s %s", h.Name, h.AnsibleHost), hostListWidth-1)
+
+		s.WriteString("\n")
+		if i == e.cursor {
+			s.WriteString(st.Selected.Render(row))
+		} else {
+			s.WriteString(st.Row.Render(row))
+		}
+	}
+
+	return style.Render(s.String())
+}
+
+func (e *Editor) formView() string {
+	st := e.common.Styles.Form
+	if e.importing {
+		return fmt.Sprintf("%s %s\n\n%s\n%s",
+			st.FocusedLabel.Render("Import from"), e.path.View(),
+			st.Info.Render("An INI inventory, a CSV host list or ssh_config, the format is guessed from the file"),
+			e.messageView())
+	}
+
+	if len(e.hosts) == 0 {
+		return e.messageView()
+	}
+
+	s := strings.Builder{}
+	for i, input := range e.inputs {
+		label := st.Label.Render(fieldLabels[i])
+		if e.editing && e.focus == i {
+			label = st.FocusedLabel.Render(fieldLabels[i])
+		}
+
+		s.WriteString(fmt.Sprintf("%s %s\n", label, input.View()))
+	}
+
+	// Groups and other variables are kept as they are, they are edited in the Config tab.
+	h := e.hosts[e.cursor]
+	groups, other := "-", "-"
+	if len(h.Groups) > 0 {
+		groups = strings.Join(h.Groups, ", ")
+	}
+	if len(h.Vars) > 0 {
+		keys := make([]string, 0, len(h.Vars))
+		for k := range h.Vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		other = strings.Join(keys, ", ")
+	}
+	s.WriteString(fmt.Sprintf("%s %s\n", st.Label.Render("Groups"), common.TruncateString(groups, e.inputs[0].Width)))
+	s.WriteString(fmt.Sprintf("%s %s\n", st.Label.Render("Other vars"), common.TruncateString(other, e.inputs[0].Width)))
+
+	s.WriteString("\n")
+	s.WriteString(e.services.View())
+	s.WriteString("\n")
+	s.WriteString(e.messageView())
+
+	return s.String()
+}
+
+func (e *Editor) messageView() string {
+	st := e.common.Styles.Form
+	switch {
+	case e.message != "" && e.failed:
+		return st.Error.Render("✗ " + e.message)
+	case e.message != "":
+		return st.Info.Render(e.message)
+	case e.dirty:
+		return st.Modified.Render("● unsaved changes, press ctrl+s to save")
+	}
+
+	return ""
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (e *Editor) StatusBarValue() string {
+	return e.cfg.ConfigFiles[config.InventoryConfig]
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (e *Editor) StatusBarInfo() string {
+	if e.dirty {
+		return fmt.Sprintf("%d host(s), modified", len(e.hosts))
+	}
+
+	return fmt.Sprintf("%d host(s)", len(e.hosts))
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (e *Editor) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", e.cfg.WebitelVersion)
+}
+
+// fill shows the host under the cursor in the form.
+func (e *Editor) fill() {
+	if len(e.hosts) == 0 {
+		return
+	}
+
+	h := e.hosts[e.cursor]
+	port := ""
+	if h.AnsiblePort != 0 {
+		port = strconv.Itoa(h.AnsiblePort)
+	}
+
+	values := []string{h.Name, h.AnsibleHost, h.AnsibleUser, port, h.AnsibleSSHPrivateKeyFile}
+	for i := range e.inputs {
+		e.inputs[i].SetValue(values[i])
+		e.inputs[i].Blur()
+	}
+
+	// Services unknown to wdeploy are listed too, so they are not lost on save.
+	items := append([]string(nil), vars.WebitelServices...)
+	for _, s := range h.WebitelServices {
+		known := false
+		for _, item := range items {
+			known = known || item == s
+		}
+		if !known {
+			items = append(items, s)
+		}
+	}
+
+	e.services.SetItems(items)
+	e.services.SetChecked(h.WebitelServices)
+	e.services.SetCursor(0)
+	e.services.Blur()
+}
+
+func (e *Editor) openForm(isNew bool) tea.Cmd {
+	e.editing, e.isNew = true, isNew
+	e.setMessage("", false)
+
+	return e.focusField(nameField)
+}
+
+func (e *Editor) closeForm() {
+	e.editing, e.isNew = false, false
+	e.fill()
+}
+
+func (e *Editor) focusField(field int) tea.Cmd {
+	e.focus = field
+	for i := range e.inputs {
+		e.inputs[i].Blur()
+	}
+	e.services.Blur()
+
+	if field == servicesField {
+		e.services.Focus()
+		return nil
+	}
+
+	e.inputs[field].CursorEnd()
+
+	return e.inputs[field].Focus()
+}
+
+// formHost returns the host in the form or the first problem found in it.
+func (e *Editor) formHost() (config.InventoryHost, error) {
+	h := e.hosts[e.cursor]
+	h.Name = strings.TrimSpace(e.inputs[nameField].Value())
+	h.AnsibleHost = strings.TrimSpace(e.inputs[addressField].Value())
+	h.AnsibleUser = strings.TrimSpace(e.inputs[userField].Value())
+	h.AnsibleSSHPrivateKeyFile = strings.TrimSpace(e.inputs[keyField].Value())
+	h.WebitelServices = e.services.Checked()
+
+	if !hostNameRegexp.MatchString(h.Name) {
+		return h, errors.New("name: use letters, digits, '.', '_' and '-'")
+	}
+	for i, other := range e.hosts {
+		if i != e.cursor && other.Name == h.Name {
+			return h, fmt.Errorf("name: host %s already exists", h.Name)
+		}
+	}
+
+	if !validator.IsHost(h.AnsibleHost) {
+		return h, fmt.Errorf("address: %q is neither an IP address nor a hostname", h.AnsibleHost)
+	}
+
+	h.AnsiblePort = 0
+	if port := strings.TrimSpace(e.inputs[portField].Value()); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return h, fmt.Errorf("port: expected a number between 1 and 65535, got %q", port)
+		}
+		h.AnsiblePort = p
+	}
+
+	return h, nil
+}
+
+// newHostName returns the first free nodeN name.
+func (e *Editor) newHostName() string {
+	for n := len(e.hosts) + 1; ; n++ {
+		name := fmt.Sprintf("node%d", n)
+		free := true
+		for _, h := range e.hosts {
+			free = free && h.Name != name
+		}
+		if free {
+			return name
+		}
+	}
+}
+
+func (e *Editor) save() tea.Cmd {
+	if err := e.cfg.ImportInventory(e.hosts, e.groups); err != nil {
+		e.logger.Zap.Error(err)
+		e.setMessage(err.Error(), true)
+
+		return nil
+	}
+
+	e.Init()
+	e.setMessage(fmt.Sprintf("Saved to %s", e.cfg.ConfigFiles[config.InventoryConfig]), false)
+
+	return func() tea.Msg {
+		return InventorySavedMsg{}
+	}
+}
+
+func (e *Editor) setMessage(message string, failed bool) {
+	e.message, e.failed = message, failed
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/kirychukyurii/wdeploy/internal/tui/common"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/footer"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/problems"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/revisions"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/statusbar"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
 )
@@ -19,6 +21,9 @@ type tab int
 
 const (
 	configTab tab = iota
+	editorTab
+	problemsTab
+	revisionsTab
 
 	lastTab
 )
@@ -26,6 +31,9 @@ const (
 func (t tab) String() string {
 	return []string{
 		"Config",
+		"Editor",
+		"Problems",
+		"Revisions",
 	}[t]
 }
 
@@ -60,16 +68,22 @@ func New(c common.Common, cfg config.Config, logger logger.Logger) *Inventory {
 	sb := statusbar.New(c)
 	ts := make([]string, lastTab)
 	// Tabs must match the order of tab constants above.
-	for i, t := range []tab{configTab} {
+	for i, t := range []tab{configTab, editorTab, problemsTab, revisionsTab} {
 		ts[i] = t.String()
 	}
 	tb := tabs.New(c, ts)
 
+	problemList := problems.New(c, cfg, config.InventoryConfig, logger)
+	revisionList := revisions.New(c, cfg, config.InventoryConfig, logger)
 	config := NewConfig(c, cfg, logger)
+	editor := NewEditor(c, cfg, logger)
 
 	// Make sure the order matches the order of tab constants above.
 	panes := []common.Component{
 		config,
+		editor,
+		problemList,
+		revisionList,
 	}
 
 	i := &Inventory{
@@ -125,6 +139,19 @@ func (i *Inventory) FullHelp() [][]key.Binding {
 	return b
 }
 
+// IsEditing reports whether the host form of the Editor tab or the restore dialog of the Revisions tab
+// takes key presses.
+func (i *Inventory) IsEditing() bool {
+	switch p := i.panes[i.activeTab].(type) {
+	case *Editor:
+		return p.IsEditing()
+	case *revisions.Revisions:
+		return p.IsConfirming()
+	}
+
+	return false
+}
+
 // Init implements tea.View.
 func (i *Inventory) Init() tea.Cmd {
 	return tea.Batch(
@@ -145,6 +172,11 @@ func (i *Inventory) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			i.updateStatusBarCmd,
 			i.updateModels(msg),
 		)
+	case InventorySavedMsg, revisions.RestoredMsg:
+		// Every pane shows the file, the pane that wrote it has already reloaded it.
+		cmds = append(cmds, i.updateModels(msg), i.updateStatusBarCmd)
+
+		return i, tea.Batch(cmds...)
 	case tabs.SelectTabMsg:
 		i.activeTab = tab(msg)
 		t, cmd := i.tabs.Update(msg)
@@ -0,0 +1,203 @@
+package profiles
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/profile"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"strings"
+	"time"
+)
+
+// SwitchProfileMsg is a message that contains the config of the profile picked in the list.
+type SwitchProfileMsg struct {
+	Config config.Config
+}
+
+// profileRow is a profile with its last run rendered for the list.
+type profileRow struct {
+	profile profile.Profile
+	lastRun string
+}
+
+// List is the list of profiles.
+type List struct {
+	common   common.Common
+	repo     action.Action
+	profiles []profileRow
+	cursor   int
+	offset   int
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewList creates a new profiles list model.
+func NewList(common common.Common, cfg config.Config, logger logger.Logger) *List {
+	return &List{
+		common: common,
+
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// SetSize implements common.Component.
+func (l *List) SetSize(width, height int) {
+	l.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (l *List) ShortHelp() []key.Binding {
+	pick := l.common.KeyMap.Select
+	pick.SetHelp("enter", "switch profile")
+
+	return []key.Binding{
+		l.common.KeyMap.UpDown,
+		pick,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (l *List) FullHelp() [][]key.Binding {
+	pick := l.common.KeyMap.Select
+	pick.SetHelp("enter", "switch profile")
+
+	return [][]key.Binding{
+		{
+			l.common.KeyMap.Up,
+			l.common.KeyMap.Down,
+			pick,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (l *List) Init() tea.Cmd {
+	profiles, err := profile.List()
+	if err != nil {
+		l.logger.Zap.Error(err)
+		return common.ErrorCmd(err)
+	}
+
+	l.profiles, l.cursor, l.offset = make([]profileRow, 0, len(profiles)), 0, 0
+	for i, p := range profiles {
+		row := profileRow{profile: p, lastRun: "-"}
+		if run, err := p.LastRun(); err == nil {
+			row.lastRun = fmt.Sprintf("%s %s", run.Start.Format(time.DateTime), run.Status)
+		}
+		if p.Name == l.cfg.Profile {
+			l.cursor = i
+		}
+
+		l.profiles = append(l.profiles, row)
+	}
+
+	return nil
+}
+
+// Update implements tea.Model.
+func (l *List) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RepoMsg:
+		l.repo = action.Action(msg)
+		cmds = append(cmds, l.Init())
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, l.common.KeyMap.Up):
+			if l.cursor > 0 {
+				l.cursor--
+			}
+		case key.Matches(msg, l.common.KeyMap.Down):
+			if l.cursor < len(l.profiles)-1 {
+				l.cursor++
+			}
+		case key.Matches(msg, l.common.KeyMap.Select):
+			cmds = append(cmds, l.switchCmd())
+		}
+	}
+
+	return l, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (l *List) View() string {
+	st := l.common.Styles.History
+	if len(l.profiles) == 0 {
+		return st.NoRuns.Render("No profiles yet, create one with `wdeploy profile create`")
+	}
+
+	// Keep the cursor visible below the header row.
+	height := l.common.Height - 1
+	if height < 1 {
+		height = 1
+	}
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+height {
+		l.offset = l.cursor - height + 1
+	}
+
+	s := strings.Builder{}
+	s.WriteString(st.Header.Render(fmt.Sprintf(" %-1s %-20s %-29s %s", "", "NAME", "LAST DEPLOY", "DIRECTORY")))
+	for i := l.offset; i < len(l.profiles) && i < l.offset+height; i++ {
+		p := l.profiles[i]
+		mark := " "
+		if p.profile.Name == l.cfg.Profile {
+			mark = "*"
+		}
+
+		row := fmt.Sprintf("%s %-20s %-29s %s", mark, p.profile.Name, p.lastRun, p.profile.Dir)
+		row = common.TruncateString(row, l.common.Width-1)
+
+		s.WriteString("\n")
+		if i == l.cursor {
+			s.WriteString(st.Selected.Render(row))
+		} else {
+			s.WriteString(st.Row.Render(row))
+		}
+	}
+
+	return s.String()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (l *List) StatusBarValue() string {
+	return config.ProfilesDir()
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (l *List) StatusBarInfo() string {
+	return fmt.Sprintf("active: %s", l.cfg.Profile)
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (l *List) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", l.cfg.WebitelVersion)
+}
+
+// switchCmd loads the config of the profile under the cursor.
+func (l *List) switchCmd() tea.Cmd {
+	if len(l.profiles) == 0 || l.profiles[l.cursor].profile.Name == l.cfg.Profile {
+		return nil
+	}
+
+	name, cfg, log := l.profiles[l.cursor].profile.Name, l.cfg, l.logger
+	return func() tea.Msg {
+		// Config loading reports to its writer, which must not reach the terminal under the TUI.
+		out := bytes.Buffer{}
+		next := cfg.SwitchProfile(name, &out)
+		if s := strings.TrimSpace(out.String()); s != "" {
+			log.Zap.Infof("Switching to profile %s:\n%s", name, s)
+		}
+
+		return SwitchProfileMsg{Config: next}
+	}
+}
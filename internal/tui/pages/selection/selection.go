@@ -170,6 +170,16 @@ func (s *Selection) Init() tea.Cmd {
 			Name:    "Deploy Webitel",
 			Action:  "You are one step closer to deploy Webitel services! Choose this and go on",
 		},
+		action.ActionItem{
+			Command: "history",
+			Name:    "Deploy history",
+			Action:  "Past deploys with their status, summary and log",
+		},
+		action.ActionItem{
+			Command: "profiles",
+			Name:    "Profiles",
+			Action:  "Switch between deployment profiles, each with its own variables, hosts and history",
+		},
 	}
 
 	for _, a := range actions {
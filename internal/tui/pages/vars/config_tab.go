@@ -12,6 +12,7 @@ import (
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/code"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/editor"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/revisions"
 )
 
 var (
@@ -19,6 +20,10 @@ var (
 		key.WithKeys("l"),
 		key.WithHelp("l", "toggle line numbers"),
 	)
+	revealSecrets = key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "reveal secrets"),
+	)
 )
 
 type ReadmeMsg struct{}
@@ -36,6 +41,7 @@ type Config struct {
 	repo           action.Action
 	currentContent FileContentMsg
 	lineNumber     bool
+	reveal         bool
 
 	cfg    config.Config
 	logger logger.Logger
@@ -71,6 +77,7 @@ func (c *Config) ShortHelp() []key.Binding {
 		c.common.KeyMap.BackItem,
 		c.common.KeyMap.EditItem,
 		copyKey,
+		revealSecrets,
 	}
 	lexer := lexers.Match(c.currentContent.ext)
 	lang := ""
@@ -107,6 +114,7 @@ func (c *Config) FullHelp() [][]key.Binding {
 		k.Down,
 		k.Up,
 		copyKey,
+		revealSecrets,
 	}
 	lexer := lexers.Match(c.currentContent.ext)
 	lang := ""
@@ -132,9 +140,10 @@ func (c *Config) Init() tea.Cmd {
 		return nil
 	}
 
+	c.currentContent = FileContentMsg{content: varsConfig, ext: ".yml"}
 	c.code.GotoTop()
 	return tea.Batch(
-		c.code.SetContent(varsConfig, ".yml"),
+		c.code.SetContent(c.cfg.MaskSecrets(varsConfig, c.reveal), ".yml"),
 	)
 }
 
@@ -155,7 +164,10 @@ func (c *Config) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, lineNo):
 			c.lineNumber = !c.lineNumber
 			c.code.SetShowLineNumber(c.lineNumber)
-			cmds = append(cmds, c.code.SetContent(c.currentContent.content, c.currentContent.ext))
+			cmds = append(cmds, c.code.SetContent(c.cfg.MaskSecrets(c.currentContent.content, c.reveal), c.currentContent.ext))
+		case key.Matches(msg, revealSecrets):
+			c.reveal = !c.reveal
+			cmds = append(cmds, c.code.SetContent(c.cfg.MaskSecrets(c.currentContent.content, c.reveal), c.currentContent.ext))
 		case key.Matches(msg, c.common.KeyMap.EditItem):
 			return c, c.editConfig()
 		case key.Matches(msg, c.common.KeyMap.Select):
@@ -163,12 +175,14 @@ func (c *Config) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case FileContentMsg:
 		c.currentContent = msg
-		c.code.SetContent(msg.content, msg.ext)
+		c.code.SetContent(c.cfg.MaskSecrets(msg.content, c.reveal), msg.ext)
 		c.code.GotoTop()
 		cmds = append(cmds, updateStatusBarCmd)
 	case RepoMsg:
 		c.repo = action.Action(msg)
 		cmds = append(cmds, c.Init())
+	case VarsSavedMsg, revisions.RestoredMsg:
+		cmds = append(cmds, c.Init())
 
 	}
 	co, cmd := c.code.Update(msg)
@@ -200,6 +214,10 @@ func (c *Config) StatusBarBranch() string {
 }
 
 func (c *Config) updateFileContent() tea.Msg {
+	if _, err := c.cfg.EncryptSecrets(config.VarsConfig); err != nil {
+		c.logger.Zap.Error(err)
+	}
+
 	varsConfig, err := file.ReadFileContent(c.cfg.ConfigFiles[config.VarsConfig])
 	if err != nil {
 		return nil
@@ -212,8 +230,12 @@ func (c *Config) updateFileContent() tea.Msg {
 	return FileContentMsg{content: varsConfig, ext: ".yml"}
 }
 
-// editConfig opens the editor.
+// editConfig opens the editor, the content before the session is kept as a revision.
 func (c *Config) editConfig() tea.Cmd {
+	if err := c.cfg.SaveRevision(config.VarsConfig); err != nil {
+		c.logger.Zap.Error(err)
+	}
+
 	return tea.ExecProcess(editor.Cmd(c.cfg.ConfigFiles[config.VarsConfig]), func(err error) tea.Msg {
 		return c.updateFileContent()
 	})
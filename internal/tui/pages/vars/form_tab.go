@@ -0,0 +1,616 @@
+package vars
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/constants/vars"
+	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
+	"github.com/kirychukyurii/wdeploy/internal/lib/validator"
+	"github.com/kirychukyurii/wdeploy/internal/lib/vault"
+	"github.com/kirychukyurii/wdeploy/internal/tui/common"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/checklist"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/revisions"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	editField = key.NewBinding(
+		key.WithKeys("enter", " "),
+		key.WithHelp("enter", "edit/toggle"),
+	)
+	nextChoice = key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("←/→", "choose value"),
+	)
+	prevChoice = key.NewBinding(
+		key.WithKeys("left", "h"),
+	)
+	saveVars = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "save"),
+	)
+	revertVars = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "discard changes"),
+	)
+	applyField = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "apply"),
+	)
+	cancelField = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	)
+)
+
+// fieldKind is the widget used to edit a variable.
+type fieldKind int
+
+const (
+	toggleField fieldKind = iota
+	selectField
+	textField
+	numberField
+	multiSelectField
+)
+
+// varField is a variable of config.Variables shown in the form.
+type varField struct {
+	key     string
+	kind    fieldKind
+	choices []string
+	secret  bool
+
+	// value is a bool for toggles, a []string for multi-selects and a string for the others.
+	value   any
+	changed bool
+}
+
+// VarsSavedMsg is a message sent when the form has written the variables file.
+type VarsSavedMsg struct{}
+
+// Form is a form editor of the variables generated from config.Variables.
+type Form struct {
+	common common.Common
+	repo   action.Action
+	fields []*varField
+	cursor int
+	offset int
+	input  textinput.Model
+	multi  *checklist.Checklist
+
+	editing bool // The text input or the multi-select of the field under the cursor has focus
+	message string
+	failed  bool // message is an error
+
+	cfg    config.Config
+	logger logger.Logger
+}
+
+// NewForm creates a new variables form model.
+func NewForm(common common.Common, cfg config.Config, logger logger.Logger) *Form {
+	f := &Form{
+		common: common,
+		input:  textinput.New(),
+		multi:  checklist.New(common, "", nil),
+
+		cfg:    cfg,
+		logger: logger,
+	}
+	f.input.Prompt = ""
+
+	return f
+}
+
+// SetSize implements common.Component.
+func (f *Form) SetSize(width, height int) {
+	f.common.SetSize(width, height)
+	f.input.Width = width - f.labelWidth() - 4
+	f.multi.SetSize(width, height-1)
+}
+
+// IsEditing reports whether key presses go to a field.
+func (f *Form) IsEditing() bool {
+	return f.editing
+}
+
+// ShortHelp implements help.KeyMap.
+func (f *Form) ShortHelp() []key.Binding {
+	if f.editing {
+		b := []key.Binding{applyField, cancelField}
+		if f.fields[f.cursor].kind == multiSelectField {
+			b = append([]key.Binding{f.common.KeyMap.UpDown, f.multi.KeyMap.Toggle}, b...)
+		}
+
+		return b
+	}
+
+	return []key.Binding{
+		f.common.KeyMap.UpDown,
+		editField,
+		nextChoice,
+		saveVars,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (f *Form) FullHelp() [][]key.Binding {
+	if f.editing {
+		return [][]key.Binding{f.ShortHelp()}
+	}
+
+	return [][]key.Binding{
+		{
+			f.common.KeyMap.Up,
+			f.common.KeyMap.Down,
+		},
+		{
+			editField,
+			nextChoice,
+		},
+		{
+			saveVars,
+			revertVars,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (f *Form) Init() tea.Cmd {
+	// Decode into empty variables, so the keys removed from the file don't keep their old values.
+	cfg := f.cfg
+	cfg.Variables = config.Variables{}
+	if err := cfg.ReadToStruct(config.VarsConfig); err != nil {
+		f.logger.Zap.Error(err)
+		f.setMessage(err.Error(), true)
+	}
+
+	f.fields, f.editing = fieldsOf(cfg.Variables), false
+	if f.cursor >= len(f.fields) {
+		f.cursor = 0
+	}
+
+	return nil
+}
+
+// Update implements tea.Model.
+func (f *Form) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RepoMsg:
+		f.repo = action.Action(msg)
+		f.setMessage("", false)
+		cmds = append(cmds, f.Init())
+	case tabs.ActiveTabMsg:
+		// The file could be edited in the Config tab in the meantime.
+		if !f.dirty() && !f.editing {
+			cmds = append(cmds, f.Init())
+		}
+	case revisions.RestoredMsg:
+		// The restored file replaces the changes that were not saved.
+		f.setMessage("", false)
+		cmds = append(cmds, f.Init())
+	case tea.KeyMsg:
+		if len(f.fields) == 0 {
+			break
+		}
+		if f.editing {
+			return f, f.updateField(msg)
+		}
+
+		field := f.fields[f.cursor]
+		switch {
+		case key.Matches(msg, f.common.KeyMap.Up):
+			if f.cursor > 0 {
+				f.cursor--
+			}
+			f.setMessage("", false)
+		case key.Matches(msg, f.common.KeyMap.Down):
+			if f.cursor < len(f.fields)-1 {
+				f.cursor++
+			}
+			f.setMessage("", false)
+		case key.Matches(msg, nextChoice) && field.kind == selectField:
+			f.choose(field, 1)
+		case key.Matches(msg, prevChoice) && field.kind == selectField:
+			f.choose(field, -1)
+		case key.Matches(msg, editField):
+			cmds = append(cmds, f.edit(field))
+		case key.Matches(msg, saveVars):
+			cmds = append(cmds, f.save())
+		case key.Matches(msg, revertVars):
+			f.setMessage("", false)
+			cmds = append(cmds, f.Init())
+		}
+	default:
+		// Keep the cursor of the text input blinking.
+		if f.editing && f.input.Focused() {
+			var cmd tea.Cmd
+			f.input, cmd = f.input.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	return f, tea.Batch(cmds...)
+}
+
+// edit toggles a bool or a choice, or gives focus to the text input or the multi-select of the field.
+func (f *Form) edit(field *varField) tea.Cmd {
+	if field.secret && vault.IsEncrypted(field.value.(string)) {
+		f.setMessage(fmt.Sprintf("%s is encrypted, start wdeploy with the vault password to edit it", field.key), true)
+		return nil
+	}
+
+	switch field.kind {
+	case toggleField:
+		f.set(field, !field.value.(bool))
+	case selectField:
+		f.choose(field, 1)
+	case multiSelectField:
+		items := append([]string(nil), field.choices...)
+		for _, v := range field.value.([]string) {
+			items = appendMissing(items, v)
+		}
+
+		f.multi.SetItems(items)
+		f.multi.SetChecked(field.value.([]string))
+		f.multi.SetCursor(0)
+		f.multi.Focus()
+		f.editing = true
+	default:
+		f.input.SetValue(field.value.(string))
+		f.input.EchoMode = textinput.EchoNormal
+		if field.secret {
+			f.input.EchoMode = textinput.EchoPassword
+		}
+		f.input.CursorEnd()
+		f.editing = true
+
+		return f.input.Focus()
+	}
+
+	return nil
+}
+
+// updateField handles a key press while the text input or the multi-select has focus.
+func (f *Form) updateField(msg tea.KeyMsg) tea.Cmd {
+	field := f.fields[f.cursor]
+	switch {
+	case key.Matches(msg, cancelField):
+		f.closeField()
+		f.setMessage("", false)
+
+		return nil
+	case key.Matches(msg, applyField):
+		var value any = f.input.Value()
+		if field.kind == multiSelectField {
+			value = f.multi.Checked()
+		}
+
+		if err := validateField(field, value); err != nil {
+			f.setMessage(err.Error(), true)
+			return nil
+		}
+
+		f.set(field, value)
+		f.closeField()
+
+		return nil
+	}
+
+	if field.kind == multiSelectField {
+		_, cmd := f.multi.Update(msg)
+		return cmd
+	}
+
+	var cmd tea.Cmd
+	f.input, cmd = f.input.Update(msg)
+	if err := validateField(field, f.input.Value()); err != nil {
+		f.setMessage(err.Error(), true)
+	} else {
+		f.setMessage("", false)
+	}
+
+	return cmd
+}
+
+// View implements tea.Model.
+func (f *Form) View() string {
+	if f.editing && f.fields[f.cursor].kind == multiSelectField {
+		return f.multi.View()
+	}
+
+	st := f.common.Styles.Form
+	if len(f.fields) == 0 {
+		return f.messageView()
+	}
+
+	// Keep the cursor visible above the message line.
+	height := f.common.Height - 1
+	if height < 1 {
+		height = 1
+	}
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	}
+	if f.cursor >= f.offset+height {
+		f.offset = f.cursor - height + 1
+	}
+
+	labelWidth := f.labelWidth()
+	s := strings.Builder{}
+	for i := f.offset; i < len(f.fields) && i < f.offset+height; i++ {
+		field := f.fields[i]
+		label := st.Label.Copy().Width(labelWidth).Render(field.key)
+		if i == f.cursor {
+			label = st.FocusedLabel.Copy().Width(labelWidth).Render(field.key)
+		}
+
+		value := f.valueView(field)
+		if f.editing && i == f.cursor {
+			value = f.input.View()
+		}
+
+		mark := "  "
+		if field.changed {
+			mark = st.Modified.Render("● ")
+		}
+
+		s.WriteString(common.TruncateString(fmt.Sprintf("%s%s %s", mark, label, value), f.common.Width))
+		s.WriteString("\n")
+	}
+	s.WriteString(f.messageView())
+
+	return s.String()
+}
+
+func (f *Form) valueView(field *varField) string {
+	st := f.common.Styles.Checklist
+	switch field.kind {
+	case toggleField:
+		if field.value.(bool) {
+			return st.Checked.Render("[x] true")
+		}
+
+		return st.Unchecked.Render("[ ] false")
+	case selectField:
+		choices := make([]string, 0, len(field.choices))
+		for _, c := range field.choices {
+			if c == field.value {
+				choices = append(choices, st.Cursor.Render(c))
+			} else {
+				choices = append(choices, st.Unchecked.Render(c))
+			}
+		}
+
+		return strings.Join(choices, " ")
+	case multiSelectField:
+		return st.Item.Render(strings.Join(field.value.([]string), ", "))
+	}
+
+	value := field.value.(string)
+	if field.secret && value != "" {
+		value = "********"
+	}
+
+	if err := validateField(field, field.value); err != nil {
+		return fmt.Sprintf("%s %s", st.Item.Render(value), f.common.Styles.Form.Error.Render("✗ "+err.Error()))
+	}
+
+	return st.Item.Render(value)
+}
+
+func (f *Form) messageView() string {
+	st := f.common.Styles.Form
+	switch {
+	case f.message != "" && f.failed:
+		return st.Error.Render("✗ " + f.message)
+	case f.message != "":
+		return st.Info.Render(f.message)
+	case f.dirty():
+		return st.Modified.Render("● unsaved changes, press ctrl+s to save")
+	}
+
+	return ""
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (f *Form) StatusBarValue() string {
+	return f.cfg.ConfigFiles[config.VarsConfig]
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (f *Form) StatusBarInfo() string {
+	if f.dirty() {
+		return fmt.Sprintf("%d variable(s), modified", len(f.fields))
+	}
+
+	return fmt.Sprintf("%d variable(s)", len(f.fields))
+}
+
+// StatusBarBranch implements statusbar.StatusBar.
+func (f *Form) StatusBarBranch() string {
+	return fmt.Sprintf("v%s", f.cfg.WebitelVersion)
+}
+
+func (f *Form) set(field *varField, value any) {
+	field.value, field.changed = value, true
+	f.setMessage("", false)
+}
+
+// choose moves a select field to the next or the previous choice.
+func (f *Form) choose(field *varField, step int) {
+	i := 0
+	for n, c := range field.choices {
+		if c == field.value {
+			i = n + step
+		}
+	}
+
+	f.set(field, field.choices[(i+len(field.choices))%len(field.choices)])
+}
+
+func (f *Form) closeField() {
+	f.editing = false
+	f.input.Blur()
+	f.multi.Blur()
+}
+
+func (f *Form) dirty() bool {
+	for _, field := range f.fields {
+		if field.changed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *Form) labelWidth() int {
+	width := 0
+	for _, field := range f.fields {
+		if len(field.key) > width {
+			width = len(field.key)
+		}
+	}
+
+	return width + 1
+}
+
+func (f *Form) save() tea.Cmd {
+	values := make([]config.VariableValue, 0)
+	for _, field := range f.fields {
+		if field.changed {
+			values = append(values, config.VariableValue{Key: field.key, Value: fieldValue(field)})
+		}
+	}
+
+	if len(values) == 0 {
+		f.setMessage("Nothing to save", false)
+		return nil
+	}
+
+	if err := f.cfg.SetVariables(values); err != nil {
+		f.logger.Zap.Error(err)
+		f.setMessage(err.Error(), true)
+
+		return nil
+	}
+
+	if _, err := f.cfg.EncryptSecrets(config.VarsConfig); err != nil {
+		f.logger.Zap.Error(err)
+	}
+
+	f.Init()
+	f.setMessage(fmt.Sprintf("Saved to %s", f.cfg.ConfigFiles[config.VarsConfig]), false)
+
+	return func() tea.Msg {
+		return VarsSavedMsg{}
+	}
+}
+
+func (f *Form) setMessage(message string, failed bool) {
+	f.message, f.failed = message, failed
+}
+
+// fieldsOf returns the form fields of the variables in the order of the struct.
+func fieldsOf(v config.Variables) []*varField {
+	rv := reflect.ValueOf(v)
+	fields := make([]*varField, 0, rv.NumField())
+	for i := 0; i < rv.NumField(); i++ {
+		name, _, _ := strings.Cut(rv.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		field := &varField{key: name, choices: vars.Choices[name]}
+		for _, s := range vars.SecretKeys {
+			field.secret = field.secret || s == name
+		}
+
+		fv := rv.Field(i)
+		switch fv.Kind() {
+		case reflect.Bool:
+			field.kind, field.value = toggleField, fv.Bool()
+		case reflect.Int:
+			field.kind, field.value = numberField, ""
+			if fv.Int() != 0 {
+				field.value = strconv.FormatInt(fv.Int(), 10)
+			}
+		case reflect.Slice:
+			field.kind, field.value = multiSelectField, append([]string{}, fv.Interface().([]string)...)
+		default:
+			field.kind, field.value = textField, fv.String()
+			// A value out of the choices can only be fixed in the text input.
+			if field.choices != nil && (fv.String() == "" || contains(field.choices, fv.String())) {
+				field.kind = selectField
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// validateField checks a value of the field before it is applied.
+func validateField(field *varField, value any) error {
+	switch field.kind {
+	case numberField:
+		if s := value.(string); s != "" {
+			port, err := strconv.Atoi(s)
+			if err != nil || port < 1 || port > 65535 {
+				return fmt.Errorf("expected a port number between 1 and 65535, got %q", s)
+			}
+		}
+	case textField:
+		return validator.ValidateVar(field.key, value.(string))
+	}
+
+	return nil
+}
+
+// fieldValue returns the value of the field to write to the file, nil removes an empty Ansible connection
+// variable from the file so the default of Ansible applies.
+func fieldValue(field *varField) any {
+	switch field.kind {
+	case numberField:
+		if field.value == "" {
+			return nil
+		}
+
+		port, _ := strconv.Atoi(field.value.(string))
+		return port
+	case textField:
+		if field.value == "" && strings.HasPrefix(field.key, "ansible_") {
+			return nil
+		}
+	}
+
+	return field.value
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendMissing(items []string, item string) []string {
+	if contains(items, item) {
+		return items
+	}
+
+	return append(items, item)
+}
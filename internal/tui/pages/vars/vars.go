@@ -11,6 +11,8 @@ import (
 	"github.com/kirychukyurii/wdeploy/internal/tui/common"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/action"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/footer"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/problems"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/revisions"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/statusbar"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/tabs"
 )
@@ -19,6 +21,9 @@ type tab int
 
 const (
 	configTab tab = iota
+	formTab
+	problemsTab
+	revisionsTab
 
 	lastTab
 )
@@ -26,6 +31,9 @@ const (
 func (t tab) String() string {
 	return []string{
 		"Config",
+		"Form",
+		"Problems",
+		"Revisions",
 	}[t]
 }
 
@@ -60,16 +68,22 @@ func New(c common.Common, cfg config.Config, logger logger.Logger) *Vars {
 	sb := statusbar.New(c)
 	ts := make([]string, lastTab)
 	// Tabs must match the order of tab constants above.
-	for i, t := range []tab{configTab} {
+	for i, t := range []tab{configTab, formTab, problemsTab, revisionsTab} {
 		ts[i] = t.String()
 	}
 	tb := tabs.New(c, ts)
 
+	problemList := problems.New(c, cfg, config.VarsConfig, logger)
+	revisionList := revisions.New(c, cfg, config.VarsConfig, logger)
 	config := NewConfig(c, cfg, logger)
+	form := NewForm(c, cfg, logger)
 
 	// Make sure the order matches the order of tab constants above.
 	panes := []common.Component{
 		config,
+		form,
+		problemList,
+		revisionList,
 	}
 
 	v := &Vars{
@@ -123,6 +137,18 @@ func (v *Vars) FullHelp() [][]key.Binding {
 	return b
 }
 
+// IsEditing reports whether a field of the Form tab or the restore dialog of the Revisions tab takes key presses.
+func (v *Vars) IsEditing() bool {
+	switch p := v.panes[v.activeTab].(type) {
+	case *Form:
+		return p.IsEditing()
+	case *revisions.Revisions:
+		return p.IsConfirming()
+	}
+
+	return false
+}
+
 // Init implements tea.View.
 func (v *Vars) Init() tea.Cmd {
 	return tea.Batch(
@@ -143,6 +169,11 @@ func (v *Vars) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			v.updateStatusBarCmd,
 			v.updateModels(msg),
 		)
+	case VarsSavedMsg, revisions.RestoredMsg:
+		// Every pane shows the file, the pane that wrote it has already reloaded it.
+		cmds = append(cmds, v.updateModels(msg), v.updateStatusBarCmd)
+
+		return v, tea.Batch(cmds...)
 	case tabs.SelectTabMsg:
 		v.activeTab = tab(msg)
 		t, cmd := v.tabs.Update(msg)
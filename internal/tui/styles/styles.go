@@ -135,6 +135,51 @@ type Styles struct {
 
 	CodeNoContent lipgloss.Style
 
+	Problems struct {
+		Error      lipgloss.Style
+		Warning    lipgloss.Style
+		Location   lipgloss.Style
+		NoProblems lipgloss.Style
+	}
+
+	Progress struct {
+		Header      lipgloss.Style
+		Task        lipgloss.Style
+		Pending     lipgloss.Style
+		Ok          lipgloss.Style
+		Changed     lipgloss.Style
+		Failed      lipgloss.Style
+		Skipped     lipgloss.Style
+		Unreachable lipgloss.Style
+		NoEvents    lipgloss.Style
+	}
+
+	History struct {
+		Header    lipgloss.Style
+		Row       lipgloss.Style
+		Selected  lipgloss.Style
+		Succeeded lipgloss.Style
+		Failed    lipgloss.Style
+		Running   lipgloss.Style
+		NoRuns    lipgloss.Style
+	}
+
+	Checklist struct {
+		Title     lipgloss.Style
+		Item      lipgloss.Style
+		Cursor    lipgloss.Style
+		Checked   lipgloss.Style
+		Unchecked lipgloss.Style
+	}
+
+	Form struct {
+		Label        lipgloss.Style
+		FocusedLabel lipgloss.Style
+		Error        lipgloss.Style
+		Info         lipgloss.Style
+		Modified     lipgloss.Style
+	}
+
 	StatusBar       lipgloss.Style
 	StatusBarKey    lipgloss.Style
 	StatusBarValue  lipgloss.Style
@@ -452,6 +497,108 @@ func DefaultStyles() *Styles {
 		MarginLeft(2).
 		Foreground(lipgloss.Color("242"))
 
+	s.Problems.Error = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("203")).
+		Bold(true)
+
+	s.Problems.Warning = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214")).
+		Bold(true)
+
+	s.Problems.Location = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243"))
+
+	s.Problems.NoProblems = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("42"))
+
+	s.Progress.Header = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243")).
+		Bold(true)
+
+	s.Progress.Task = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	s.Progress.Pending = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("239"))
+
+	s.Progress.Ok = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("42"))
+
+	s.Progress.Changed = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214"))
+
+	s.Progress.Failed = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("203")).
+		Bold(true)
+
+	s.Progress.Skipped = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243"))
+
+	s.Progress.Unreachable = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("171")).
+		Bold(true)
+
+	s.Progress.NoEvents = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243"))
+
+	s.History.Header = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243")).
+		Bold(true)
+
+	s.History.Row = lipgloss.NewStyle().
+		PaddingLeft(1)
+
+	s.History.Selected = lipgloss.NewStyle().
+		PaddingLeft(1).
+		Foreground(lipgloss.Color("212")).
+		Bold(true)
+
+	s.History.Succeeded = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("42"))
+
+	s.History.Failed = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("203"))
+
+	s.History.Running = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214"))
+
+	s.History.NoRuns = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243"))
+
+	s.Checklist.Title = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243")).
+		Bold(true)
+
+	s.Checklist.Item = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	s.Checklist.Cursor = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("212")).
+		Bold(true)
+
+	s.Checklist.Checked = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("42"))
+
+	s.Checklist.Unchecked = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("239"))
+
+	s.Form.Label = lipgloss.NewStyle().
+		Width(14).
+		Foreground(lipgloss.Color("243"))
+
+	s.Form.FocusedLabel = s.Form.Label.Copy().
+		Foreground(lipgloss.Color("212")).
+		Bold(true)
+
+	s.Form.Error = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("203"))
+
+	s.Form.Info = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("42"))
+
+	s.Form.Modified = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214"))
+
 	s.StatusBar = lipgloss.NewStyle().
 		Height(1)
 
@@ -1,18 +1,23 @@
 package tui
 
 import (
+	"fmt"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kirychukyurii/wdeploy/internal/config"
+	"github.com/kirychukyurii/wdeploy/internal/lib/deployment"
 	"github.com/kirychukyurii/wdeploy/internal/lib/logger"
 	"github.com/kirychukyurii/wdeploy/internal/tui/common"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/footer"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/header"
+	"github.com/kirychukyurii/wdeploy/internal/tui/components/revisions"
 	"github.com/kirychukyurii/wdeploy/internal/tui/components/selector"
 	"github.com/kirychukyurii/wdeploy/internal/tui/pages/deploy"
+	"github.com/kirychukyurii/wdeploy/internal/tui/pages/history"
 	"github.com/kirychukyurii/wdeploy/internal/tui/pages/inventory"
+	"github.com/kirychukyurii/wdeploy/internal/tui/pages/profiles"
 	"github.com/kirychukyurii/wdeploy/internal/tui/pages/selection"
 	"github.com/kirychukyurii/wdeploy/internal/tui/pages/vars"
 )
@@ -24,6 +29,8 @@ const (
 	varsPage
 	hostsPage
 	deployPage
+	historyPage
+	profilesPage
 )
 
 type sessionState int
@@ -34,6 +41,11 @@ const (
 	loadedState
 )
 
+// editor is a page with text inputs.
+type editor interface {
+	IsEditing() bool
+}
+
 // UI is the main UI model.
 type UI struct {
 	common     common.Common
@@ -45,27 +57,32 @@ type UI struct {
 	showFooter bool
 	error      error
 	cfg        config.Config
+	runs       *deployment.Runs
 	logger     logger.Logger
 }
 
-// New returns a new UI model.
-func New(c common.Common, cfg config.Config, logger logger.Logger) *UI {
-	h := header.New(c, "wdeploy")
-
+// New returns a new UI model, deploys are started with runs.
+func New(c common.Common, cfg config.Config, logger logger.Logger, runs *deployment.Runs) *UI {
 	ui := &UI{
 		common:     c,
-		pages:      make([]common.Component, 4), // pages
+		pages:      make([]common.Component, 6), // pages
 		activePage: selectionPage,
 		state:      startState,
-		header:     h,
+		header:     newHeader(c, cfg),
 		showFooter: true,
 		cfg:        cfg,
+		runs:       runs,
 		logger:     logger,
 	}
 	ui.footer = footer.New(c, ui)
 	return ui
 }
 
+// newHeader returns the header of the selection page with the active profile.
+func newHeader(c common.Common, cfg config.Config) *header.Header {
+	return header.New(c, fmt.Sprintf("wdeploy · %s", cfg.Profile))
+}
+
 func (ui *UI) getMargins() (wm, hm int) {
 	style := ui.common.Styles.App.Copy()
 	switch ui.activePage {
@@ -141,7 +158,9 @@ func (ui *UI) Init() tea.Cmd {
 	ui.pages[selectionPage] = selection.New(ui.common, ui.logger)
 	ui.pages[varsPage] = vars.New(ui.common, ui.cfg, ui.logger)
 	ui.pages[hostsPage] = inventory.New(ui.common, ui.cfg, ui.logger)
-	ui.pages[deployPage] = deploy.New(ui.common, ui.cfg, ui.logger)
+	ui.pages[deployPage] = deploy.New(ui.common, ui.cfg, ui.logger, ui.runs)
+	ui.pages[historyPage] = history.New(ui.common, ui.cfg, ui.logger)
+	ui.pages[profilesPage] = profiles.New(ui.common, ui.cfg, ui.logger)
 
 	/*
 		ui.pages[varsPage] = vars.New(
@@ -162,6 +181,8 @@ func (ui *UI) Init() tea.Cmd {
 		ui.pages[varsPage].Init(),
 		ui.pages[hostsPage].Init(),
 		ui.pages[deployPage].Init(),
+		ui.pages[historyPage].Init(),
+		ui.pages[profilesPage].Init(),
 
 		/*
 			ui.pages[varsPage].Init(),
@@ -175,13 +196,17 @@ func (ui *UI) Init() tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-// IsFiltering returns true if the selection page is filtering.
+// IsFiltering returns true if the selection page is filtering or the active page takes text input,
+// key presses then go to the page instead of the global key bindings.
 func (ui *UI) IsFiltering() bool {
 	if ui.activePage == selectionPage {
 		if s, ok := ui.pages[selectionPage].(*selection.Selection); ok && s.FilterState() == list.Filtering {
 			return true
 		}
 	}
+	if e, ok := ui.pages[ui.activePage].(editor); ok && e.IsEditing() {
+		return true
+	}
 	return false
 }
 
@@ -211,15 +236,22 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				// Always show the footer on error.
 				ui.showFooter = ui.footer.ShowAll()
+			case ui.IsFiltering():
+				// The key is text input, the active page gets it below.
 			case key.Matches(msg, ui.common.KeyMap.Help):
 				cmds = append(cmds, footer.ToggleFooterCmd)
 			case key.Matches(msg, ui.common.KeyMap.Quit):
-				if !ui.IsFiltering() {
-					// Stop bubble-zone background workers.
-					ui.common.Zone.Close()
-
-					return ui, tea.Quit
+				// A running deploy is aborted first, the deploy page quits once it is over.
+				if d, ok := ui.pages[deployPage].(*deploy.Deploy); ok && d.Running() {
+					ui.activePage = deployPage
+					ui.showFooter = ui.footer.ShowAll()
+					ui.error = nil
+					ui.state = loadedState
+
+					return ui, d.ConfirmQuit()
 				}
+
+				return ui, ui.quit()
 			case ui.activePage != selectionPage && key.Matches(msg, ui.common.KeyMap.Back):
 				ui.activePage = selectionPage
 				// Always show the footer on selection page.
@@ -264,6 +296,12 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "deploy":
 				ui.activePage = deployPage
 				ui.showFooter = ui.footer.ShowAll()
+			case "history":
+				ui.activePage = historyPage
+				ui.showFooter = ui.footer.ShowAll()
+			case "profiles":
+				ui.activePage = profilesPage
+				ui.showFooter = ui.footer.ShowAll()
 			}
 			/*
 				case selector.ActiveMsg:
@@ -280,6 +318,38 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				ui.showFooter = ui.footer.ShowAll()
 		*/
 
+	case deploy.QuitMsg:
+		return ui, ui.quit()
+
+	// The deploy page shows the config files saved on the other pages, the active page gets the message below.
+	case vars.VarsSavedMsg:
+		cmds = append(cmds, ui.updateDeployPage(deploy.ConfigChangedMsg{ConfigFileType: config.VarsConfig}))
+	case inventory.InventorySavedMsg:
+		cmds = append(cmds, ui.updateDeployPage(deploy.ConfigChangedMsg{ConfigFileType: config.InventoryConfig}))
+	case revisions.RestoredMsg:
+		cmds = append(cmds, ui.updateDeployPage(deploy.ConfigChangedMsg{ConfigFileType: msg.ConfigFileType}))
+
+	// A running deploy keeps sending output while another page is shown.
+	case deploy.LogMsg, deploy.EventMsg, deploy.DeployFinishedMsg:
+		m, cmd := ui.pages[deployPage].Update(msg)
+		ui.pages[deployPage] = m.(common.Component)
+
+		return ui, cmd
+
+	// Every page is created again for the picked profile, the menu is shown first.
+	case profiles.SwitchProfileMsg:
+		if d, ok := ui.pages[deployPage].(*deploy.Deploy); ok && d.Running() {
+			return ui, common.ErrorCmd(fmt.Errorf("a deploy of profile %s is running, switch the profile when it finishes", ui.cfg.Profile))
+		}
+
+		ui.logger.Zap.Infof("Switched to profile %s", msg.Config.Profile)
+		ui.cfg = msg.Config
+		ui.header = newHeader(ui.common, ui.cfg)
+		ui.activePage = selectionPage
+		ui.showFooter = true
+
+		return ui, ui.Init()
+
 	case common.ErrorMsg:
 		ui.error = msg
 		ui.state = errorState
@@ -327,6 +397,26 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return ui, tea.Batch(cmds...)
 }
 
+// updateDeployPage sends msg to the deploy page.
+func (ui *UI) updateDeployPage(msg tea.Msg) tea.Cmd {
+	if ui.pages[deployPage] == nil {
+		return nil
+	}
+
+	m, cmd := ui.pages[deployPage].Update(msg)
+	ui.pages[deployPage] = m.(common.Component)
+
+	return cmd
+}
+
+// quit stops the application.
+func (ui *UI) quit() tea.Cmd {
+	// Stop bubble-zone background workers.
+	ui.common.Zone.Close()
+
+	return tea.Quit
+}
+
 // View implements tea.Model.
 func (ui *UI) View() string {
 	var view string